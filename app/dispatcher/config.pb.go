@@ -3,6 +3,7 @@ package dispatcher
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import v2ray_core_common_serial "v2ray.com/core/common/serial"
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -23,14 +24,45 @@ func (m *SessionConfig) String() string            { return proto.CompactTextStr
 func (*SessionConfig) ProtoMessage()               {}
 func (*SessionConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+type SniffingConfig struct {
+	// Total time in milliseconds to wait for data of a connection to be sniffed. Default value is 300 if unset.
+	Timeout uint32 `protobuf:"varint,1,opt,name=timeout" json:"timeout,omitempty"`
+	// Maximum number of bytes to be sniffed. Default value is 2048 for TCP and 8192 for UDP if unset.
+	ByteLimit uint32 `protobuf:"varint,2,opt,name=byte_limit,json=byteLimit" json:"byte_limit,omitempty"`
+}
+
+func (m *SniffingConfig) Reset()                    { *m = SniffingConfig{} }
+func (m *SniffingConfig) String() string            { return proto.CompactTextString(m) }
+func (*SniffingConfig) ProtoMessage()               {}
+func (*SniffingConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *SniffingConfig) GetTimeout() uint32 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *SniffingConfig) GetByteLimit() uint32 {
+	if m != nil {
+		return m.ByteLimit
+	}
+	return 0
+}
+
 type Config struct {
-	Settings *SessionConfig `protobuf:"bytes,1,opt,name=settings" json:"settings,omitempty"`
+	Settings *SessionConfig  `protobuf:"bytes,1,opt,name=settings" json:"settings,omitempty"`
+	Sniffing *SniffingConfig `protobuf:"bytes,2,opt,name=sniffing" json:"sniffing,omitempty"`
+	// Settings of interceptors, which are called in order for every dispatched connection. Each setting must be
+	// registered with a creator of dispatcher.Interceptor, by V2Ray itself or by plugins.
+	Interceptor []*v2ray_core_common_serial.TypedMessage `protobuf:"bytes,3,rep,name=interceptor" json:"interceptor,omitempty"`
+	Fallback    *FallbackConfig                          `protobuf:"bytes,4,opt,name=fallback" json:"fallback,omitempty"`
 }
 
 func (m *Config) Reset()                    { *m = Config{} }
 func (m *Config) String() string            { return proto.CompactTextString(m) }
 func (*Config) ProtoMessage()               {}
-func (*Config) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*Config) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
 func (m *Config) GetSettings() *SessionConfig {
 	if m != nil {
@@ -39,24 +71,77 @@ func (m *Config) GetSettings() *SessionConfig {
 	return nil
 }
 
+func (m *Config) GetSniffing() *SniffingConfig {
+	if m != nil {
+		return m.Sniffing
+	}
+	return nil
+}
+
+func (m *Config) GetInterceptor() []*v2ray_core_common_serial.TypedMessage {
+	if m != nil {
+		return m.Interceptor
+	}
+	return nil
+}
+
+func (m *Config) GetFallback() *FallbackConfig {
+	if m != nil {
+		return m.Fallback
+	}
+	return nil
+}
+
+type FallbackConfig struct {
+	// Maximum number of bytes of a connection to be buffered for retrying with fallback outbounds. Default value is
+	// 16384 if unset.
+	BufferSize uint32 `protobuf:"varint,1,opt,name=buffer_size,json=bufferSize" json:"buffer_size,omitempty"`
+}
+
+func (m *FallbackConfig) Reset()                    { *m = FallbackConfig{} }
+func (m *FallbackConfig) String() string            { return proto.CompactTextString(m) }
+func (*FallbackConfig) ProtoMessage()               {}
+func (*FallbackConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *FallbackConfig) GetBufferSize() uint32 {
+	if m != nil {
+		return m.BufferSize
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*SessionConfig)(nil), "v2ray.core.app.dispatcher.SessionConfig")
+	proto.RegisterType((*SniffingConfig)(nil), "v2ray.core.app.dispatcher.SniffingConfig")
 	proto.RegisterType((*Config)(nil), "v2ray.core.app.dispatcher.Config")
+	proto.RegisterType((*FallbackConfig)(nil), "v2ray.core.app.dispatcher.FallbackConfig")
 }
 
 func init() { proto.RegisterFile("v2ray.com/core/app/dispatcher/config.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 176 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xd2, 0x2a, 0x33, 0x2a, 0x4a,
-	0xac, 0xd4, 0x4b, 0xce, 0xcf, 0xd5, 0x4f, 0xce, 0x2f, 0x4a, 0xd5, 0x4f, 0x2c, 0x28, 0xd0, 0x4f,
-	0xc9, 0x2c, 0x2e, 0x48, 0x2c, 0x49, 0xce, 0x48, 0x2d, 0xd2, 0x4f, 0xce, 0xcf, 0x4b, 0xcb, 0x4c,
-	0xd7, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x92, 0x84, 0xa9, 0x2d, 0x4a, 0xd5, 0x4b, 0x2c, 0x28,
-	0xd0, 0x43, 0xa8, 0x53, 0x12, 0xe5, 0xe2, 0x0d, 0x4e, 0x2d, 0x2e, 0xce, 0xcc, 0xcf, 0x73, 0x06,
-	0xeb, 0xf0, 0x62, 0xe1, 0x60, 0x14, 0x60, 0x52, 0xf2, 0xe3, 0x62, 0x83, 0xf0, 0x85, 0x5c, 0xb8,
-	0x38, 0x8a, 0x53, 0x4b, 0x4a, 0x32, 0xf3, 0xd2, 0x8b, 0x25, 0x18, 0x15, 0x18, 0x35, 0xb8, 0x8d,
-	0x34, 0xf4, 0x70, 0x1a, 0xa7, 0x87, 0x62, 0x56, 0x10, 0x5c, 0xa7, 0x93, 0x27, 0x97, 0x6c, 0x72,
-	0x7e, 0x2e, 0x6e, 0x8d, 0x01, 0x8c, 0x51, 0x5c, 0x08, 0xde, 0x2a, 0x26, 0xc9, 0x30, 0xa3, 0xa0,
-	0xc4, 0x4a, 0x3d, 0x67, 0x90, 0x4a, 0xc7, 0x82, 0x02, 0x3d, 0x17, 0xb8, 0x5c, 0x12, 0x1b, 0xd8,
-	0x4f, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff, 0x4b, 0x47, 0xc5, 0xd6, 0x01, 0x01, 0x00, 0x00,
+	// 356 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0x4d, 0x4b, 0xeb, 0x40,
+	0x14, 0x25, 0x69, 0xe9, 0xeb, 0x9b, 0xd0, 0xf2, 0x08, 0x3c, 0x48, 0x1f, 0x94, 0x57, 0xb2, 0x90,
+	0xea, 0x62, 0xa2, 0xf1, 0x17, 0x68, 0xab, 0x58, 0x51, 0x90, 0x54, 0x5c, 0xb8, 0x29, 0x93, 0xe9,
+	0x4d, 0x1c, 0x4c, 0x32, 0xc3, 0xcc, 0x54, 0x48, 0x7f, 0x90, 0x0b, 0x7f, 0xa5, 0xe4, 0xab, 0x1f,
+	0x42, 0x75, 0x39, 0xe7, 0x9e, 0x73, 0xee, 0xb9, 0x87, 0x41, 0x27, 0x6f, 0xbe, 0x24, 0x39, 0xa6,
+	0x3c, 0xf5, 0x28, 0x97, 0xe0, 0x11, 0x21, 0xbc, 0x25, 0x53, 0x82, 0x68, 0xfa, 0x02, 0xd2, 0xa3,
+	0x3c, 0x8b, 0x58, 0x8c, 0x85, 0xe4, 0x9a, 0xdb, 0x83, 0x86, 0x2b, 0x01, 0x13, 0x21, 0xf0, 0x96,
+	0xf7, 0xef, 0xf4, 0x8b, 0x0d, 0xe5, 0x69, 0xca, 0x33, 0x4f, 0x81, 0x64, 0x24, 0xf1, 0x74, 0x2e,
+	0x60, 0xb9, 0x48, 0x41, 0x29, 0x12, 0x43, 0x65, 0xe6, 0xfe, 0x45, 0xbd, 0x39, 0x28, 0xc5, 0x78,
+	0x36, 0x29, 0x77, 0xdc, 0xb6, 0xbb, 0xc6, 0x1f, 0xd3, 0x9d, 0xa1, 0xfe, 0x3c, 0x63, 0x51, 0xc4,
+	0xb2, 0xb8, 0xc2, 0x6d, 0x07, 0xfd, 0xd2, 0x2c, 0x05, 0xbe, 0xd2, 0x8e, 0x31, 0x32, 0xc6, 0xbd,
+	0xa0, 0x79, 0xda, 0x43, 0x84, 0xc2, 0x5c, 0xc3, 0x22, 0x61, 0x29, 0xd3, 0x8e, 0x59, 0x0e, 0x7f,
+	0x17, 0xc8, 0x5d, 0x01, 0xb8, 0xef, 0x26, 0xea, 0xd4, 0x1e, 0x53, 0xd4, 0x55, 0xa0, 0x35, 0xcb,
+	0x62, 0x55, 0x9a, 0x58, 0xfe, 0x18, 0x1f, 0x3c, 0x06, 0xef, 0xe5, 0x0a, 0x36, 0x4a, 0xfb, 0x0a,
+	0x75, 0x55, 0x9d, 0xad, 0xdc, 0x66, 0xf9, 0xc7, 0xdf, 0xb9, 0xec, 0x9d, 0x11, 0x6c, 0xa4, 0xf6,
+	0x0d, 0xb2, 0x58, 0xa6, 0x41, 0x52, 0x10, 0x9a, 0x4b, 0xa7, 0x35, 0x6a, 0x8d, 0x2d, 0xff, 0x68,
+	0xd7, 0xa9, 0x6a, 0x0f, 0x57, 0xed, 0xe1, 0xc7, 0xa2, 0xbd, 0xfb, 0xaa, 0xbc, 0x60, 0x57, 0x5a,
+	0x04, 0x8a, 0x48, 0x92, 0x84, 0x84, 0xbe, 0x3a, 0xed, 0x1f, 0x03, 0x5d, 0xd7, 0xd4, 0x26, 0x50,
+	0x23, 0x75, 0xcf, 0x50, 0x7f, 0x7f, 0x66, 0xff, 0x47, 0x56, 0xb8, 0x8a, 0x22, 0x90, 0x0b, 0xc5,
+	0xd6, 0x50, 0xf7, 0x8e, 0x2a, 0x68, 0xce, 0xd6, 0x70, 0x39, 0x43, 0x43, 0xca, 0xd3, 0xc3, 0xcb,
+	0x1e, 0x8c, 0x67, 0xb4, 0x7d, 0x7d, 0x98, 0x83, 0x27, 0x3f, 0x20, 0x39, 0x9e, 0x14, 0xcc, 0x0b,
+	0x21, 0xf0, 0x74, 0x33, 0x0b, 0x3b, 0xe5, 0x7f, 0x38, 0xff, 0x1c, 0x00, 0xb8, 0x28, 0xfd, 0x87,
+	0x8a, 0x02, 0x00, 0x00,
 }
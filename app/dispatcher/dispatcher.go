@@ -2,6 +2,7 @@ package dispatcher
 
 import (
 	"context"
+	"time"
 
 	"v2ray.com/core/app"
 	"v2ray.com/core/common/net"
@@ -13,6 +14,31 @@ type Interface interface {
 	Dispatch(ctx context.Context, dest net.Destination) (ray.InboundRay, error)
 }
 
+// Interceptor intercepts connections being dispatched. Interceptors are called in the order they are configured.
+type Interceptor interface {
+	// BeforeRouting is called before the outbound is chosen. It returns the context and destination to be used
+	// afterwards, or an error to reject the connection.
+	BeforeRouting(ctx context.Context, dest net.Destination) (context.Context, net.Destination, error)
+
+	// AfterRouting is called after the outbound is chosen. tag is empty if the default outbound is chosen.
+	// It returns an error to reject the connection.
+	AfterRouting(ctx context.Context, dest net.Destination, tag string) error
+
+	// OnFinished is called when the connection ends, or is rejected.
+	OnFinished(ctx context.Context, result *Result)
+}
+
+// Result is the result of dispatching a connection.
+type Result struct {
+	Destination net.Destination
+	// Tag is the tag of the chosen outbound. It is empty if the default outbound is chosen.
+	Tag string
+	// Err is the reason why the connection is rejected or the outbound fails, or nil if the connection ends normally.
+	Err error
+	// Duration is the time from routing to the end of the connection.
+	Duration time.Duration
+}
+
 func FromSpace(space app.Space) Interface {
 	if app := space.GetApplication((*Interface)(nil)); app != nil {
 		return app.(Interface)
@@ -0,0 +1,264 @@
+package impl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/transport/ray"
+)
+
+// userBucketCleanupInterval is the interval to remove idle per user buckets.
+const userBucketCleanupInterval = time.Minute
+
+// bandwidthBucket is a token bucket of bytes. It may go into debt, so that a large write is delayed instead of
+// rejected.
+type bandwidthBucket struct {
+	sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBandwidthBucket(rate uint64, burst uint64, now time.Time) *bandwidthBucket {
+	if burst == 0 {
+		burst = rate
+	}
+	return &bandwidthBucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+func (b *bandwidthBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// reserve takes n bytes from the bucket, and returns the time to wait until they are available.
+func (b *bandwidthBucket) reserve(now time.Time, n int) time.Duration {
+	b.Lock()
+	defer b.Unlock()
+
+	b.refill(now)
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// isFull returns true if the bucket is in the same state as a new one.
+func (b *bandwidthBucket) isFull(now time.Time) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// userBandwidth is the buckets shared by all connections of a user.
+type userBandwidth struct {
+	uplink   *bandwidthBucket
+	downlink *bandwidthBucket
+	// connections is the number of live connections using the buckets, guarded by the lock of the manager.
+	connections int
+}
+
+// isIdle returns true if the buckets can be removed without giving the user more bandwidth.
+func (u *userBandwidth) isIdle(now time.Time) bool {
+	return u.connections == 0 && (u.uplink == nil || u.uplink.isFull(now)) && (u.downlink == nil || u.downlink.isFull(now))
+}
+
+// bandwidthManager enforces the bandwidth limits in policies, for each connection and for each user.
+type bandwidthManager struct {
+	sync.Mutex
+	users       map[string]*userBandwidth
+	lastCleanup time.Time
+}
+
+func newBandwidthManager() *bandwidthManager {
+	return &bandwidthManager{
+		users:       make(map[string]*userBandwidth),
+		lastCleanup: time.Now(),
+	}
+}
+
+// acquireUser returns the buckets of the user with the given email for a new connection, creating them if necessary.
+// The buckets are released when the context is done.
+func (m *bandwidthManager) acquireUser(ctx context.Context, email string, config *policy.Policy_Bandwidth, now time.Time) *userBandwidth {
+	m.Lock()
+	defer m.Unlock()
+
+	if now.Sub(m.lastCleanup) > userBucketCleanupInterval {
+		for e, u := range m.users {
+			if u.isIdle(now) {
+				delete(m.users, e)
+			}
+		}
+		m.lastCleanup = now
+	}
+
+	u := m.users[email]
+	if u == nil {
+		u = &userBandwidth{}
+		if config.UserUplink > 0 {
+			u.uplink = newBandwidthBucket(config.UserUplink, config.Burst, now)
+		}
+		if config.UserDownlink > 0 {
+			u.downlink = newBandwidthBucket(config.UserDownlink, config.Burst, now)
+		}
+		m.users[email] = u
+	}
+	u.connections++
+	go func() {
+		<-ctx.Done()
+		m.Lock()
+		u.connections--
+		m.Unlock()
+	}()
+	return u
+}
+
+// limit returns the given ray with the bandwidth limits of the user in the context applied. The limits of all
+// connections of a user together only apply to users with an email.
+func (m *bandwidthManager) limit(ctx context.Context, config *policy.Policy_Bandwidth, inbound ray.InboundRay) ray.InboundRay {
+	if config == nil {
+		return inbound
+	}
+
+	now := time.Now()
+	var uplink, downlink bandwidthLimiter
+	if config.Uplink > 0 {
+		uplink = append(uplink, newBandwidthBucket(config.Uplink, config.Burst, now))
+	}
+	if config.Downlink > 0 {
+		downlink = append(downlink, newBandwidthBucket(config.Downlink, config.Burst, now))
+	}
+	if user := protocol.UserFromContext(ctx); user != nil && len(user.Email) > 0 && (config.UserUplink > 0 || config.UserDownlink > 0) {
+		u := m.acquireUser(ctx, user.Email, config, now)
+		if u.uplink != nil {
+			uplink = append(uplink, u.uplink)
+		}
+		if u.downlink != nil {
+			downlink = append(downlink, u.downlink)
+		}
+	}
+	if len(uplink) == 0 && len(downlink) == 0 {
+		return inbound
+	}
+
+	limited := &limitedRay{
+		input:  inbound.InboundInput(),
+		output: inbound.InboundOutput(),
+	}
+	if len(uplink) > 0 {
+		limited.input = &limitedOutputStream{
+			OutputStream: limited.input,
+			ctx:          ctx,
+			limiter:      uplink,
+		}
+	}
+	if len(downlink) > 0 {
+		limited.output = &limitedInputStream{
+			InputStream: limited.output,
+			ctx:         ctx,
+			limiter:     downlink,
+		}
+	}
+	return limited
+}
+
+// bandwidthLimiter is the buckets that all traffic in one direction of a connection must go through.
+type bandwidthLimiter []*bandwidthBucket
+
+// wait takes n bytes from all buckets, and waits until they are available.
+func (l bandwidthLimiter) wait(ctx context.Context, n int) error {
+	now := time.Now()
+	var wait time.Duration
+	for _, b := range l {
+		if w := b.reserve(now, n); w > wait {
+			wait = w
+		}
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type limitedRay struct {
+	input  ray.OutputStream
+	output ray.InputStream
+}
+
+// InboundInput implements ray.InboundRay.
+func (r *limitedRay) InboundInput() ray.OutputStream {
+	return r.input
+}
+
+// InboundOutput implements ray.InboundRay.
+func (r *limitedRay) InboundOutput() ray.InputStream {
+	return r.output
+}
+
+// limitedOutputStream delays writes to the input of a connection, to keep the uplink within the limits.
+type limitedOutputStream struct {
+	ray.OutputStream
+	ctx     context.Context
+	limiter bandwidthLimiter
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (s *limitedOutputStream) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if err := s.limiter.wait(s.ctx, mb.Len()); err != nil {
+		mb.Release()
+		return err
+	}
+	return s.OutputStream.WriteMultiBuffer(mb)
+}
+
+// limitedInputStream delays reads from the output of a connection, to keep the downlink within the limits.
+type limitedInputStream struct {
+	ray.InputStream
+	ctx     context.Context
+	limiter bandwidthLimiter
+}
+
+func (s *limitedInputStream) delay(mb buf.MultiBuffer, err error) (buf.MultiBuffer, error) {
+	if err != nil {
+		return mb, err
+	}
+	if err := s.limiter.wait(s.ctx, mb.Len()); err != nil {
+		mb.Release()
+		return nil, err
+	}
+	return mb, nil
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (s *limitedInputStream) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	return s.delay(s.InputStream.ReadMultiBuffer())
+}
+
+// ReadTimeout implements buf.TimeoutReader.
+func (s *limitedInputStream) ReadTimeout(timeout time.Duration) (buf.MultiBuffer, error) {
+	return s.delay(s.InputStream.ReadTimeout(timeout))
+}
@@ -0,0 +1,135 @@
+package impl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	. "v2ray.com/ext/assert"
+)
+
+func newBandwidthDispatcher(bandwidth *policy.Policy_Bandwidth) dispatcher.Interface {
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			1: {Bandwidth: bandwidth},
+		},
+	}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	return dispatcher.FromSpace(space)
+}
+
+// echo sends size bytes through a connection to the echo server, and reads them back.
+func echo(ctx context.Context, d dispatcher.Interface, dest net.Destination, size int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	link, err := d.Dispatch(ctx, dest)
+	if err != nil {
+		return err
+	}
+	defer link.InboundInput().Close()
+
+	b := buf.New()
+	common.Must(b.Reset(func(v []byte) (int, error) {
+		return size, nil
+	}))
+	if err := link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)); err != nil {
+		return err
+	}
+
+	for size > 0 {
+		mb, err := link.InboundOutput().ReadTimeout(time.Second * 10)
+		if err != nil {
+			return err
+		}
+		size -= mb.Len()
+		mb.Release()
+	}
+	return nil
+}
+
+func TestBandwidthPerConnection(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	d := newBandwidthDispatcher(&policy.Policy_Bandwidth{
+		Uplink: 10000,
+		Burst:  1000,
+	})
+	ctx := protocol.ContextWithUser(context.Background(), &protocol.User{Level: 1})
+
+	start := time.Now()
+	assert(echo(ctx, d, dest, 2000), IsNil)
+	assert(time.Since(start) >= time.Millisecond*80, IsTrue)
+
+	// Users in other levels have no limit.
+	start = time.Now()
+	assert(echo(context.Background(), d, dest, 2000), IsNil)
+	assert(time.Since(start) < time.Millisecond*80, IsTrue)
+}
+
+func TestBandwidthPerUser(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	d := newBandwidthDispatcher(&policy.Policy_Bandwidth{
+		UserDownlink: 10000,
+		Burst:        1000,
+	})
+	ctx := protocol.ContextWithUser(context.Background(), &protocol.User{
+		Level: 1,
+		Email: "love@v2ray.com",
+	})
+
+	// Each connection is within the burst, but both together are not.
+	start := time.Now()
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- echo(ctx, d, dest, 1000)
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		assert(<-errs, IsNil)
+	}
+	assert(time.Since(start) >= time.Millisecond*80, IsTrue)
+}
@@ -0,0 +1,101 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+var (
+	bitTorrentHandshake = append([]byte{19}, "BitTorrent protocol"...)
+	dhtQueryPrefix      = []byte("d1:ad2:id20:")
+	dhtResponsePrefix   = []byte("d1:rd2:id20:")
+)
+
+const (
+	utpHeaderLen = 20
+	// utpSynVersion is the first byte of uTP SYN packets, which is type ST_SYN (4) and version 1.
+	utpSynVersion = 0x41
+	// utpExtensionSelectiveAck and utpExtensionBits are the known uTP extensions.
+	utpExtensionSelectiveAck = 1
+	utpExtensionBits         = 2
+)
+
+// matchPrefix returns nil if b starts with prefix, ErrMoreData if b is a prefix of prefix, or ErrInvalidData otherwise.
+func matchPrefix(b []byte, prefix []byte) error {
+	if len(b) < len(prefix) {
+		if bytes.HasPrefix(prefix, b) {
+			return ErrMoreData
+		}
+		return ErrInvalidData
+	}
+	if bytes.HasPrefix(b, prefix) {
+		return nil
+	}
+	return ErrInvalidData
+}
+
+// SniffBitTorrent detects BitTorrent traffic, including the peer wire handshake, uTP connections and DHT messages.
+// BitTorrent carries no domain, so an empty domain is returned when it is detected.
+func SniffBitTorrent(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", ErrMoreData
+	}
+
+	switch b[0] {
+	case bitTorrentHandshake[0]:
+		return "", matchPrefix(b, bitTorrentHandshake)
+	case dhtQueryPrefix[0]:
+		err := matchPrefix(b, dhtQueryPrefix)
+		if err == ErrInvalidData {
+			err = matchPrefix(b, dhtResponsePrefix)
+		}
+		return "", err
+	case utpSynVersion:
+		return "", sniffUTPSyn(b)
+	default:
+		return "", ErrInvalidData
+	}
+}
+
+// sniffUTPSyn checks the header of a uTP SYN packet, as specified in BEP 29.
+func sniffUTPSyn(b []byte) error {
+	if len(b) < utpHeaderLen {
+		return ErrMoreData
+	}
+	// A SYN packet is the first packet of a connection, so no timestamp difference is known yet.
+	if binary.BigEndian.Uint32(b[8:12]) != 0 {
+		return ErrInvalidData
+	}
+	// Receive window is never empty at the beginning of a connection.
+	if binary.BigEndian.Uint32(b[12:16]) == 0 {
+		return ErrInvalidData
+	}
+
+	// Each extension is a type byte of the next extension, a length byte and the data.
+	next := b[1]
+	offset := utpHeaderLen
+	for next != 0 {
+		if len(b) < offset+2 {
+			return ErrMoreData
+		}
+		length := int(b[offset+1])
+		switch next {
+		case utpExtensionSelectiveAck:
+			if length < 4 || length%4 != 0 {
+				return ErrInvalidData
+			}
+		case utpExtensionBits:
+			if length != 8 {
+				return ErrInvalidData
+			}
+		default:
+			return ErrInvalidData
+		}
+		next = b[offset]
+		offset += 2 + length
+		if len(b) < offset {
+			return ErrMoreData
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package impl_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/app/dispatcher/impl"
+	. "v2ray.com/ext/assert"
+)
+
+func TestBitTorrentSniffer(t *testing.T) {
+	assert := With(t)
+
+	cases := []struct {
+		input []byte
+		err   error
+	}{
+		{
+			// Peer wire handshake, followed by reserved bytes.
+			input: append([]byte("\x13BitTorrent protocol"), 0, 0, 0, 0, 0, 0x10, 0, 0x05),
+		},
+		{
+			input: []byte("\x13BitTorrent"),
+			err:   ErrMoreData,
+		},
+		{
+			input: []byte("\x13BitTorrent protokol"),
+			err:   ErrInvalidData,
+		},
+		{
+			// DHT ping query.
+			input: []byte("d1:ad2:id20:abcdefghij0123456789e1:q4:ping1:t2:aa1:y1:qe"),
+		},
+		{
+			// DHT response.
+			input: []byte("d1:rd2:id20:mnopqrstuvwxyz123456e1:t2:aa1:y1:re"),
+		},
+		{
+			input: []byte("d1:a"),
+			err:   ErrMoreData,
+		},
+		{
+			input: []byte("d8:announce"),
+			err:   ErrInvalidData,
+		},
+		{
+			// uTP SYN packet as sent by libtorrent, without extensions.
+			input: decodeHex("4100d3a1b6e0c51200000000001000008a4c0000"),
+		},
+		{
+			// uTP SYN packet as sent by libutp, with the extension bits.
+			input: decodeHex("41023b7e0f2e6d7b0000000000100000f14d0000000800000000000000000000"),
+		},
+		{
+			// uTP SYN packet with a selective ACK and the extension bits.
+			input: decodeHex("41013b7e0f2e6d7b0000000000100000f14d00000204ffffffff000800000000000000000000"),
+		},
+		{
+			input: []byte{0x41, 0x00, 0x30, 0x39},
+			err:   ErrMoreData,
+		},
+		{
+			// Extension bits are truncated.
+			input: decodeHex("41023b7e0f2e6d7b0000000000100000f14d0000000800000000"),
+			err:   ErrMoreData,
+		},
+		{
+			// Unknown extension.
+			input: decodeHex("41033b7e0f2e6d7b0000000000100000f14d0000000800000000000000000000"),
+			err:   ErrInvalidData,
+		},
+		{
+			// Extension bits of a wrong length.
+			input: decodeHex("41023b7e0f2e6d7b0000000000100000f14d00000004000000000000"),
+			err:   ErrInvalidData,
+		},
+		{
+			// Timestamp difference is set.
+			input: decodeHex("4100d3a1b6e0c512000003e8001000008a4c0000"),
+			err:   ErrInvalidData,
+		},
+		{
+			// Receive window is empty.
+			input: decodeHex("4100d3a1b6e0c51200000000000000008a4c0000"),
+			err:   ErrInvalidData,
+		},
+		{
+			// Text that happens to start with the uTP SYN byte.
+			input: []byte("A\x00BCDEFGHIJKLMNOPQRSTUVWXYZ"),
+			err:   ErrInvalidData,
+		},
+		{
+			input: []byte("GET / HTTP/1.1\r\n"),
+			err:   ErrInvalidData,
+		},
+	}
+
+	for _, test := range cases {
+		domain, err := SniffBitTorrent(test.input)
+		assert(err, Equals, test.err)
+		assert(domain, Equals, "")
+	}
+}
@@ -4,31 +4,75 @@ package impl
 
 import (
 	"context"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"v2ray.com/core/app"
 	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/app/router"
 	"v2ray.com/core/common"
-	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/log"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/ray"
 )
 
-var (
-	errSniffingTimeout = newError("timeout on sniffing")
+const (
+	DefaultSniffingTimeout   = time.Millisecond * 300
+	DefaultSniffingByteLimit = 2048
+	// DefaultPacketSniffingByteLimit is the default byte limit of sniffing UDP connections. QUIC clients pad each
+	// Initial packet to at least 1200 bytes, and a ClientHello with a large key share spans two or more of them.
+	DefaultPacketSniffingByteLimit = 8192
+
+	DefaultFallbackBufferSize = 16 * 1024
+
+	// sniffingStatsInterval is the interval to write the sniffing statistics to the log.
+	sniffingStatsInterval = time.Minute * 10
 )
 
 var (
 	_ app.Application = (*DefaultDispatcher)(nil)
 )
 
+// SniffingStats is the statistics of protocol sniffing.
+type SniffingStats struct {
+	// Success is the number of connections whose domain is sniffed.
+	Success uint64
+	// Failure is the number of connections whose data is not recognized.
+	Failure uint64
+	// Timeout is the number of connections that don't send enough data in time.
+	Timeout uint64
+	// Latency is the total time spent on sniffing.
+	Latency time.Duration
+}
+
 // DefaultDispatcher is a default implementation of Dispatcher.
 type DefaultDispatcher struct {
-	ohm    proxyman.OutboundHandlerManager
-	router *router.Router
+	// Sniffing statistics are accessed atomically, and they must be 64-bit aligned.
+	sniffSuccess uint64
+	sniffFailure uint64
+	sniffTimeout uint64
+	sniffLatency int64
+
+	ohm                     proxyman.OutboundHandlerManager
+	router                  *router.Router
+	fakeDNS                 *dns.FakeDNSPool
+	policy                  policy.Manager
+	bandwidth               *bandwidthManager
+	sniffingTimeout         time.Duration
+	sniffingByteLimit       int
+	packetSniffingByteLimit int
+	interceptors            []dispatcher.Interceptor
+
+	fallbackBufferSize int
+
+	// done is closed when the dispatcher closes.
+	done chan struct{}
 }
 
 // NewDefaultDispatcher create a new DefaultDispatcher.
@@ -37,25 +81,69 @@ func NewDefaultDispatcher(ctx context.Context, config *dispatcher.Config) (*Defa
 	if space == nil {
 		return nil, newError("no space in context")
 	}
-	d := &DefaultDispatcher{}
+	d := &DefaultDispatcher{
+		sniffingTimeout:         DefaultSniffingTimeout,
+		sniffingByteLimit:       DefaultSniffingByteLimit,
+		packetSniffingByteLimit: DefaultPacketSniffingByteLimit,
+		fallbackBufferSize:      DefaultFallbackBufferSize,
+		bandwidth:               newBandwidthManager(),
+	}
+	if sniffing := config.GetSniffing(); sniffing != nil {
+		if sniffing.Timeout > 0 {
+			d.sniffingTimeout = time.Millisecond * time.Duration(sniffing.Timeout)
+		}
+		if sniffing.ByteLimit > 0 {
+			d.sniffingByteLimit = int(sniffing.ByteLimit)
+			d.packetSniffingByteLimit = int(sniffing.ByteLimit)
+		}
+	}
+	if size := config.GetFallback().GetBufferSize(); size > 0 {
+		d.fallbackBufferSize = int(size)
+	}
+	for _, settings := range config.Interceptor {
+		rawConfig, err := settings.GetInstance()
+		if err != nil {
+			return nil, newError("failed to load interceptor settings").Base(err)
+		}
+		rawInterceptor, err := common.CreateObject(ctx, rawConfig)
+		if err != nil {
+			return nil, newError("failed to create interceptor").Base(err)
+		}
+		interceptor, ok := rawInterceptor.(dispatcher.Interceptor)
+		if !ok {
+			return nil, newError("not an interceptor: ", settings.Type)
+		}
+		d.interceptors = append(d.interceptors, interceptor)
+	}
 	space.On(app.SpaceInitializing, func(interface{}) error {
 		d.ohm = proxyman.OutboundHandlerManagerFromSpace(space)
 		if d.ohm == nil {
 			return newError("OutboundHandlerManager is not found in the space")
 		}
 		d.router = router.FromSpace(space)
+		d.policy = policy.FromSpace(space)
+		if dnsServer := dns.FromSpace(space); dnsServer != nil {
+			d.fakeDNS = dnsServer.FakeDNS()
+		}
 		return nil
 	})
 	return d, nil
 }
 
 // Start implements app.Application.
-func (*DefaultDispatcher) Start() error {
+func (d *DefaultDispatcher) Start() error {
+	d.done = make(chan struct{})
+	go d.logSniffingStats(d.done)
 	return nil
 }
 
 // Close implements app.Application.
-func (*DefaultDispatcher) Close() {}
+func (d *DefaultDispatcher) Close() {
+	if d.done != nil {
+		close(d.done)
+		d.done = nil
+	}
+}
 
 // Interface implements app.Application.
 func (*DefaultDispatcher) Interface() interface{} {
@@ -67,71 +155,243 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 	if !destination.IsValid() {
 		panic("Dispatcher: Invalid destination.")
 	}
+	if d.fakeDNS != nil && destination.Address.Family().IsIP() {
+		if domain := d.fakeDNS.GetDomainFromFakeDNS(destination.Address); len(domain) > 0 {
+			newError("restored domain ", domain, " from fake IP ", destination.Address).WriteToLog()
+			destination.Address = net.DomainAddress(domain)
+		}
+	}
 	ctx = proxy.ContextWithTarget(ctx, destination)
 
+	// The access message of the inbound is recorded here, and not again when outbounds dispatch through the
+	// dispatcher with the same context.
+	accessMessage := log.AccessMessageFromContext(ctx)
+	if accessMessage != nil {
+		ctx = log.ContextWithAccessMessage(ctx, nil)
+	}
+
 	outbound := ray.NewRay(ctx)
-	sniferList := proxyman.ProtocoSniffersFromContext(ctx)
-	if destination.Address.Family().IsDomain() || len(sniferList) == 0 {
-		go d.routedDispatch(ctx, outbound, destination)
+	override := proxyman.DomainOverrideFromContext(ctx)
+	if destination.Address.Family().IsDomain() || override == nil {
+		if accessMessage != nil {
+			log.Record(accessMessage)
+		}
+		go d.routedDispatch(ctx, outbound, destination, destination)
 	} else {
 		go func() {
-			domain, err := snifer(ctx, sniferList, outbound)
+			result, latency, err := d.sniff(ctx, override.Protocols, destination.Network, outbound)
+			if accessMessage != nil {
+				accessMessage.Sniffed = describeSniffing(result, latency, err)
+				log.Record(accessMessage)
+			}
 			if err == nil {
+				ctx = proxy.ContextWithSniffedProtocol(ctx, strings.ToLower(result.Protocol.String()))
+			}
+			domain := result.Domain
+			switch {
+			case err != nil:
+			case len(domain) == 0:
+				newError("sniffed protocol: ", result.Protocol).WriteToLog()
+			case override.IsExcluded(domain):
+				newError("sniffed domain ", domain, " is excluded from overriding").WriteToLog()
+			case override.RouteOnly:
+				newError("sniffed domain for routing: ", domain).WriteToLog()
+				sniffed := destination
+				sniffed.Address = net.ParseAddress(domain)
+				d.routedDispatch(ctx, outbound, destination, sniffed)
+				return
+			default:
 				newError("sniffed domain: ", domain).WriteToLog()
 				destination.Address = net.ParseAddress(domain)
 				ctx = proxy.ContextWithTarget(ctx, destination)
 			}
-			d.routedDispatch(ctx, outbound, destination)
+			d.routedDispatch(ctx, outbound, destination, destination)
 		}()
 	}
-	return outbound, nil
+	return d.limitBandwidth(ctx, outbound), nil
+}
+
+// limitBandwidth applies the bandwidth limits in the policy of the user in the context to the inbound side of the ray.
+func (d *DefaultDispatcher) limitBandwidth(ctx context.Context, inbound ray.InboundRay) ray.InboundRay {
+	if d.policy == nil {
+		return inbound
+	}
+	var level uint32
+	if user := protocol.UserFromContext(ctx); user != nil {
+		level = user.Level
+	}
+	return d.bandwidth.limit(ctx, d.policy.GetPolicy(level).Bandwidth, inbound)
+}
+
+// sniff detects the protocol of the connection, and returns the result, the time it takes, and the error if the
+// protocol is not detected.
+func (d *DefaultDispatcher) sniff(ctx context.Context, sniferList []proxyman.KnownProtocols, network net.Network, outbound ray.OutboundRay) (SniffResult, time.Duration, error) {
+	byteLimit := d.sniffingByteLimit
+	if network == net.Network_UDP {
+		byteLimit = d.packetSniffingByteLimit
+	}
+	start := time.Now()
+	result, err := NewSniffer(sniferList).SniffStream(ctx, outbound.OutboundInput(), d.sniffingTimeout, byteLimit)
+	latency := time.Since(start)
+
+	atomic.AddInt64(&d.sniffLatency, int64(latency))
+	switch err {
+	case nil:
+		atomic.AddUint64(&d.sniffSuccess, 1)
+	case ErrTimeout:
+		atomic.AddUint64(&d.sniffTimeout, 1)
+	default:
+		atomic.AddUint64(&d.sniffFailure, 1)
+	}
+	newError("sniffing finished in ", latency).Base(err).AtDebug().WriteToLog()
+	return result, latency, err
 }
 
-func snifer(ctx context.Context, sniferList []proxyman.KnownProtocols, outbound ray.OutboundRay) (string, error) {
-	payload := buf.New()
-	defer payload.Release()
+// describeSniffing returns the result of sniffing for the access log.
+func describeSniffing(result SniffResult, latency time.Duration, err error) string {
+	var description string
+	switch {
+	case err == ErrTimeout:
+		description = "timeout"
+	case err != nil:
+		description = "unknown"
+	case len(result.Domain) > 0:
+		description = strings.ToLower(result.Protocol.String()) + " " + result.Domain
+	default:
+		description = strings.ToLower(result.Protocol.String())
+	}
+	return description + " in " + latency.String()
+}
 
-	sniffer := NewSniffer(sniferList)
-	totalAttempt := 0
+// SniffingStats returns the statistics of protocol sniffing so far.
+func (d *DefaultDispatcher) SniffingStats() SniffingStats {
+	return SniffingStats{
+		Success: atomic.LoadUint64(&d.sniffSuccess),
+		Failure: atomic.LoadUint64(&d.sniffFailure),
+		Timeout: atomic.LoadUint64(&d.sniffTimeout),
+		Latency: time.Duration(atomic.LoadInt64(&d.sniffLatency)),
+	}
+}
+
+// logSniffingStats writes the sniffing statistics to the log periodically, when there are new connections sniffed,
+// until done is closed.
+func (d *DefaultDispatcher) logSniffingStats(done <-chan struct{}) {
+	ticker := time.NewTicker(sniffingStatsInterval)
+	defer ticker.Stop()
+
+	var last SniffingStats
 	for {
 		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		default:
-			totalAttempt++
-			if totalAttempt > 5 {
-				return "", errSniffingTimeout
-			}
-			outbound.OutboundInput().Peek(payload)
-			if !payload.IsEmpty() {
-				domain, err := sniffer.Sniff(payload.Bytes())
-				if err != ErrMoreData {
-					return domain, err
-				}
-			}
-			if payload.IsFull() {
-				return "", ErrInvalidData
-			}
-			time.Sleep(time.Millisecond * 100)
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+		stats := d.SniffingStats()
+		if stats == last {
+			continue
+		}
+		var average time.Duration
+		if count := stats.Success + stats.Failure + stats.Timeout; count > 0 {
+			average = stats.Latency / time.Duration(count)
 		}
+		newError("sniffing statistics: ", stats.Success, " succeeded, ", stats.Failure, " failed, ", stats.Timeout, " timed out, average latency ", average).AtInfo().WriteToLog()
+		last = stats
 	}
 }
 
-func (d *DefaultDispatcher) routedDispatch(ctx context.Context, outbound ray.OutboundRay, destination net.Destination) {
-	dispatcher := d.ohm.GetDefaultHandler()
+// routedDispatch dispatches the connection to destination, through the outbound chosen for routeTarget.
+func (d *DefaultDispatcher) routedDispatch(ctx context.Context, outbound ray.OutboundRay, destination net.Destination, routeTarget net.Destination) {
+	result := &dispatcher.Result{
+		Destination: destination,
+	}
+	start := time.Now()
+	finish := func() {
+		result.Duration = time.Since(start)
+		for _, interceptor := range d.interceptors {
+			interceptor.OnFinished(ctx, result)
+		}
+	}
+
+	for _, interceptor := range d.interceptors {
+		newCtx, newDest, err := interceptor.BeforeRouting(ctx, destination)
+		if err != nil {
+			d.reject(outbound, result, err)
+			finish()
+			return
+		}
+		ctx = newCtx
+		if newDest != destination {
+			newError("destination is changed from ", destination, " to ", newDest, " by interceptor").WriteToLog()
+			destination = newDest
+			routeTarget = newDest
+			ctx = proxy.ContextWithTarget(ctx, destination)
+		}
+	}
+	result.Destination = destination
+
+	routeCtx := ctx
+	if routeTarget != destination {
+		routeCtx = proxy.ContextWithTarget(ctx, routeTarget)
+	}
+	tag, handler, fallback := d.getHandler(routeCtx, routeTarget)
+	result.Tag = tag
+
+	for _, interceptor := range d.interceptors {
+		if err := interceptor.AfterRouting(ctx, destination, tag); err != nil {
+			d.reject(outbound, result, err)
+			finish()
+			return
+		}
+	}
+
+	if len(d.interceptors) == 0 {
+		d.outboundDispatch(ctx, outbound, tag, handler, fallback, result)
+		return
+	}
+
+	// Outbounds may return before the connection ends, such as those with mux. The connection ends when the output
+	// is closed, which is watched in another goroutine.
+	tracked := newTrackedRay(outbound)
+	d.outboundDispatch(ctx, tracked, tag, handler, fallback, result)
+	go func() {
+		result.Err = tracked.output.wait()
+		finish()
+	}()
+}
+
+// outboundDispatch dispatches the connection through the given handler, or its fallback handlers in order.
+func (d *DefaultDispatcher) outboundDispatch(ctx context.Context, outbound ray.OutboundRay, tag string, handler proxyman.OutboundHandler, fallback []string, result *dispatcher.Result) {
+	if tags, handlers := d.fallbackHandlers(handler, fallback); len(handlers) > 0 {
+		tags = append([]string{tag}, tags...)
+		handlers = append([]proxyman.OutboundHandler{handler}, handlers...)
+		d.fallbackDispatch(ctx, outbound, tags, handlers, result)
+	} else {
+		handler.Dispatch(ctx, outbound)
+	}
+}
+
+func (d *DefaultDispatcher) reject(outbound ray.OutboundRay, result *dispatcher.Result, err error) {
+	newError("connection to ", result.Destination, " is rejected by interceptor").Base(err).WriteToLog()
+	result.Err = err
+	outbound.OutboundInput().CloseError()
+	outbound.OutboundOutput().CloseError()
+}
+
+// getHandler returns the tag and the outbound handler for the target in the given context, as chosen by the router,
+// and the fallback tags of the routing rule. The tag is empty for the default handler.
+func (d *DefaultDispatcher) getHandler(ctx context.Context, destination net.Destination) (string, proxyman.OutboundHandler, []string) {
 	if d.router != nil {
-		if tag, err := d.router.TakeDetour(ctx); err == nil {
-			if handler := d.ohm.GetHandler(tag); handler != nil {
-				newError("taking detour [", tag, "] for [", destination, "]").WriteToLog()
-				dispatcher = handler
-			} else {
-				newError("nonexisting tag: ", tag).AtWarning().WriteToLog()
+		if rule, err := d.router.PickRule(ctx); err == nil {
+			if handler := d.ohm.GetHandler(rule.Tag); handler != nil {
+				newError("taking detour [", rule.Tag, "] for [", destination, "]").WriteToLog()
+				return rule.Tag, handler, rule.Fallback
 			}
+			newError("nonexisting tag: ", rule.Tag).AtWarning().WriteToLog()
 		} else {
 			newError("default route for ", destination).WriteToLog()
 		}
 	}
-	dispatcher.Dispatch(ctx, outbound)
+	return "", d.ohm.GetDefaultHandler(), nil
 }
 
 func init() {
@@ -0,0 +1,311 @@
+package impl_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	_ "v2ray.com/core/app/dispatcher/impl"
+	"v2ray.com/core/app/policy"
+	_ "v2ray.com/core/app/policy/manager"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/log"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/blackhole"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	_ "v2ray.com/core/transport/internet/tcp"
+	. "v2ray.com/ext/assert"
+)
+
+type testInterceptorConfig struct{}
+
+func (*testInterceptorConfig) Reset()         {}
+func (*testInterceptorConfig) String() string { return "testInterceptorConfig" }
+func (*testInterceptorConfig) ProtoMessage()  {}
+
+var errRejected = errors.New("rejected")
+
+// testInterceptor rejects connections to port 1, and rewrites destinations of "echo.v2ray.com".
+type testInterceptor struct {
+	echo    net.Destination
+	results chan *dispatcher.Result
+}
+
+func (i *testInterceptor) BeforeRouting(ctx context.Context, dest net.Destination) (context.Context, net.Destination, error) {
+	if dest.Port == 1 {
+		return nil, dest, errRejected
+	}
+	if dest.Address.Family().IsDomain() && dest.Address.Domain() == "echo.v2ray.com" {
+		return ctx, i.echo, nil
+	}
+	return ctx, dest, nil
+}
+
+func (i *testInterceptor) AfterRouting(ctx context.Context, dest net.Destination, tag string) error {
+	return nil
+}
+
+func (i *testInterceptor) OnFinished(ctx context.Context, result *dispatcher.Result) {
+	i.results <- result
+}
+
+var interceptor = &testInterceptor{
+	results: make(chan *dispatcher.Result, 2),
+}
+
+func init() {
+	proto.RegisterType((*testInterceptorConfig)(nil), "v2ray.core.app.dispatcher.impl.testInterceptorConfig")
+	common.Must(common.RegisterConfig((*testInterceptorConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return interceptor, nil
+	}))
+}
+
+func TestInterceptor(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+	interceptor.echo = dest
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{
+		Interceptor: []*serial.TypedMessage{
+			serial.ToTypedMessage(&testInterceptorConfig{}),
+		},
+	}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	// The connection ends soon after the input is closed, so that the result comes in time.
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {
+				Timeout: &policy.Policy_Timeout{
+					UplinkOnly:   &policy.Second{Value: 1},
+					DownlinkOnly: &policy.Second{Value: 1},
+				},
+			},
+		},
+	}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+
+	d := dispatcher.FromSpace(space)
+
+	{
+		link, err := d.Dispatch(context.Background(), net.TCPDestination(net.DomainAddress("echo.v2ray.com"), 80))
+		assert(err, IsNil)
+
+		b := buf.New()
+		b.Append([]byte("hello"))
+		assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+		mb, err := link.InboundOutput().ReadTimeout(time.Second * 5)
+		assert(err, IsNil)
+		response := make([]byte, mb.Len())
+		mb.Copy(response)
+		assert(string(response), Equals, "hello")
+		mb.Release()
+		link.InboundInput().Close()
+
+		result := <-interceptor.results
+		assert(result.Destination, Equals, dest)
+		assert(result.Tag, Equals, "")
+		assert(result.Err, IsNil)
+	}
+
+	{
+		link, err := d.Dispatch(context.Background(), net.TCPDestination(net.LocalHostIP, 1))
+		assert(err, IsNil)
+
+		_, err = link.InboundOutput().ReadMultiBuffer()
+		assert(err, IsNotNil)
+
+		result := <-interceptor.results
+		assert(result.Err, Equals, errRejected)
+	}
+
+	{
+		closedServer := tcp.Server{}
+		closedDest, err := closedServer.Start()
+		assert(err, IsNil)
+		closedServer.Close()
+
+		link, err := d.Dispatch(context.Background(), closedDest)
+		assert(err, IsNil)
+
+		_, err = link.InboundOutput().ReadMultiBuffer()
+		assert(err, IsNotNil)
+
+		result := <-interceptor.results
+		assert(result.Destination, Equals, closedDest)
+		assert(result.Err, IsNotNil)
+	}
+}
+
+// accessLogger sends the access log messages to a channel.
+type accessLogger chan *log.AccessMessage
+
+func (l accessLogger) Handle(msg log.Message) {
+	if msg, ok := msg.(*log.AccessMessage); ok {
+		l <- msg
+	}
+}
+
+func TestSniffingAccessLog(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	defer space.Close()
+
+	logger := make(accessLogger, 16)
+	log.RegisterHandler(logger)
+
+	ctx = proxyman.ContextWithDomainOverride(context.Background(), &proxyman.DomainOverride{
+		Protocols: []proxyman.KnownProtocols{proxyman.KnownProtocols_HTTP},
+		RouteOnly: true,
+	})
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+		From:   "client",
+		To:     dest,
+		Status: log.AccessAccepted,
+	})
+	link, err := dispatcher.FromSpace(space).Dispatch(ctx, dest)
+	assert(err, IsNil)
+
+	b := buf.New()
+	b.Append([]byte("GET / HTTP/1.1\r\nHost: v2ray.com\r\n\r\n"))
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	// The access message of the inbound is recorded once, with the result of sniffing.
+	select {
+	case msg := <-logger:
+		assert(msg.From, Equals, "client")
+		assert(strings.HasPrefix(msg.String(), "client accepted "+dest.String()), IsTrue)
+		assert(strings.HasPrefix(msg.Sniffed.(string), "http v2ray.com in "), IsTrue)
+	case <-time.After(time.Second * 5):
+		t.Fatal("no access log")
+	}
+
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 5)
+	assert(err, IsNil)
+	mb.Release()
+	link.InboundInput().Close()
+
+	select {
+	case msg := <-logger:
+		t.Fatal("unexpected access log: ", msg)
+	default:
+	}
+}
+
+func TestSniffingRouteOnly(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &router.Config{
+		Rule: []*router.RoutingRule{
+			{
+				Tag: "direct",
+				Domain: []*router.Domain{
+					{Type: router.Domain_Plain, Value: "v2ray.com"},
+				},
+			},
+		},
+	}))
+
+	// Connections that are not routed by the sniffed domain go nowhere.
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&blackhole.Config{}),
+	}))
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		Tag:           "direct",
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	defer space.Close()
+
+	ctx = proxyman.ContextWithDomainOverride(context.Background(), &proxyman.DomainOverride{
+		Protocols: []proxyman.KnownProtocols{proxyman.KnownProtocols_HTTP},
+		RouteOnly: true,
+	})
+	link, err := dispatcher.FromSpace(space).Dispatch(ctx, dest)
+	assert(err, IsNil)
+
+	request := "GET / HTTP/1.1\r\nHost: v2ray.com\r\n\r\n"
+	b := buf.New()
+	b.Append([]byte(request))
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	// The connection is routed to the direct outbound by the sniffed domain, and the outbound connects to the
+	// original IP destination, which is the echo server.
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 5)
+	assert(err, IsNil)
+	response := make([]byte, mb.Len())
+	mb.Copy(response)
+	mb.Release()
+	assert(string(response), Equals, request)
+	link.InboundInput().Close()
+}
@@ -0,0 +1,194 @@
+package impl
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/transport/ray"
+)
+
+// replayWriter writes the input of a connection to the outbound being tried. It keeps a copy of the input, so that
+// the input can be replayed to a fallback outbound, until the copy exceeds the size limit or the connection is
+// committed to an outbound.
+type replayWriter struct {
+	sync.Mutex
+	current   ray.OutputStream
+	data      buf.MultiBuffer
+	size      int
+	limit     int
+	committed bool
+	eof       bool
+}
+
+func copyBuffer(b *buf.Buffer) *buf.Buffer {
+	var c *buf.Buffer
+	if b.Len() <= buf.Size {
+		c = buf.New()
+	} else {
+		c = buf.NewLocal(b.Len())
+	}
+	c.Append(b.Bytes())
+	c.SetDestination(b.Destination())
+	return c
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *replayWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.Lock()
+	current := w.current
+	if !w.committed {
+		w.size += mb.Len()
+		if w.size <= w.limit {
+			for _, b := range mb {
+				w.data.Append(copyBuffer(b))
+			}
+			w.Unlock()
+			// The outbound may have failed already. It is not an error, as the data will be replayed to the next one.
+			current.WriteMultiBuffer(mb)
+			return nil
+		}
+		newError("input exceeds fallback buffer size, no more fallback").AtDebug().WriteToLog()
+		w.commitLocked()
+	}
+	w.Unlock()
+	return current.WriteMultiBuffer(mb)
+}
+
+// run copies the input of the connection until it ends.
+func (w *replayWriter) run(input ray.InputStream) {
+	err := buf.Copy(input, w)
+
+	w.Lock()
+	current := w.current
+	if err != nil {
+		w.commitLocked()
+	} else {
+		w.eof = true
+	}
+	w.Unlock()
+
+	if err != nil {
+		current.CloseError()
+		input.CloseError()
+	} else {
+		current.Close()
+	}
+}
+
+func (w *replayWriter) commitLocked() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	w.data.Release()
+	w.data = nil
+}
+
+// commit stops keeping the input for replay. The connection can't fall back to another outbound afterwards.
+func (w *replayWriter) commit() {
+	w.Lock()
+	w.commitLocked()
+	w.Unlock()
+}
+
+// switchTo replays the input so far to the given stream, and writes further input to it. It returns false if the
+// input can't be replayed. If last is true, the input is committed to the new stream.
+func (w *replayWriter) switchTo(next ray.OutputStream, last bool) bool {
+	w.Lock()
+	defer w.Unlock()
+
+	w.current.CloseError()
+	if w.committed {
+		return false
+	}
+
+	replay := buf.NewMultiBufferCap(len(w.data))
+	for _, b := range w.data {
+		replay.Append(copyBuffer(b))
+	}
+	if err := next.WriteMultiBuffer(replay); err != nil {
+		return false
+	}
+	if w.eof {
+		next.Close()
+	}
+	w.current = next
+	if last {
+		w.commitLocked()
+	}
+	return true
+}
+
+// fallbackDispatch dispatches the connection through the given handlers in order. It moves on to the next handler,
+// if the current one fails before any response.
+func (d *DefaultDispatcher) fallbackDispatch(ctx context.Context, outbound ray.OutboundRay, tags []string, handlers []proxyman.OutboundHandler, result *dispatcher.Result) {
+	input := &replayWriter{
+		limit: d.fallbackBufferSize,
+	}
+	output := outbound.OutboundOutput()
+
+	for idx, handler := range handlers {
+		attempt := ray.NewRay(ctx)
+		tracked := newTrackedRay(attempt)
+		if idx == 0 {
+			input.current = attempt.InboundInput()
+			go input.run(outbound.OutboundInput())
+		} else if !input.switchTo(attempt.InboundInput(), idx == len(handlers)-1) {
+			err := newError("unable to fall back to [", tags[idx], "] as input is not kept")
+			err.WriteToLog()
+			ray.CloseWithError(output, err)
+			return
+		}
+		result.Tag = tags[idx]
+		go handler.Dispatch(ctx, tracked)
+
+		response := attempt.InboundOutput()
+		mb, err := response.ReadMultiBuffer()
+		if err != nil && err != io.EOF && idx < len(handlers)-1 {
+			newError("outbound [", tags[idx], "] failed before any response, falling back to [", tags[idx+1], "]").AtWarning().WriteToLog()
+			continue
+		}
+		input.commit()
+
+		switch {
+		case err == io.EOF:
+			output.Close()
+		case err != nil:
+			ray.CloseWithError(output, tracked.output.wait())
+		case output.WriteMultiBuffer(mb) != nil:
+			response.CloseError()
+		case buf.Copy(response, output) != nil:
+			response.CloseError()
+			output.CloseError()
+		default:
+			output.Close()
+		}
+		return
+	}
+}
+
+// fallbackHandlers returns the handlers to try after the given one, with their tags.
+func (d *DefaultDispatcher) fallbackHandlers(handler proxyman.OutboundHandler, fallback []string) ([]string, []proxyman.OutboundHandler) {
+	if len(fallback) == 0 {
+		if h, ok := handler.(proxyman.FallbackOutboundHandler); ok {
+			fallback = h.FallbackTags()
+		}
+	}
+
+	tags := make([]string, 0, len(fallback))
+	handlers := make([]proxyman.OutboundHandler, 0, len(fallback))
+	for _, tag := range fallback {
+		h := d.ohm.GetHandler(tag)
+		if h == nil {
+			newError("nonexisting fallback tag: ", tag).AtWarning().WriteToLog()
+			continue
+		}
+		tags = append(tags, tag)
+		handlers = append(handlers, h)
+	}
+	return tags, handlers
+}
@@ -0,0 +1,160 @@
+package impl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	. "v2ray.com/ext/assert"
+)
+
+// newFallbackDispatcher creates a dispatcher whose default outbound connects to the given dead destination, and
+// falls back to a working outbound. The default outbound uses mux if muxConfig is set.
+func newFallbackDispatcher(dead net.Destination, config *dispatcher.Config, muxConfig *proxyman.MultiplexingConfig) dispatcher.Interface {
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, config))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{
+			DestinationOverride: &freedom.DestinationOverride{
+				Server: &protocol.ServerEndpoint{
+					Address: net.NewIPOrDomain(dead.Address),
+					Port:    uint32(dead.Port),
+				},
+			},
+		}),
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			MultiplexSettings: muxConfig,
+		}),
+		FallbackTag: []string{"missing", "backup"},
+	}))
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		Tag:           "backup",
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	return dispatcher.FromSpace(space)
+}
+
+func TestFallback(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	deadServer := tcp.Server{}
+	dead, err := deadServer.Start()
+	assert(err, IsNil)
+	deadServer.Close()
+
+	d := newFallbackDispatcher(dead, &dispatcher.Config{}, nil)
+	link, err := d.Dispatch(context.Background(), dest)
+	assert(err, IsNil)
+
+	b := buf.New()
+	b.Append([]byte("hello"))
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 10)
+	assert(err, IsNil)
+	response := make([]byte, mb.Len())
+	mb.Copy(response)
+	assert(string(response), Equals, "hello")
+	mb.Release()
+	link.InboundInput().Close()
+}
+
+func TestFallbackBufferExceeded(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	deadServer := tcp.Server{}
+	dead, err := deadServer.Start()
+	assert(err, IsNil)
+	deadServer.Close()
+
+	d := newFallbackDispatcher(dead, &dispatcher.Config{
+		Fallback: &dispatcher.FallbackConfig{
+			BufferSize: 4,
+		},
+	}, nil)
+	link, err := d.Dispatch(context.Background(), dest)
+	assert(err, IsNil)
+
+	b := buf.New()
+	b.Append([]byte("hello"))
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	_, err = link.InboundOutput().ReadTimeout(time.Second * 10)
+	assert(err, IsNotNil)
+}
+
+func TestFallbackFromMux(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	deadServer := tcp.Server{}
+	dead, err := deadServer.Start()
+	assert(err, IsNil)
+	deadServer.Close()
+
+	// The session is taken by mux at once, and fails when the Mux connection fails.
+	d := newFallbackDispatcher(dead, &dispatcher.Config{}, &proxyman.MultiplexingConfig{
+		Enabled:     true,
+		Concurrency: 8,
+	})
+	link, err := d.Dispatch(context.Background(), dest)
+	assert(err, IsNil)
+
+	b := buf.New()
+	b.Append([]byte("hello"))
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 10)
+	assert(err, IsNil)
+	response := make([]byte, mb.Len())
+	mb.Copy(response)
+	assert(string(response), Equals, "hello")
+	mb.Release()
+	link.InboundInput().Close()
+}
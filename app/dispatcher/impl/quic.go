@@ -0,0 +1,298 @@
+package impl
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+type quicVersion struct {
+	salt        []byte
+	keyLabel    string
+	ivLabel     string
+	hpLabel     string
+	initialType byte
+}
+
+var quicVersions = map[uint32]*quicVersion{
+	// QUIC v1, RFC 9001
+	0x00000001: {
+		salt:     []byte{0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17, 0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a},
+		keyLabel: "quic key",
+		ivLabel:  "quic iv",
+		hpLabel:  "quic hp",
+	},
+	// Draft 29
+	0xff00001d: {
+		salt:     []byte{0xaf, 0xbf, 0xec, 0x28, 0x99, 0x93, 0xd2, 0x4c, 0x9e, 0x97, 0x86, 0xf1, 0x9c, 0x61, 0x11, 0xe0, 0x43, 0x90, 0xa8, 0x99},
+		keyLabel: "quic key",
+		ivLabel:  "quic iv",
+		hpLabel:  "quic hp",
+	},
+	// QUIC v2, RFC 9369
+	0x6b3343cf: {
+		salt:        []byte{0x0d, 0xed, 0xe3, 0xde, 0xf7, 0x00, 0xa6, 0xdb, 0x81, 0x93, 0x81, 0xbe, 0x6e, 0x26, 0x9d, 0xcb, 0xf9, 0xbd, 0x2e, 0xd9},
+		keyLabel:    "quicv2 key",
+		ivLabel:     "quicv2 iv",
+		hpLabel:     "quicv2 hp",
+		initialType: 1,
+	},
+}
+
+// hkdfExpandLabel implements HKDF-Expand-Label of TLS 1.3 with empty context.
+func hkdfExpandLabel(secret []byte, label string, length int) []byte {
+	label = "tls13 " + label
+	info := make([]byte, 0, 4+len(label))
+	info = append(info, byte(length>>8), byte(length), byte(len(label)))
+	info = append(info, label...)
+	info = append(info, 0)
+
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, secret, info), out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// quicInitialKeys returns the key, IV and header protection key of the client Initial packets.
+func quicInitialKeys(version *quicVersion, dcid []byte) (key []byte, iv []byte, hp []byte) {
+	initialSecret := hkdf.Extract(sha256.New, dcid, version.salt)
+	clientSecret := hkdfExpandLabel(initialSecret, "client in", sha256.Size)
+	return hkdfExpandLabel(clientSecret, version.keyLabel, 16),
+		hkdfExpandLabel(clientSecret, version.ivLabel, 12),
+		hkdfExpandLabel(clientSecret, version.hpLabel, 16)
+}
+
+// readVarint reads a QUIC variable-length integer. It returns the value and the number of bytes read, or 0 if
+// there is not enough data.
+func readVarint(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	length := 1 << (b[0] >> 6)
+	if len(b) < length {
+		return 0, 0
+	}
+	v := uint64(b[0] & 0x3f)
+	for i := 1; i < length; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, length
+}
+
+type cryptoFrame struct {
+	offset uint64
+	data   []byte
+}
+
+// decryptQUICInitial removes the protection of the client Initial packet at the head of b. It returns the
+// plaintext payload and the length of the packet.
+func decryptQUICInitial(b []byte) ([]byte, int, error) {
+	if len(b) < 6 {
+		return nil, 0, ErrMoreData
+	}
+	if b[0]&0xc0 != 0xc0 {
+		return nil, 0, ErrInvalidData
+	}
+	version, found := quicVersions[binary.BigEndian.Uint32(b[1:5])]
+	if !found || (b[0]&0x30)>>4 != version.initialType {
+		return nil, 0, ErrInvalidData
+	}
+
+	offset := 5
+	dcidLen := int(b[offset])
+	if dcidLen > 20 {
+		return nil, 0, ErrInvalidData
+	}
+	if len(b) < offset+1+dcidLen+1 {
+		return nil, 0, ErrMoreData
+	}
+	dcid := b[offset+1 : offset+1+dcidLen]
+	offset += 1 + dcidLen
+
+	scidLen := int(b[offset])
+	if scidLen > 20 {
+		return nil, 0, ErrInvalidData
+	}
+	offset += 1 + scidLen
+	if len(b) < offset {
+		return nil, 0, ErrMoreData
+	}
+
+	tokenLen, n := readVarint(b[offset:])
+	if n == 0 {
+		return nil, 0, ErrMoreData
+	}
+	offset += n
+	if tokenLen > uint64(len(b)-offset) {
+		return nil, 0, ErrMoreData
+	}
+	offset += int(tokenLen)
+
+	length, n := readVarint(b[offset:])
+	if n == 0 {
+		return nil, 0, ErrMoreData
+	}
+	pnOffset := offset + n
+	// The packet must be long enough for the header protection sample, which starts 4 bytes after the packet number.
+	if length < 4+aes.BlockSize {
+		return nil, 0, ErrInvalidData
+	}
+	if length > uint64(len(b)-pnOffset) {
+		return nil, 0, ErrMoreData
+	}
+	packetLen := pnOffset + int(length)
+
+	key, iv, hpKey := quicInitialKeys(version, dcid)
+
+	hp, err := aes.NewCipher(hpKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	mask := make([]byte, aes.BlockSize)
+	hp.Encrypt(mask, b[pnOffset+4:pnOffset+4+aes.BlockSize])
+
+	// Header is copied, so that the given data is not modified.
+	header := make([]byte, pnOffset+4)
+	copy(header, b)
+	header[0] ^= mask[0] & 0x0f
+	pnLen := int(header[0]&0x03) + 1
+	var packetNumber uint64
+	for i := 0; i < pnLen; i++ {
+		header[pnOffset+i] ^= mask[1+i]
+		packetNumber = packetNumber<<8 | uint64(header[pnOffset+i])
+	}
+	header = header[:pnOffset+pnLen]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, 0, err
+	}
+	nonce := iv
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(packetNumber >> (8 * uint(i)))
+	}
+	payload, err := aead.Open(nil, nonce, b[pnOffset+pnLen:packetLen], header)
+	if err != nil {
+		return nil, 0, ErrInvalidData
+	}
+	return payload, packetLen, nil
+}
+
+// readCryptoFrames returns the CRYPTO frames in the given packet payload.
+func readCryptoFrames(payload []byte, frames []cryptoFrame) ([]cryptoFrame, error) {
+	for len(payload) > 0 {
+		frameType, n := readVarint(payload)
+		if n == 0 {
+			return nil, ErrInvalidData
+		}
+		payload = payload[n:]
+
+		switch frameType {
+		case 0x00, 0x01: // PADDING, PING
+		case 0x02, 0x03: // ACK
+			fields := 4
+			for i := 0; i < fields; i++ {
+				v, n := readVarint(payload)
+				if n == 0 {
+					return nil, ErrInvalidData
+				}
+				payload = payload[n:]
+				if i == 2 {
+					// Each ACK range has two more fields.
+					fields += 2 * int(v)
+				}
+			}
+			if frameType == 0x03 {
+				for i := 0; i < 3; i++ {
+					_, n := readVarint(payload)
+					if n == 0 {
+						return nil, ErrInvalidData
+					}
+					payload = payload[n:]
+				}
+			}
+		case 0x06: // CRYPTO
+			offset, n := readVarint(payload)
+			if n == 0 {
+				return nil, ErrInvalidData
+			}
+			payload = payload[n:]
+			length, n := readVarint(payload)
+			if n == 0 || uint64(len(payload)-n) < length {
+				return nil, ErrInvalidData
+			}
+			payload = payload[n:]
+			frames = append(frames, cryptoFrame{
+				offset: offset,
+				data:   payload[:length],
+			})
+			payload = payload[length:]
+		default:
+			return nil, ErrInvalidData
+		}
+	}
+	return frames, nil
+}
+
+// SniffQUIC returns the server name in the client Initial packets of QUIC. The TLS ClientHello may span multiple
+// Initial packets.
+func SniffQUIC(b []byte) (string, error) {
+	var frames []cryptoFrame
+	for len(b) > 0 && b[0]&0xc0 == 0xc0 {
+		payload, packetLen, err := decryptQUICInitial(b)
+		if err == ErrMoreData || (err != nil && len(frames) > 0) {
+			// Only the Initial packets at the head are used.
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		frames, err = readCryptoFrames(payload, frames)
+		if err != nil {
+			return "", err
+		}
+		b = b[packetLen:]
+	}
+	if len(frames) == 0 {
+		if len(b) == 0 || b[0]&0xc0 == 0xc0 {
+			return "", ErrMoreData
+		}
+		return "", ErrInvalidData
+	}
+
+	sort.Slice(frames, func(i, j int) bool {
+		return frames[i].offset < frames[j].offset
+	})
+	var data []byte
+	for _, frame := range frames {
+		end := frame.offset + uint64(len(frame.data))
+		if frame.offset > uint64(len(data)) {
+			break
+		}
+		if end > uint64(len(data)) {
+			data = append(data, frame.data[uint64(len(data))-frame.offset:]...)
+		}
+	}
+
+	if len(data) < 4 {
+		return "", ErrMoreData
+	}
+	if data[0] != 0x01 /* ClientHello */ {
+		return "", ErrInvalidData
+	}
+	helloLen := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if len(data) < 4+helloLen {
+		return "", ErrMoreData
+	}
+	return ReadClientHello(data[:4+helloLen])
+}
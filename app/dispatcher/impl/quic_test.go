@@ -0,0 +1,243 @@
+package impl_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	. "v2ray.com/core/app/dispatcher/impl"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/transport/ray"
+	. "v2ray.com/ext/assert"
+)
+
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	common.Must(err)
+	return b
+}
+
+// quicV1Initial is a QUIC v1 client Initial packet, whose ClientHello is split into two CRYPTO frames in reverse order.
+var quicV1Initial = decodeHex(
+	"cf00000001088394c8f03e5157080000410c8a33e1c046f4679aed71a331c3b24691cf8d7ec52a1414fda3571f1eb970" +
+		"ba6d9c2500860c55e4704d0f7e5c75112fff15f5ec72e10760d135e2bf77a2dcd588376a1632a8535be8f9068ebe6c29" +
+		"be7cf13cbd43afb032dd9dda8874650adf6406ed68e20fb1dcfac4b120275f832ae3b539a8578f9917b88e57039fee49" +
+		"90468758351eb76eebfd737d7c14aeb03e0c5c499fc8a5338bb9b97dd19af12c1b9f0739580746b1db60f482ca14cfcd" +
+		"721b1f112d6a789cc00b1696579e650b2d476ed765ee7ecc07762fbe7b2c3dcf612d0959f419bfac914afb456ddbfde0" +
+		"5f2e9885aa8d14459bb3e418ebcf313729235cf156a8fbaa9b695c2eb5752b6768948900e3fea029ff407fa3df06")
+
+// quicV2Initial are two QUIC v2 client Initial packets, each of which carries half of the ClientHello.
+var quicV2Initial = decodeHex(
+	"d06b3343cf088394c8f03e5157080000408ff6441017d4acde2e1f5e293278d0eb004f12346e18ca405cd6c7819cf8c3" +
+		"a7917fd488a5d8fa7dfd4327abb2711eec307e6f0b99b80c3a9ff99f166566a044bf70995e5195b6bf4808e0c88de128" +
+		"98dc98ff06ca3044d09439ceb64139a14d3c052181c8e9e2ac37150d81430b3a0a067dbb22d84774ff85b87c8bcd530c" +
+		"9ec483170f5173fd9be42cae817b7f2f7bd36b3343cf088394c8f03e515708000040900dd1ab095ad1fcb197c0e155c6" +
+		"5dc663ab7f8e19c34dc8c246da72c34b324847da25b76668bf2143c95eb8156a870fc115364a790315811f7c68882490" +
+		"b537aa5a4514fe8dc7059240e07636fbc2a5195dcb5ea0a7568eef91bdca5ae066e9351b157d0bf710a33d1480a2901c" +
+		"91c2a0feaf900e8e4b14d4471b6fad626ed90d7c9aab627b36ea66bc0d51e6b78a893a")
+
+// The following packets are laid out like the Initial packets of current browsers: the same connection ID lengths,
+// packet number lengths, datagram sizes and framing. They are generated for the tests, not captured.
+
+// quicChromeInitial1 and quicChromeInitial2 are two 1250-byte datagrams of a client whose ClientHello has an
+// X25519MLKEM768 key share. Like Chrome, the source connection ID is empty, and the CRYPTO frames are out of order
+// and interleaved with PING and PADDING frames.
+var quicChromeInitial1 = decodeHex(
+	"c00000000108329cfffd4a75e498000044d024426cac4d0a38e25e562f1b822beab147795c765abc49af64a2f1183b2f" +
+		"81a3d04b653ac0ca6df0cd2a0059cdbdefe337f87cf585d2a87187a2069c83e6ffbce23b4963c763d7ea75a7396f2e16" +
+		"f0717974fd9fe8095b8a1c3dfa9313a858bfbbb7d0e9e7cd12dff0043e40a5f80f7cf1b23f640086b2294122999fec1b" +
+		"cf7855ba1b1c5a3de5435b15ccd51c65b44674b372cc0fdecd0ec2845a0551959d16bae40325393fc6bf14f9479c1f55" +
+		"4b6ef13a816a740862af88e3677b896a08d019e00d1ebfca7a288d597f4b1e7db7ecd3f2e509925b966f53c925907584" +
+		"8d758853258feb1682086a7c1628d064a4a6cdd87d8f37402ae028dc64dcd85abaab97419aca07848981f3056cbab626" +
+		"8bba3570ae979336914fb8ae8b30b037b63fc56ec581ee2c5957e59bccfa3a0224ad207073c6d4820cf17f54681f396f" +
+		"e85c97d3d0479dfe51e8d77890920a39056270ad0cd78070b7b70ec23aba58b03078e4a180f6f34a5ae89452c713f57b" +
+		"bfd2083e20c8268eb7abe9d25206f6fb9111f1bc8dce924ab58012dc66b0a183a1343a79caf1e385bea753f9473d689b" +
+		"9dbf066f3845fc1aa18bedcd8a1a566f3abe6e31ac83902e67eda126a4652240bd7743e802691503636968ac86e5284e" +
+		"50667fe1011e55306ab98f22d030cdaf1d5c0dab1b8473d4924fe71b768e9d5cfd4719eccc4c99fcb5fa740ee1b527b1" +
+		"3c3a7653e34dbe8b3304d2583c1356b486afdee5c4c8ed16f33bd3eb4e9e56971f8ea2e5015df21095327149f92da7fd" +
+		"c9790cba70185677b72355a31221dffa254373adf068e2eb261f0d89bc82d9f847090bf464316a16fc17e0b143024362" +
+		"aee92361966e83c93d049dc36793bf05497a39b8159d2de33211933da2706a48375605929a9ea32c09870f9b2ec635a2" +
+		"bae927c6e506625847c78e2c1ee86a6303854c005e170e0163e5db1ab24218cc7c71c92977bd092d96b711c7457cdeda" +
+		"25087ff1df725ab640e9e90b78aa7901b68222c9994644b0a85061dab854a8664796557844b702f2a0745ce75714f833" +
+		"aa187b823930710db6dc499ff7f3bf51c05a3aec932330c2c83a8a68931734ad0a9b6becf9741551b6d01ad2a95fc630" +
+		"0f767e3d6a2e2f3924f2dcd64ff3570af008cf8476a03d069f14cc4b0dec340167cd7b008a0304d79b5d941d31b7469e" +
+		"689389ff4f53ffbe271f56f77c13297168318870b4ec804af259ba50d3d56644196da8095d0ccdfee97a82e8fe6f8f2d" +
+		"8874a5d5b22b1a949caad2f5f7fa42a921c688f111d584c76823853487e6a9deff75cc179cd3b0da3656fb78e09a5e73" +
+		"c3dbb8f3c5e651c9d9ffac557e7b0eb8b25351a559f4997a5ebc25ec0d9d40e82a57a0f56b607654cc0eba699b1d46b3" +
+		"a03d08eb0d283d320063553b25eea503786d8ecc3c8123158b91c284895dcbcbf727711afc94d99b8e64786da610abf9" +
+		"a42344aa901bbf9f150f00e5f611467527e1ef9d5cda7980ae96bcebc3efe335eeab3ca00aff82f689561e15281d6434" +
+		"3315c8f0a07285de265c3cf6194f39eec93a745de6cf3d83d82c48c0e20419669794723a2ea20b42f4f317717e836ef0" +
+		"6e7a6a82e546335437a6c45aa7c3bee2f2f7c814c2ea5af772e78b955c702067687550e2906661ae6e46a8e3a2192fd6" +
+		"2edc2ab81598ed6697c010ff6873cba6daad9e03dfc1717080f87aa8924bd20e752566ac75778f9caaa003ad07be53b4" +
+		"a6b4")
+
+var quicChromeInitial2 = decodeHex(
+	"cf0000000108329cfffd4a75e498000044d00a401c0a0bdbc73e022c9eb4d4ed67e9dcf9f8a4219dd3ba85ca7dfd1fda" +
+		"5d0ee926d2cda26c93d8573f13edec8ce5335e13e732bf3111fa52e5cde6846be53ff3d182c99d01f721185d17e09979" +
+		"77f9dbcd0c520d451d3c7351e9d3353dd959f7b741541f6159062bd10cd7633da4ac61da763162d40dbfb788390a45a9" +
+		"d41ebde8fa4cd7c3706c2cc02e9289012ddbcb14e57317537eedd5707b8774733c61d46809a7d419d0f6a94d1342711c" +
+		"8acd706d9678795da0c4b491f1d6809655f51eb849621f0c274fa45e3148fade6dc742280eb5d001594fed69e3ff61f6" +
+		"637624fe0c0d115c92c6cc85587cb5bb92f342a639676cde9c80450e9dfd2cca5a17617b19f45ec9b123d5c0a1364319" +
+		"9ef6a2d81fe990d59340429cd67f966a84a92ad13b1d2a34eb387d0675722c97327064279f172eb7bf08f7f515af3437" +
+		"2dd52d1adb4a1607f63963fd710e67424cd3100a1bfaa6fea2e58d30bb714f01db9b4240cac03c3a6aa8d1ee6f9036f4" +
+		"61218ae0e653994c84a4795e9657549d1f40b2d8b66a5fe5075706bc1aa3d925ac03d81cca896706483f0fe04b0956a7" +
+		"6731c846605984e2de7dce461016da820ac90d1e62cc74cd1db36b15a996eea782026c1d3803bc7553f4447ebcf09cfd" +
+		"e85a7142070774bff280fc6f2eacf9ad17a9debc76a867d200d44c81661155e33021275733492f111f95dffe538c3c02" +
+		"329ef222af2efc2f7d92d6f21083735b5b9617c0c7d623697b5cf951f5839339ddf8d95200f27dabba16d7343e1383ba" +
+		"5bc47657cf9eca2388e9840ff38f31620d6b00cbc588877872d02115f8fc5b495003af2b262848ce9bdb0a9fd3751a91" +
+		"45e30041c337c86226d4f25d5a9eac222821c7527cec41d63b36fc8464c3914fa4a7a3585b08eec6bce85965cb922ef2" +
+		"dfc62bdb667581da6b3b49d5e07083b1c730d9a0933ca19666d4706d3afff2b8a24744d2851940581d6f41fd52b0dd6f" +
+		"a74b57a4abf03f19e7b6be34a0bff6322c7fd24fcfab8914f7dc2416951f87f2578161d4e052421d891c2f771b186b1d" +
+		"6495b9fefdfb514a6a3b03c8c63b589476af1dc86bccc2eb4bb8031f4abd652559faf44718f53778e67b631873322df8" +
+		"90f33d942d9e0133ebafd883a7a71ef848211caa54d2bf15a5f77f9d50eab6ab49ecd8f84e5a67c954ebcad505589c36" +
+		"d146f4debaa0e8984ca5127199bb5370ee8ffe575d1562284f594e6d736cede2fc607756fe3201dc168107bc1ad323ed" +
+		"be212abc5fd3d8e5873a70d9f3aecd6569b46c81adc8e1d30ff16d510594afe23fe21a671af4fb343063f1fd84bae6ae" +
+		"1c529737c31a10538a9eb6110e1865333ce62fce26583fb90fe4376851173d9e72f68fa3035b44753a53e78e2b66850a" +
+		"e7323253c7014323dd183272e492011eb58b0409219e21c58a053d0bc8faeed42a0a87fc82c3ace65c959216748cee77" +
+		"5701b4a25121740061b2fba4367970150db3c3adc1d1a85ca7d61e48d18385f2a68cd6bdf7ba1ef11213bc46b8f729ab" +
+		"2b34bc7c7ba60052c81333e0eb1f64ce77a3eb1a4a9e87bb376f0d2facddd7f252c7a3e251f9ea3fe2b287d30b7d96a6" +
+		"e91ee990b7a8473c134e93b0aa32e8d59937f276815c9e9fc1bc9d8e123e2bb7e5e4103b9098cbc585f6d9a922a96097" +
+		"d65da3fa57455e42a4f933040fdb78b0043509463e8752517fcd373da37beaf8c6f37b41b45a7b1b0703d840c0f07c37" +
+		"971f")
+
+// quicFirefoxInitial is a 1357-byte datagram of a client with an 8-byte destination and 3-byte source connection
+// ID, and a 2-byte packet number. The ClientHello is in one CRYPTO frame followed by PADDING.
+var quicFirefoxInitial = decodeHex(
+	"cd0000000108af5e39cc416e734d03373c5e004538887d790f4e59bb37afe4487615863a3e57119d3a01039aa6968ab6" +
+		"f7a11db84dab2e3b50085d170af63d861dabad4a7a6ad9b38b52a37c09201b247e4d7a0cf0df4f3fb286256ef03c132b" +
+		"93b3e3fffacca234bd25a517706768a25772e67e4d380931a873df10ba893c8dd60b67ff2bd764cebe15dd3b64214a2b" +
+		"3e0ae2b46001617543a6698dc73e4e231e2599b4b9bd37dd37805ad8735d51047ab5107c644acbffc9231266989a6e74" +
+		"910c0f3cbf2493855c7a3dd180a9c9530065ed76e70f658e2f302399eda4c2418493d7487c021685caf64d91ba53bfea" +
+		"f687716b01c13d7fbcc102320e93ed6d7f9f51d74733e0907ae3d3841db57d6d60d5f58fa5747269a494a8957fc8ecdf" +
+		"6e88c543fdfbd7c65c21edeb1f524cb2d9b91b9379d0149b5c66371e9bb70effe8bdfa6c2ae871e65caca6c62b0aa332" +
+		"9f6f46a4eb3d9d9a9803deace40980c5058566c4c2d590e19bf52fcc1a5f95c1627f7c04d18bff11090c15591547f1e7" +
+		"6fea73e6fc847d9869b7a340817158b96c236c4dd263c220078ae4359a0197e57cf0a4216518afe0db62dc832fc97ef8" +
+		"74624b200517f6072075602ce243fd8aaaf079622fef3e313cf669b786e720839038495c673254aa00dbc66616474b07" +
+		"6d3c6d10ce4c995bf744f563b807793057eb4942ef5a9f50eaa289c29f572aa7274a3dcad1829d51567d2bc388e999d6" +
+		"a4edb84e464bd6c94df49ccd5f2c1a94f5c2a06c7a4fb225cc778cb79c9249e45960a9ee4c1db37991504e7f8c2cf473" +
+		"0ff5dcb62c55093b36552afafd17c9cf56810635297f55539320fb7daeeb0207d6ff7472c1d826ac77ec30b6bb212b89" +
+		"750aff2021ed46bded4a8d8a6a15e2e927badc52479c630c1b4487d7ba1b40c107d8fafbffcd0b386112f8204a870112" +
+		"34e68aae0c245ca6bc13ecdd5e78e25a6edd8307ace6cdc2466705efe7dc5596c2ce9c3e8c780da148270aacb8840776" +
+		"292e11acca5d5cba6bb7d8b522d7e1c07007cacfd29c9b412f847fb094fc9a4335decfb8e3f7f4d371ed25e881b08699" +
+		"4319c8ee3ea3a6e8a2052b4119322f0596e3142bc9659d6083a29f75453e556a49c42fae8a1c77a2a930a77e735eb551" +
+		"b3563d13b2064152bce73f1e693aee57c44fc3b3528e08d3f28592d0cda7469ed28ba4ee33d91948e9e1b7e9f38db854" +
+		"a662cb52f60c68c73d00dfecea9dde2ac58e2bd1e64d3d38975b6809b44670d6a958249ab266d8eca9921ce92a226ad9" +
+		"18d47f88da5aed274bd14b9a54f81faf495feac12bf2920ccb197781e74e53a70089b22770713504908bd4aa4e91c459" +
+		"8fb542dcb137c5534d56f19fd574527989008d7505262b235a0e7c6d9b9c4bfae0c4063cc8c5e59e61f147709d38da1d" +
+		"01dbbee7b2db050601aea86025d62fafe0222c26159fb71e25d205893c06d158590253cd11895d62cf773fe9dab05bad" +
+		"1c2c7f038b41eb2023e7b56f048e1a4cc2e9894869b35afc7c79575084b5c80b893239811810f558a6867aa410864c82" +
+		"9f54b552197471b57ff96e090139b249961d95bde0e7c2ca9915a793939f9adc2636207130ea331246fcecca5ca24763" +
+		"bced8984b014bfca4b350f221434d74ac423c04c08616c1e13dbff8233a2a8314103be32f052ec896c43aeea0e604496" +
+		"7264de57aadbc9ab581443ef54a349d03f8805405a8e22d33934967c7a4658594d82e648c2bd70007143e8b3d0f56207" +
+		"4271d291c66d21d3b7518b5ea78f229342ab846e1a597f3c0249f73e4fb0d202760e81c36a1a15134d89590a9475ca80" +
+		"993f4c6e14492e4db8cf91982f187418b007cdb8360a97ae339da3645dbb8092b4bc6e693e5cb59cc14dd4851f182d8b" +
+		"a31afa70ec8fc2ee833d8e4fd9")
+
+func TestQUICSniffer(t *testing.T) {
+	assert := With(t)
+
+	domain, err := SniffQUIC(quicV1Initial)
+	assert(err, IsNil)
+	assert(domain, Equals, "www.v2ray.com")
+
+	domain, err = SniffQUIC(quicV2Initial)
+	assert(err, IsNil)
+	assert(domain, Equals, "www.v2ray.com")
+
+	domain, err = SniffQUIC(append(append([]byte{}, quicChromeInitial1...), quicChromeInitial2...))
+	assert(err, IsNil)
+	assert(domain, Equals, "www.v2ray.com")
+
+	domain, err = SniffQUIC(quicFirefoxInitial)
+	assert(err, IsNil)
+	assert(domain, Equals, "www.v2ray.com")
+}
+
+func TestQUICSnifferMoreData(t *testing.T) {
+	assert := With(t)
+
+	_, err := SniffQUIC(quicV1Initial[:100])
+	assert(err, Equals, ErrMoreData)
+
+	// Only the first packet is available.
+	_, err = SniffQUIC(quicV2Initial[:len(quicV2Initial)/2])
+	assert(err, Equals, ErrMoreData)
+
+	_, err = SniffQUIC(quicChromeInitial1)
+	assert(err, Equals, ErrMoreData)
+}
+
+func TestQUICSnifferInvalidData(t *testing.T) {
+	assert := With(t)
+
+	_, err := SniffQUIC([]byte("GET / HTTP/1.1\r\n"))
+	assert(err, Equals, ErrInvalidData)
+
+	corrupted := make([]byte, len(quicV1Initial))
+	copy(corrupted, quicV1Initial)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = SniffQUIC(corrupted)
+	assert(err, Equals, ErrInvalidData)
+
+	unknownVersion := make([]byte, len(quicV1Initial))
+	copy(unknownVersion, quicV1Initial)
+	unknownVersion[4] = 0x02
+	_, err = SniffQUIC(unknownVersion)
+	assert(err, Equals, ErrInvalidData)
+}
+
+func TestQUICSnifferTruncated(t *testing.T) {
+	assert := With(t)
+
+	cases := [][]byte{
+		// Source connection ID is longer than the packet.
+		decodeHex("c0000000010014"),
+		// Token length is missing.
+		decodeHex("c00000000100010a"),
+		// Token is longer than the packet.
+		decodeHex("c0000000010000443f0102"),
+		// Packet length is longer than the packet.
+		decodeHex("c000000001000000449a0102030405060708090a0b0c0d0e0f10111213"),
+	}
+	for _, input := range cases {
+		_, err := SniffQUIC(input)
+		assert(err, Equals, ErrMoreData)
+	}
+
+	// Packet length is too short for the header protection sample.
+	_, err := SniffQUIC(decodeHex("c00000000100000010000102030405060708090a0b0c0d0e0f"))
+	assert(err, Equals, ErrInvalidData)
+
+	for i := 0; i < len(quicV1Initial); i++ {
+		_, err := SniffQUIC(quicV1Initial[:i])
+		assert(err, Equals, ErrMoreData)
+	}
+}
+
+func TestQUICSniffStream(t *testing.T) {
+	assert := With(t)
+
+	// Each datagram is a separate buffer in the stream.
+	newStream := func() ray.InputStream {
+		stream := ray.NewStream(context.Background())
+		for _, packet := range [][]byte{quicChromeInitial1, quicChromeInitial2} {
+			b := buf.New()
+			b.Append(packet)
+			assert(stream.WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+		}
+		return stream
+	}
+	sniffer := NewSniffer([]proxyman.KnownProtocols{proxyman.KnownProtocols_QUIC})
+
+	// The two Initial packets don't fit in the default byte limit of TCP.
+	_, err := sniffer.SniffStream(context.Background(), newStream(), time.Second, DefaultSniffingByteLimit)
+	assert(err, Equals, ErrInvalidData)
+
+	result, err := sniffer.SniffStream(context.Background(), newStream(), time.Second, DefaultPacketSniffingByteLimit)
+	assert(err, IsNil)
+	assert(result.Protocol, Equals, proxyman.KnownProtocols_QUIC)
+	assert(result.Domain, Equals, "www.v2ray.com")
+}
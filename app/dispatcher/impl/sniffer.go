@@ -2,15 +2,20 @@ package impl
 
 import (
 	"bytes"
+	"context"
 	"strings"
+	"time"
 
 	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/serial"
+	"v2ray.com/core/transport/ray"
 )
 
 var (
 	ErrMoreData    = newError("need more data")
 	ErrInvalidData = newError("invalid data")
+	ErrTimeout     = newError("timeout on sniffing")
 )
 
 func ContainsValidHTTPMethod(b []byte) bool {
@@ -168,7 +173,15 @@ func SniffTLS(b []byte) (string, error) {
 	return ReadClientHello(b[5 : 5+headerLen])
 }
 
+// SniffResult is the result of protocol sniffing.
+type SniffResult struct {
+	Protocol proxyman.KnownProtocols
+	// Domain is the destination domain found in the data. It is empty if the protocol carries no domain.
+	Domain string
+}
+
 type Sniffer struct {
+	plist []proxyman.KnownProtocols
 	slist []func([]byte) (string, error)
 	err   []error
 }
@@ -183,9 +196,14 @@ func NewSniffer(sniferList []proxyman.KnownProtocols) *Sniffer {
 			f = SniffHTTP
 		case proxyman.KnownProtocols_TLS:
 			f = SniffTLS
+		case proxyman.KnownProtocols_QUIC:
+			f = SniffQUIC
+		case proxyman.KnownProtocols_BitTorrent:
+			f = SniffBitTorrent
 		default:
 			panic("Unsupported protocol")
 		}
+		s.plist = append(s.plist, protocol)
 		s.slist = append(s.slist, f)
 	}
 	s.err = make([]error, len(s.slist))
@@ -193,7 +211,7 @@ func NewSniffer(sniferList []proxyman.KnownProtocols) *Sniffer {
 	return s
 }
 
-func (s *Sniffer) Sniff(payload []byte) (string, error) {
+func (s *Sniffer) Sniff(payload []byte) (SniffResult, error) {
 	sniffed := false
 	for idx, sniffer := range s.slist {
 		if s.err[idx] != nil {
@@ -202,14 +220,56 @@ func (s *Sniffer) Sniff(payload []byte) (string, error) {
 		sniffed = true
 		domain, err := sniffer(payload)
 		if err == nil {
-			return domain, nil
+			return SniffResult{
+				Protocol: s.plist[idx],
+				Domain:   domain,
+			}, nil
 		}
 		if err != ErrMoreData {
 			s.err[idx] = err
 		}
 	}
 	if sniffed {
-		return "", ErrMoreData
+		return SniffResult{}, ErrMoreData
+	}
+	return SniffResult{}, s.err[0]
+}
+
+// SniffStream sniffs the data at the head of the given stream, without consuming it. It tries again every time
+// more data arrives, until a result is found, the first byteLimit bytes are sniffed, or timeout is reached.
+func (s *Sniffer) SniffStream(ctx context.Context, stream ray.InputStream, timeout time.Duration, byteLimit int) (SniffResult, error) {
+	var payload *buf.Buffer
+	if byteLimit <= buf.Size {
+		payload = buf.New()
+	} else {
+		payload = buf.NewLocal(byteLimit)
+	}
+	defer payload.Release()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		stream.Peek(payload)
+		if payload.Len() > byteLimit {
+			payload.Slice(0, byteLimit)
+		}
+		if !payload.IsEmpty() {
+			result, err := s.Sniff(payload.Bytes())
+			if err != ErrMoreData {
+				return result, err
+			}
+		}
+		if payload.Len() >= byteLimit {
+			return SniffResult{}, ErrInvalidData
+		}
+
+		select {
+		case <-ctx.Done():
+			return SniffResult{}, ctx.Err()
+		case <-timer.C:
+			return SniffResult{}, ErrTimeout
+		case <-stream.DataSignal():
+		}
 	}
-	return "", s.err[0]
 }
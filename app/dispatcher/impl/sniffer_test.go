@@ -1,9 +1,13 @@
 package impl_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/transport/ray"
 
 	. "v2ray.com/core/app/dispatcher/impl"
 	. "v2ray.com/ext/assert"
@@ -192,3 +196,51 @@ func TestUnknownSniffer(t *testing.T) {
 
 	assert(func() { NewSniffer([]proxyman.KnownProtocols{proxyman.KnownProtocols(-1)}) }, Panics)
 }
+
+func TestSniffStream(t *testing.T) {
+	assert := With(t)
+
+	stream := ray.NewStream(context.Background())
+	go func() {
+		for _, part := range []string{"GET / HTTP/1.1", "\r\nHost: www.v2ray.com\r\n"} {
+			time.Sleep(time.Millisecond * 50)
+			b := buf.New()
+			b.Append([]byte(part))
+			stream.WriteMultiBuffer(buf.NewMultiBufferValue(b))
+		}
+	}()
+
+	start := time.Now()
+	sniffer := NewSniffer([]proxyman.KnownProtocols{proxyman.KnownProtocols_HTTP})
+	result, err := sniffer.SniffStream(context.Background(), stream, time.Second, 2048)
+	assert(err, IsNil)
+	assert(result.Protocol, Equals, proxyman.KnownProtocols_HTTP)
+	assert(result.Domain, Equals, "www.v2ray.com")
+	assert(time.Since(start) < time.Millisecond*500, IsTrue)
+}
+
+func TestSniffStreamTimeout(t *testing.T) {
+	assert := With(t)
+
+	stream := ray.NewStream(context.Background())
+	b := buf.New()
+	b.Append([]byte("GET / HTTP/1.1"))
+	assert(stream.WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	sniffer := NewSniffer([]proxyman.KnownProtocols{proxyman.KnownProtocols_HTTP})
+	_, err := sniffer.SniffStream(context.Background(), stream, time.Millisecond*100, 2048)
+	assert(err, Equals, ErrTimeout)
+}
+
+func TestSniffStreamByteLimit(t *testing.T) {
+	assert := With(t)
+
+	stream := ray.NewStream(context.Background())
+	b := buf.New()
+	b.Append([]byte("GET /a/very/long/path HTTP/1.1\r\nHost: www.v2ray.com\r\n"))
+	assert(stream.WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	sniffer := NewSniffer([]proxyman.KnownProtocols{proxyman.KnownProtocols_HTTP})
+	_, err := sniffer.SniffStream(context.Background(), stream, time.Second, 20)
+	assert(err, Equals, ErrInvalidData)
+}
@@ -0,0 +1,81 @@
+package impl
+
+import (
+	"sync"
+
+	"v2ray.com/core/transport/ray"
+)
+
+var errOutboundFailed = newError("outbound failed")
+
+// outputTracker is the output stream of an outbound. It signals when the outbound closes the stream, which is the end
+// of the connection, and keeps the error that ends the outbound. As a proxy may close the stream before its handler
+// does, the stream is closed by the first call, and the error is given by the last call.
+type outputTracker struct {
+	ray.OutputStream
+	access sync.Mutex
+	once   sync.Once
+	done   chan struct{}
+	err    error
+}
+
+func newOutputTracker(output ray.OutputStream) *outputTracker {
+	return &outputTracker{
+		OutputStream: output,
+		done:         make(chan struct{}),
+	}
+}
+
+func (t *outputTracker) finish(err error) {
+	t.access.Lock()
+	t.err = err
+	t.access.Unlock()
+	t.once.Do(func() {
+		close(t.done)
+	})
+}
+
+// Close implements ray.OutputStream.
+func (t *outputTracker) Close() {
+	t.OutputStream.Close()
+	t.finish(nil)
+}
+
+// CloseError implements ray.OutputStream.
+func (t *outputTracker) CloseError() {
+	t.OutputStream.CloseError()
+	t.finish(errOutboundFailed)
+}
+
+// CloseWithError implements ray.ErrorCloser.
+func (t *outputTracker) CloseWithError(err error) {
+	t.OutputStream.CloseError()
+	t.finish(err)
+}
+
+// wait waits until the outbound closes the stream, and returns the error that ends the outbound, or nil if the
+// outbound finishes normally.
+func (t *outputTracker) wait() error {
+	<-t.done
+	t.access.Lock()
+	defer t.access.Unlock()
+	return t.err
+}
+
+// trackedRay is an outbound ray whose output is tracked.
+type trackedRay struct {
+	ray.OutboundRay
+	output *outputTracker
+}
+
+func newTrackedRay(outbound ray.OutboundRay) *trackedRay {
+	return &trackedRay{
+		OutboundRay: outbound,
+		output:      newOutputTracker(outbound.OutboundOutput()),
+	}
+}
+
+// OutboundOutput implements ray.OutboundRay.
+func (r *trackedRay) OutboundOutput() ray.OutputStream {
+	return r.output
+}
@@ -0,0 +1,163 @@
+package dns
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"v2ray.com/core/common/cache"
+	"v2ray.com/core/common/net"
+)
+
+const (
+	DefaultCacheSize = 1024
+	DefaultStaleTTL  = time.Hour * 24
+)
+
+type DomainRecord struct {
+	IP         []net.IP
+	Expire     time.Time
+	LastAccess time.Time
+
+	ttl        time.Duration
+	refreshing bool
+}
+
+func (r *DomainRecord) Expired() bool {
+	return r.Expire.Before(time.Now())
+}
+
+func (r *DomainRecord) Inactive() bool {
+	now := time.Now()
+	return r.Expire.Before(now) || r.LastAccess.Add(time.Minute*5).Before(now)
+}
+
+// shouldPrefetch returns true if the record is in the last 10% of its TTL.
+func (r *DomainRecord) shouldPrefetch(now time.Time) bool {
+	return r.Expire.Sub(now) < r.ttl/10
+}
+
+// recordCache is a bounded cache of DNS records.
+type recordCache struct {
+	// hits and misses are accessed atomically, and they must be 64-bit aligned.
+	hits   uint64
+	misses uint64
+
+	sync.Mutex
+	records    *cache.Lru
+	minTTL     time.Duration
+	maxTTL     time.Duration
+	prefetch   bool
+	serveStale bool
+	staleTTL   time.Duration
+}
+
+func newRecordCache(config *CacheConfig) *recordCache {
+	size := int(config.GetSize())
+	if size == 0 {
+		size = DefaultCacheSize
+	}
+	c := &recordCache{
+		records:    cache.NewLru(size),
+		minTTL:     time.Second * time.Duration(config.GetMinTtl()),
+		maxTTL:     time.Second * time.Duration(config.GetMaxTtl()),
+		prefetch:   config.GetPrefetch(),
+		serveStale: config.GetServeStale(),
+		staleTTL:   DefaultStaleTTL,
+	}
+	if config.GetStaleTtl() > 0 {
+		c.staleTTL = time.Second * time.Duration(config.GetStaleTtl())
+	}
+	return c
+}
+
+// Get returns the IPs of the given domain if its record hasn't expired. The second return value is true if the
+// caller should refresh the record in background.
+func (c *recordCache) Get(domain string) ([]net.IP, bool) {
+	v, found := c.records.Get(domain)
+	if !found {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	record := v.(*DomainRecord)
+
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	if record.Expire.Before(now) {
+		atomic.AddUint64(&c.misses, 1)
+		if !c.serveStale {
+			c.records.Remove(domain)
+		}
+		return nil, false
+	}
+
+	atomic.AddUint64(&c.hits, 1)
+	record.LastAccess = now
+	refresh := false
+	if c.prefetch && !record.refreshing && record.shouldPrefetch(now) {
+		record.refreshing = true
+		refresh = true
+	}
+	return record.IP, refresh
+}
+
+// GetStale returns the IPs of the given domain, if its record has expired no longer than the stale TTL.
+func (c *recordCache) GetStale(domain string) []net.IP {
+	if !c.serveStale {
+		return nil
+	}
+	v, found := c.records.Get(domain)
+	if !found {
+		return nil
+	}
+	record := v.(*DomainRecord)
+
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	if record.Expire.Add(c.staleTTL).Before(now) {
+		c.records.Remove(domain)
+		return nil
+	}
+	record.LastAccess = now
+	return record.IP
+}
+
+// Put adds the given record into cache, with its TTL adjusted to the configured range.
+func (c *recordCache) Put(domain string, record *ARecord) {
+	now := time.Now()
+	ttl := record.Expire.Sub(now)
+	if c.minTTL > 0 && ttl < c.minTTL {
+		ttl = c.minTTL
+	}
+	if c.maxTTL > 0 && ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+
+	c.records.Put(domain, &DomainRecord{
+		IP:         record.IPs,
+		Expire:     now.Add(ttl),
+		LastAccess: now,
+		ttl:        ttl,
+	})
+}
+
+// EndRefresh marks the record of the given domain as not being refreshed, so that it may be refreshed again.
+func (c *recordCache) EndRefresh(domain string) {
+	v, found := c.records.Peek(domain)
+	if !found {
+		return
+	}
+
+	c.Lock()
+	v.(*DomainRecord).refreshing = false
+	c.Unlock()
+}
+
+// Stats returns the number of cache hits and misses.
+func (c *recordCache) Stats() (uint64, uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
@@ -5,6 +5,7 @@ import fmt "fmt"
 import math "math"
 import v2ray_core_common_net "v2ray.com/core/common/net"
 import v2ray_core_common_net2 "v2ray.com/core/common/net"
+import v2ray_core_app_router "v2ray.com/core/app/router"
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -17,12 +18,85 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type Config_QueryStrategy int32
+
+const (
+	// Query name servers one by one, until one of them returns an answer.
+	Config_Sequential Config_QueryStrategy = 0
+	// Query all name servers at the same time, and take the first answer with IPs.
+	Config_Race Config_QueryStrategy = 1
+	// Query all name servers at the same time, and take answers in the order of name servers.
+	// An answer from a latter server is used only if all servers before it fail, or they don't
+	// answer within the grace period.
+	Config_Prefer Config_QueryStrategy = 2
+)
+
+var Config_QueryStrategy_name = map[int32]string{
+	0: "Sequential",
+	1: "Race",
+	2: "Prefer",
+}
+var Config_QueryStrategy_value = map[string]int32{
+	"Sequential": 0,
+	"Race":       1,
+	"Prefer":     2,
+}
+
+func (x Config_QueryStrategy) String() string {
+	return proto.EnumName(Config_QueryStrategy_name, int32(x))
+}
+func (Config_QueryStrategy) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 0} }
+
+type Config_IPType int32
+
+const (
+	// Query A records. AAAA records are also queried if the name server supports multiple questions in one message.
+	Config_Auto Config_IPType = 0
+	// Query A records only.
+	Config_IPv4 Config_IPType = 1
+	// Query AAAA records only.
+	Config_IPv6 Config_IPType = 2
+	// Query both A and AAAA records.
+	Config_Both Config_IPType = 3
+)
+
+var Config_IPType_name = map[int32]string{
+	0: "Auto",
+	1: "IPv4",
+	2: "IPv6",
+	3: "Both",
+}
+var Config_IPType_value = map[string]int32{
+	"Auto": 0,
+	"IPv4": 1,
+	"IPv6": 2,
+	"Both": 3,
+}
+
+func (x Config_IPType) String() string {
+	return proto.EnumName(Config_IPType_name, int32(x))
+}
+func (Config_IPType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 1} }
+
 type Config struct {
 	// Nameservers used by this DNS. Only traditional UDP servers are support at the moment.
 	// A special value 'localhost' as a domain address can be set to use DNS on local system.
 	NameServers []*v2ray_core_common_net2.Endpoint `protobuf:"bytes,1,rep,name=NameServers" json:"NameServers,omitempty"`
 	// Static hosts. Domain to IP.
 	Hosts map[string]*v2ray_core_common_net.IPOrDomain `protobuf:"bytes,2,rep,name=Hosts" json:"Hosts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Fake DNS settings. Fake DNS is disabled if not set. Fake IPs are answered to clients by the DNS outbound.
+	FakeDns *FakeDnsConfig `protobuf:"bytes,3,opt,name=fake_dns,json=fakeDns" json:"fake_dns,omitempty"`
+	// Strategy to query multiple name servers.
+	QueryStrategy Config_QueryStrategy `protobuf:"varint,4,opt,name=query_strategy,json=queryStrategy,enum=v2ray.core.app.dns.Config_QueryStrategy" json:"query_strategy,omitempty"`
+	// Time in milliseconds to wait for preferred name servers, after an answer is received from a
+	// less preferred one. Only used by Prefer strategy. Default value is 100 if unset.
+	GracePeriod uint32 `protobuf:"varint,5,opt,name=grace_period,json=gracePeriod" json:"grace_period,omitempty"`
+	// Types of IP addresses to query.
+	IpType Config_IPType `protobuf:"varint,6,opt,name=ip_type,json=ipType,enum=v2ray.core.app.dns.Config_IPType" json:"ip_type,omitempty"`
+	// Settings of DNS cache.
+	Cache *CacheConfig `protobuf:"bytes,7,opt,name=cache" json:"cache,omitempty"`
+	// Nameservers with additional settings. They are used after NameServers.
+	NameServer []*NameServerConfig `protobuf:"bytes,8,rep,name=name_server,json=nameServer" json:"name_server,omitempty"`
 }
 
 func (m *Config) Reset()                    { *m = Config{} }
@@ -44,30 +118,277 @@ func (m *Config) GetHosts() map[string]*v2ray_core_common_net.IPOrDomain {
 	return nil
 }
 
+func (m *Config) GetFakeDns() *FakeDnsConfig {
+	if m != nil {
+		return m.FakeDns
+	}
+	return nil
+}
+
+func (m *Config) GetQueryStrategy() Config_QueryStrategy {
+	if m != nil {
+		return m.QueryStrategy
+	}
+	return Config_Sequential
+}
+
+func (m *Config) GetGracePeriod() uint32 {
+	if m != nil {
+		return m.GracePeriod
+	}
+	return 0
+}
+
+func (m *Config) GetIpType() Config_IPType {
+	if m != nil {
+		return m.IpType
+	}
+	return Config_Auto
+}
+
+func (m *Config) GetCache() *CacheConfig {
+	if m != nil {
+		return m.Cache
+	}
+	return nil
+}
+
+func (m *Config) GetNameServer() []*NameServerConfig {
+	if m != nil {
+		return m.NameServer
+	}
+	return nil
+}
+
+type NameServerConfig struct {
+	// Address of the nameserver. A special value 'localhost' can be used as in Config.NameServers.
+	Address *v2ray_core_common_net2.Endpoint `protobuf:"bytes,1,opt,name=address" json:"address,omitempty"`
+	// If not empty, answers from this nameserver are accepted only when all their IPs are in these ranges.
+	ExpectedIp []*v2ray_core_app_router.GeoIP `protobuf:"bytes,2,rep,name=expected_ip,json=expectedIp" json:"expected_ip,omitempty"`
+	// Answers from this nameserver are discarded if any of their IPs is in these ranges.
+	RejectedIp []*v2ray_core_app_router.GeoIP `protobuf:"bytes,3,rep,name=rejected_ip,json=rejectedIp" json:"rejected_ip,omitempty"`
+	// EDNS Client Subnet settings. Client subnet is not sent if not set.
+	ClientSubnet *ClientSubnetConfig `protobuf:"bytes,4,opt,name=client_subnet,json=clientSubnet" json:"client_subnet,omitempty"`
+}
+
+func (m *NameServerConfig) Reset()                    { *m = NameServerConfig{} }
+func (m *NameServerConfig) String() string            { return proto.CompactTextString(m) }
+func (*NameServerConfig) ProtoMessage()               {}
+func (*NameServerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *NameServerConfig) GetAddress() *v2ray_core_common_net2.Endpoint {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *NameServerConfig) GetExpectedIp() []*v2ray_core_app_router.GeoIP {
+	if m != nil {
+		return m.ExpectedIp
+	}
+	return nil
+}
+
+func (m *NameServerConfig) GetRejectedIp() []*v2ray_core_app_router.GeoIP {
+	if m != nil {
+		return m.RejectedIp
+	}
+	return nil
+}
+
+func (m *NameServerConfig) GetClientSubnet() *ClientSubnetConfig {
+	if m != nil {
+		return m.ClientSubnet
+	}
+	return nil
+}
+
+type ClientSubnetConfig struct {
+	// Subnet to be sent in CIDR form, such as "1.2.3.0/24". If empty, the subnet is derived from the
+	// source IP of the inbound connection, if the source IP is a public one.
+	Subnet string `protobuf:"bytes,1,opt,name=subnet" json:"subnet,omitempty"`
+	// Prefix length of subnets derived from IPv4 sources. Default value is 24 if unset.
+	Ipv4Prefix uint32 `protobuf:"varint,2,opt,name=ipv4_prefix,json=ipv4Prefix" json:"ipv4_prefix,omitempty"`
+	// Prefix length of subnets derived from IPv6 sources. Default value is 56 if unset.
+	Ipv6Prefix uint32 `protobuf:"varint,3,opt,name=ipv6_prefix,json=ipv6Prefix" json:"ipv6_prefix,omitempty"`
+}
+
+func (m *ClientSubnetConfig) Reset()                    { *m = ClientSubnetConfig{} }
+func (m *ClientSubnetConfig) String() string            { return proto.CompactTextString(m) }
+func (*ClientSubnetConfig) ProtoMessage()               {}
+func (*ClientSubnetConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *ClientSubnetConfig) GetSubnet() string {
+	if m != nil {
+		return m.Subnet
+	}
+	return ""
+}
+
+func (m *ClientSubnetConfig) GetIpv4Prefix() uint32 {
+	if m != nil {
+		return m.Ipv4Prefix
+	}
+	return 0
+}
+
+func (m *ClientSubnetConfig) GetIpv6Prefix() uint32 {
+	if m != nil {
+		return m.Ipv6Prefix
+	}
+	return 0
+}
+
+type CacheConfig struct {
+	// Max number of domains in cache. Default value is 1024 if unset.
+	Size uint32 `protobuf:"varint,1,opt,name=size" json:"size,omitempty"`
+	// Min TTL in seconds of cached records. Records with smaller TTL are kept for this long.
+	MinTtl uint32 `protobuf:"varint,2,opt,name=min_ttl,json=minTtl" json:"min_ttl,omitempty"`
+	// Max TTL in seconds of cached records. No limit if unset.
+	MaxTtl uint32 `protobuf:"varint,3,opt,name=max_ttl,json=maxTtl" json:"max_ttl,omitempty"`
+	// Whether or not to refresh a record in background, when it is used shortly before it expires.
+	Prefetch bool `protobuf:"varint,4,opt,name=prefetch" json:"prefetch,omitempty"`
+	// Whether or not to answer with expired records, when all name servers fail. See RFC 8767.
+	ServeStale bool `protobuf:"varint,5,opt,name=serve_stale,json=serveStale" json:"serve_stale,omitempty"`
+	// Time in seconds that a record may be served after it expires. Default value is 86400 if unset.
+	StaleTtl uint32 `protobuf:"varint,6,opt,name=stale_ttl,json=staleTtl" json:"stale_ttl,omitempty"`
+}
+
+func (m *CacheConfig) Reset()                    { *m = CacheConfig{} }
+func (m *CacheConfig) String() string            { return proto.CompactTextString(m) }
+func (*CacheConfig) ProtoMessage()               {}
+func (*CacheConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *CacheConfig) GetSize() uint32 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *CacheConfig) GetMinTtl() uint32 {
+	if m != nil {
+		return m.MinTtl
+	}
+	return 0
+}
+
+func (m *CacheConfig) GetMaxTtl() uint32 {
+	if m != nil {
+		return m.MaxTtl
+	}
+	return 0
+}
+
+func (m *CacheConfig) GetPrefetch() bool {
+	if m != nil {
+		return m.Prefetch
+	}
+	return false
+}
+
+func (m *CacheConfig) GetServeStale() bool {
+	if m != nil {
+		return m.ServeStale
+	}
+	return false
+}
+
+func (m *CacheConfig) GetStaleTtl() uint32 {
+	if m != nil {
+		return m.StaleTtl
+	}
+	return 0
+}
+
+type FakeDnsConfig struct {
+	// IP range in CIDR form, such as "198.18.0.0/15", that fake IPs are allocated from.
+	IpPool string `protobuf:"bytes,1,opt,name=ip_pool,json=ipPool" json:"ip_pool,omitempty"`
+	// Max number of domains to be remembered. Default value is 65535 if unset.
+	PoolSize uint32 `protobuf:"varint,2,opt,name=pool_size,json=poolSize" json:"pool_size,omitempty"`
+}
+
+func (m *FakeDnsConfig) Reset()                    { *m = FakeDnsConfig{} }
+func (m *FakeDnsConfig) String() string            { return proto.CompactTextString(m) }
+func (*FakeDnsConfig) ProtoMessage()               {}
+func (*FakeDnsConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *FakeDnsConfig) GetIpPool() string {
+	if m != nil {
+		return m.IpPool
+	}
+	return ""
+}
+
+func (m *FakeDnsConfig) GetPoolSize() uint32 {
+	if m != nil {
+		return m.PoolSize
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Config)(nil), "v2ray.core.app.dns.Config")
+	proto.RegisterType((*NameServerConfig)(nil), "v2ray.core.app.dns.NameServerConfig")
+	proto.RegisterType((*ClientSubnetConfig)(nil), "v2ray.core.app.dns.ClientSubnetConfig")
+	proto.RegisterType((*CacheConfig)(nil), "v2ray.core.app.dns.CacheConfig")
+	proto.RegisterType((*FakeDnsConfig)(nil), "v2ray.core.app.dns.FakeDnsConfig")
+	proto.RegisterEnum("v2ray.core.app.dns.Config_QueryStrategy", Config_QueryStrategy_name, Config_QueryStrategy_value)
+	proto.RegisterEnum("v2ray.core.app.dns.Config_IPType", Config_IPType_name, Config_IPType_value)
 }
 
 func init() { proto.RegisterFile("v2ray.com/core/app/dns/config.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 286 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x90, 0x41, 0x4b, 0x33, 0x31,
-	0x10, 0x86, 0xc9, 0x96, 0x16, 0xbe, 0xf4, 0xf2, 0x91, 0x83, 0x2c, 0xbd, 0x58, 0x15, 0xb1, 0x20,
-	0x4c, 0x60, 0x15, 0x14, 0x3d, 0xd5, 0xb6, 0xa0, 0x17, 0x2d, 0x2b, 0x78, 0xd0, 0x53, 0xdc, 0x44,
-	0x59, 0x34, 0x33, 0x21, 0x89, 0x0b, 0xfb, 0x97, 0xfc, 0x5f, 0xfe, 0x0f, 0xe9, 0x06, 0xb1, 0xa8,
-	0xbd, 0x85, 0xe4, 0x79, 0xf2, 0xbe, 0x33, 0x7c, 0xaf, 0x29, 0xbc, 0x6a, 0xa1, 0x22, 0x2b, 0x2b,
-	0xf2, 0x46, 0x2a, 0xe7, 0xa4, 0xc6, 0x20, 0x2b, 0xc2, 0xa7, 0xfa, 0x19, 0x9c, 0xa7, 0x48, 0x42,
-	0x7c, 0x41, 0xde, 0x80, 0x72, 0x0e, 0x34, 0x86, 0xd1, 0xc1, 0x0f, 0xb1, 0x22, 0x6b, 0x09, 0x25,
-	0x9a, 0x28, 0x95, 0xd6, 0xde, 0x84, 0x90, 0xe4, 0xd1, 0xe1, 0x66, 0x50, 0x9b, 0x10, 0x6b, 0x54,
-	0xb1, 0x26, 0x4c, 0xf0, 0xee, 0x07, 0xe3, 0x83, 0x59, 0x17, 0x2d, 0xa6, 0x7c, 0x78, 0xad, 0xac,
-	0xb9, 0x35, 0xbe, 0x31, 0x3e, 0xe4, 0x6c, 0xdc, 0x9b, 0x0c, 0x8b, 0x6d, 0x58, 0xab, 0x92, 0x7e,
-	0x02, 0x34, 0x11, 0x16, 0xa8, 0x1d, 0xd5, 0x18, 0xcb, 0x75, 0x47, 0x9c, 0xf3, 0xfe, 0x25, 0x85,
-	0x18, 0xf2, 0xac, 0x93, 0xf7, 0xe1, 0xf7, 0x1c, 0x90, 0xd2, 0xa0, 0xe3, 0x16, 0x18, 0x7d, 0x5b,
-	0x26, 0x67, 0xf4, 0xc0, 0xf9, 0xf7, 0xa5, 0xf8, 0xcf, 0x7b, 0x2f, 0xa6, 0xcd, 0xd9, 0x98, 0x4d,
-	0xfe, 0x95, 0xab, 0xa3, 0x38, 0xe1, 0xfd, 0x46, 0xbd, 0xbe, 0x99, 0x3c, 0x1b, 0xb3, 0xc9, 0xb0,
-	0xd8, 0xd9, 0xd0, 0xec, 0x6a, 0x79, 0xe3, 0xe7, 0x64, 0x55, 0x8d, 0x65, 0xe2, 0xcf, 0xb2, 0x53,
-	0x76, 0x71, 0xcc, 0xb7, 0x2a, 0xb2, 0x7f, 0xf4, 0x59, 0xb2, 0xfb, 0x9e, 0xc6, 0xf0, 0x9e, 0x89,
-	0xbb, 0xa2, 0x54, 0x2d, 0xcc, 0x56, 0x6f, 0x53, 0xe7, 0x60, 0x8e, 0xe1, 0x71, 0xd0, 0x2d, 0xe9,
-	0xe8, 0x33, 0x00, 0x00, 0xff, 0xff, 0x2d, 0xe1, 0x96, 0x19, 0xb5, 0x01, 0x00, 0x00,
+	// 779 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0xdd, 0x6e, 0xe3, 0x44,
+	0x14, 0xc6, 0x49, 0xe3, 0x64, 0x8f, 0x37, 0x55, 0x34, 0x17, 0x8b, 0x55, 0x90, 0x36, 0x0d, 0xb0,
+	0x44, 0x42, 0x72, 0x50, 0xe8, 0x16, 0x58, 0xe0, 0xa2, 0xbb, 0x2d, 0x10, 0x21, 0xb1, 0xc6, 0xa9,
+	0xb8, 0x80, 0x0b, 0x6b, 0xd6, 0x3e, 0x69, 0x87, 0xda, 0x33, 0xd3, 0x99, 0x49, 0x54, 0xef, 0x93,
+	0xf0, 0x0c, 0x48, 0xbc, 0x00, 0x4f, 0x87, 0x66, 0xec, 0x64, 0xd3, 0x36, 0x54, 0x5c, 0x79, 0xe6,
+	0x3b, 0xdf, 0x77, 0xce, 0x99, 0xf3, 0x63, 0xf8, 0x68, 0x35, 0x55, 0xb4, 0x8a, 0x32, 0x51, 0x4e,
+	0x32, 0xa1, 0x70, 0x42, 0xa5, 0x9c, 0xe4, 0x5c, 0x4f, 0x32, 0xc1, 0x17, 0xec, 0x22, 0x92, 0x4a,
+	0x18, 0x41, 0xc8, 0x9a, 0xa4, 0x30, 0xa2, 0x52, 0x46, 0x39, 0xd7, 0x07, 0x9f, 0xde, 0x11, 0x66,
+	0xa2, 0x2c, 0x05, 0x9f, 0x70, 0x34, 0x13, 0x9a, 0xe7, 0x0a, 0xb5, 0xae, 0xc5, 0x07, 0x9f, 0xfd,
+	0x37, 0x31, 0x47, 0x6d, 0x18, 0xa7, 0x86, 0x09, 0xde, 0x90, 0x9f, 0xed, 0x48, 0x47, 0x89, 0xa5,
+	0x41, 0x75, 0x2b, 0xa3, 0xd1, 0x3f, 0x1d, 0xf0, 0x5f, 0x39, 0x80, 0x9c, 0x40, 0xf0, 0x33, 0x2d,
+	0x71, 0x8e, 0x6a, 0x85, 0x4a, 0x87, 0xde, 0xb0, 0x3d, 0x0e, 0xa6, 0x4f, 0xa3, 0xad, 0x94, 0xeb,
+	0x88, 0x11, 0x47, 0x13, 0x9d, 0xf1, 0x5c, 0x0a, 0xc6, 0x4d, 0xb2, 0xad, 0x21, 0xdf, 0x40, 0xe7,
+	0x47, 0xa1, 0x8d, 0x0e, 0x5b, 0x4e, 0xfc, 0x49, 0x74, 0xff, 0xbd, 0x51, 0x1d, 0x2d, 0x72, 0xbc,
+	0x33, 0x6e, 0x54, 0x95, 0xd4, 0x1a, 0xf2, 0x2d, 0xf4, 0x16, 0xf4, 0x0a, 0xd3, 0x9c, 0xeb, 0xb0,
+	0x3d, 0xf4, 0xc6, 0xc1, 0xf4, 0x70, 0x97, 0xfe, 0x7b, 0x7a, 0x85, 0xa7, 0x5c, 0xd7, 0x6e, 0x92,
+	0xee, 0xa2, 0xbe, 0x92, 0xd7, 0xb0, 0x7f, 0xbd, 0x44, 0x55, 0xa5, 0xda, 0x28, 0x6a, 0xf0, 0xa2,
+	0x0a, 0xf7, 0x86, 0xde, 0x78, 0x7f, 0x3a, 0x7e, 0x20, 0x87, 0x5f, 0xac, 0x60, 0xde, 0xf0, 0x93,
+	0xfe, 0xf5, 0xf6, 0x95, 0x1c, 0xc2, 0xe3, 0x0b, 0x45, 0x33, 0x4c, 0x25, 0x2a, 0x26, 0xf2, 0xb0,
+	0x33, 0xf4, 0xc6, 0xfd, 0x24, 0x70, 0x58, 0xec, 0x20, 0xf2, 0x02, 0xba, 0x4c, 0xa6, 0xa6, 0x92,
+	0x18, 0xfa, 0x2e, 0xd8, 0xe1, 0x03, 0xc1, 0x66, 0xf1, 0x79, 0x25, 0x31, 0xf1, 0x99, 0xb4, 0x5f,
+	0xf2, 0x1c, 0x3a, 0x19, 0xcd, 0x2e, 0x31, 0xec, 0x0e, 0xbd, 0xbb, 0x75, 0xde, 0x28, 0x2d, 0xa1,
+	0x79, 0x68, 0xcd, 0x26, 0x67, 0x10, 0x70, 0x5a, 0x62, 0xaa, 0x5d, 0xc5, 0xc3, 0x9e, 0xab, 0xf3,
+	0xc7, 0xbb, 0xc4, 0xef, 0xfa, 0xd2, 0x78, 0x00, 0xbe, 0x41, 0x0e, 0x7e, 0x07, 0x78, 0xd7, 0x00,
+	0x32, 0x80, 0xf6, 0x15, 0x56, 0xa1, 0x37, 0xf4, 0xc6, 0x8f, 0x12, 0x7b, 0x24, 0x5f, 0x42, 0x67,
+	0x45, 0x8b, 0x25, 0x86, 0xad, 0xfb, 0x8d, 0xd8, 0x9a, 0x82, 0x59, 0xfc, 0x5a, 0x9d, 0x8a, 0x92,
+	0x32, 0x9e, 0xd4, 0xfc, 0x17, 0xad, 0xaf, 0xbc, 0xd1, 0x73, 0xe8, 0xdf, 0xaa, 0x2c, 0xd9, 0x07,
+	0x98, 0xe3, 0xf5, 0x12, 0xb9, 0x61, 0xb4, 0x18, 0xbc, 0x47, 0x7a, 0xb0, 0x97, 0xd0, 0x0c, 0x07,
+	0x1e, 0x01, 0xf0, 0x63, 0x85, 0x0b, 0x54, 0x83, 0xd6, 0xe8, 0x73, 0xf0, 0xeb, 0x1a, 0x59, 0xfb,
+	0xc9, 0xd2, 0x88, 0x9a, 0x39, 0x8b, 0x57, 0x47, 0x03, 0xaf, 0x39, 0x1d, 0x0f, 0x5a, 0xf6, 0xf4,
+	0x52, 0x98, 0xcb, 0x41, 0x7b, 0xf4, 0x67, 0x0b, 0x06, 0x77, 0x9f, 0x49, 0xbe, 0x86, 0x6e, 0xb3,
+	0x37, 0xa1, 0x77, 0xbf, 0xb4, 0xbb, 0x46, 0x78, 0xcd, 0x27, 0xdf, 0x41, 0x80, 0x37, 0x12, 0x33,
+	0x83, 0x79, 0xca, 0x64, 0x33, 0xc4, 0x1f, 0xde, 0x2d, 0x6e, 0xbd, 0x46, 0xd1, 0x0f, 0x28, 0x66,
+	0x71, 0x02, 0x6b, 0xc1, 0x4c, 0x5a, 0xb9, 0xc2, 0x3f, 0x36, 0xf2, 0xf6, 0xff, 0x91, 0xaf, 0x05,
+	0x33, 0x49, 0x7e, 0x82, 0x7e, 0x56, 0x30, 0xe4, 0x26, 0xd5, 0xcb, 0x37, 0x1c, 0x8d, 0x1b, 0xe0,
+	0x60, 0xfa, 0x6c, 0xe7, 0x64, 0x38, 0xe2, 0xdc, 0xf1, 0x9a, 0xf6, 0x3e, 0xce, 0xb6, 0xb0, 0x11,
+	0x07, 0x72, 0x9f, 0x43, 0x9e, 0x80, 0xdf, 0xf8, 0xae, 0x7b, 0xdd, 0xdc, 0xc8, 0x53, 0x08, 0x98,
+	0x5c, 0x1d, 0xa5, 0x52, 0xe1, 0x82, 0xdd, 0xb8, 0xa6, 0xf7, 0x13, 0xb0, 0x50, 0xec, 0x90, 0x86,
+	0x70, 0xbc, 0x26, 0xb4, 0x37, 0x84, 0xe3, 0x9a, 0x30, 0xfa, 0xdb, 0x83, 0x60, 0x6b, 0x5c, 0x09,
+	0x81, 0x3d, 0xcd, 0xde, 0xa2, 0x8b, 0xd3, 0x4f, 0xdc, 0x99, 0xbc, 0x0f, 0xdd, 0x92, 0xf1, 0xd4,
+	0x98, 0xa2, 0x89, 0xe0, 0x97, 0x8c, 0x9f, 0x9b, 0xc2, 0x19, 0xe8, 0x8d, 0x33, 0xb4, 0x1b, 0x03,
+	0xbd, 0xb1, 0x86, 0x03, 0xe8, 0xd9, 0x88, 0x68, 0xb2, 0x4b, 0x57, 0x8d, 0x5e, 0xb2, 0xb9, 0xdb,
+	0x94, 0xdc, 0x12, 0xa4, 0xda, 0xd0, 0x02, 0xdd, 0x7a, 0xf6, 0x12, 0x70, 0xd0, 0xdc, 0x22, 0xe4,
+	0x03, 0x78, 0xe4, 0x4c, 0xce, 0xaf, 0xef, 0xfc, 0xf6, 0x1c, 0x70, 0x6e, 0x8a, 0xd1, 0x19, 0xf4,
+	0x6f, 0xfd, 0x48, 0x6c, 0x0e, 0x4c, 0xa6, 0x52, 0x88, 0x62, 0x5d, 0x1b, 0x26, 0x63, 0x21, 0x0a,
+	0xeb, 0xc6, 0xa2, 0xa9, 0x7b, 0x4e, 0x9d, 0x77, 0xcf, 0x02, 0x73, 0xf6, 0x16, 0x5f, 0x1e, 0xc1,
+	0x93, 0x4c, 0x94, 0x3b, 0x3a, 0x14, 0x7b, 0xbf, 0xb5, 0x73, 0xae, 0xff, 0x6a, 0x91, 0x5f, 0xa7,
+	0x09, 0xad, 0xa2, 0x57, 0xd6, 0x76, 0x22, 0x65, 0x74, 0xca, 0xf5, 0x1b, 0xdf, 0xfd, 0x7b, 0xbf,
+	0xf8, 0x77, 0x00, 0xab, 0x47, 0x7c, 0x79, 0x34, 0x06, 0x00, 0x00,
 }
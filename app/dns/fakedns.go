@@ -0,0 +1,140 @@
+package dns
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+
+	"v2ray.com/core/common/cache"
+	"v2ray.com/core/common/net"
+)
+
+const (
+	defaultFakeDNSPoolSize = 65535
+)
+
+// FakeDNSPool hands out addresses from a reserved IP range for domains, and remembers which domain
+// each address stands for. Least recently used domains are forgotten when the pool is full.
+type FakeDNSPool struct {
+	sync.Mutex
+	ipRange    *net.IPNet
+	prefix     *big.Int
+	size       *big.Int
+	next       *big.Int
+	domainToIP *cache.Lru
+	ipToDomain map[string]string
+}
+
+// NewFakeDNSPool creates a new FakeDNSPool from its config.
+func NewFakeDNSPool(config *FakeDnsConfig) (*FakeDNSPool, error) {
+	_, ipRange, err := net.ParseCIDR(config.IpPool)
+	if err != nil {
+		return nil, newError("invalid fake DNS IP pool: ", config.IpPool).Base(err)
+	}
+	ones, bits := ipRange.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	if size.Cmp(big.NewInt(4)) < 0 {
+		return nil, newError("fake DNS IP pool is too small: ", config.IpPool)
+	}
+	// Network and broadcast addresses are not handed out.
+	size.Sub(size, big.NewInt(2))
+
+	capacity := int(config.PoolSize)
+	if capacity == 0 {
+		capacity = defaultFakeDNSPoolSize
+	}
+	if size.IsInt64() && size.Int64() < int64(capacity) {
+		capacity = int(size.Int64())
+	}
+
+	p := &FakeDNSPool{
+		ipRange:    ipRange,
+		prefix:     new(big.Int).SetBytes(normalizeIP(ipRange.IP)),
+		size:       size,
+		next:       big.NewInt(0),
+		domainToIP: cache.NewLru(capacity),
+		ipToDomain: make(map[string]string, capacity),
+	}
+	p.domainToIP.OnEvict(func(key, value interface{}) {
+		delete(p.ipToDomain, value.(string))
+	})
+	return p, nil
+}
+
+func normalizeIP(ip net.IP) net.IP {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4
+	}
+	return ip
+}
+
+func (p *FakeDNSPool) allocate() net.IP {
+	offset := new(big.Int).Add(p.next, big.NewInt(1))
+	p.next.Add(p.next, big.NewInt(1))
+	if p.next.Cmp(p.size) >= 0 {
+		p.next.SetInt64(0)
+	}
+
+	value := new(big.Int).Add(p.prefix, offset).Bytes()
+	ip := make(net.IP, len(normalizeIP(p.ipRange.IP)))
+	copy(ip[len(ip)-len(value):], value)
+	return ip
+}
+
+// GetFakeIPForDomain returns the fake address of the given domain, and assigns a new one if the domain
+// doesn't have one yet.
+func (p *FakeDNSPool) GetFakeIPForDomain(domain string) net.Address {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+
+	p.Lock()
+	defer p.Unlock()
+
+	if ip, found := p.domainToIP.Get(domain); found {
+		return net.ParseAddress(ip.(string))
+	}
+
+	ip := p.allocate()
+	key := ip.String()
+	if previous, found := p.ipToDomain[key]; found {
+		// The address is reused after a full round of the pool.
+		p.domainToIP.Remove(previous)
+	}
+	p.domainToIP.Put(domain, key)
+	p.ipToDomain[key] = domain
+	newError("assigned fake IP ", key, " to domain ", domain).AtDebug().WriteToLog()
+	return net.IPAddress(ip)
+}
+
+// GetDomainFromFakeDNS returns the domain that the given fake address stands for, or empty if the address
+// is unknown.
+func (p *FakeDNSPool) GetDomainFromFakeDNS(addr net.Address) string {
+	if !addr.Family().IsIP() || !p.IsIPInIPPool(addr) {
+		return ""
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	domain, found := p.ipToDomain[addr.IP().String()]
+	if !found {
+		return ""
+	}
+	p.domainToIP.Get(domain)
+	return domain
+}
+
+// IPFamily returns the family of the addresses in this pool.
+func (p *FakeDNSPool) IPFamily() net.AddressFamily {
+	if len(normalizeIP(p.ipRange.IP)) == net.IPv4len {
+		return net.AddressFamilyIPv4
+	}
+	return net.AddressFamilyIPv6
+}
+
+// IsIPInIPPool returns true if the given address is in the IP range of this pool.
+func (p *FakeDNSPool) IsIPInIPPool(addr net.Address) bool {
+	if !addr.Family().IsIP() {
+		return false
+	}
+	return p.ipRange.Contains(addr.IP())
+}
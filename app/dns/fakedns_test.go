@@ -0,0 +1,78 @@
+package dns_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/app/dns"
+	"v2ray.com/core/common/net"
+	. "v2ray.com/ext/assert"
+)
+
+func TestFakeDNSPool(t *testing.T) {
+	assert := With(t)
+
+	pool, err := NewFakeDNSPool(&FakeDnsConfig{
+		IpPool: "198.18.0.0/15",
+	})
+	assert(err, IsNil)
+
+	ip := pool.GetFakeIPForDomain("www.v2ray.com")
+	assert(ip.Family().IsIPv4(), IsTrue)
+	assert(pool.IsIPInIPPool(ip), IsTrue)
+	assert(ip.String(), Equals, "198.18.0.1")
+
+	assert(pool.GetFakeIPForDomain("www.v2ray.com."), Equals, ip)
+	assert(pool.GetDomainFromFakeDNS(ip), Equals, "www.v2ray.com")
+
+	ip2 := pool.GetFakeIPForDomain("www.google.com")
+	assert(ip2, NotEquals, ip)
+	assert(pool.GetDomainFromFakeDNS(ip2), Equals, "www.google.com")
+
+	assert(pool.GetDomainFromFakeDNS(net.ParseAddress("8.8.8.8")), Equals, "")
+	assert(pool.GetDomainFromFakeDNS(net.ParseAddress("198.18.10.10")), Equals, "")
+}
+
+func TestFakeDNSPoolEviction(t *testing.T) {
+	assert := With(t)
+
+	pool, err := NewFakeDNSPool(&FakeDnsConfig{
+		IpPool:   "fc00::/64",
+		PoolSize: 2,
+	})
+	assert(err, IsNil)
+
+	ip1 := pool.GetFakeIPForDomain("a.com")
+	assert(ip1.Family().IsIPv6(), IsTrue)
+	pool.GetFakeIPForDomain("b.com")
+	pool.GetFakeIPForDomain("c.com")
+
+	assert(pool.GetDomainFromFakeDNS(ip1), Equals, "")
+}
+
+func TestFakeDNSPoolWrapAround(t *testing.T) {
+	assert := With(t)
+
+	pool, err := NewFakeDNSPool(&FakeDnsConfig{
+		IpPool: "10.0.0.0/30",
+	})
+	assert(err, IsNil)
+
+	ip1 := pool.GetFakeIPForDomain("a.com")
+	ip2 := pool.GetFakeIPForDomain("b.com")
+	assert(ip1.String(), Equals, "10.0.0.1")
+	assert(ip2.String(), Equals, "10.0.0.2")
+
+	ip3 := pool.GetFakeIPForDomain("c.com")
+	assert(ip3, Equals, ip1)
+	assert(pool.GetDomainFromFakeDNS(ip3), Equals, "c.com")
+	assert(pool.GetDomainFromFakeDNS(ip2), Equals, "b.com")
+}
+
+func TestFakeDNSInvalidPool(t *testing.T) {
+	assert := With(t)
+
+	_, err := NewFakeDNSPool(&FakeDnsConfig{
+		IpPool: "10.0.0.1/32",
+	})
+	assert(err, IsNotNil)
+}
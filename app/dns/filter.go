@@ -0,0 +1,95 @@
+package dns
+
+import (
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common/net"
+)
+
+// ipRange matches IPs against a list of CIDRs.
+type ipRange struct {
+	ipv4 *net.IPNetTable
+	ipv6 []*net.IPNet
+}
+
+func newIPRange(geoips []*router.GeoIP) (*ipRange, error) {
+	r := &ipRange{
+		ipv4: net.NewIPNetTable(),
+	}
+	for _, geoip := range geoips {
+		for _, cidr := range geoip.Cidr {
+			switch len(cidr.Ip) {
+			case net.IPv4len:
+				r.ipv4.AddIP(cidr.Ip, byte(cidr.Prefix))
+			case net.IPv6len:
+				r.ipv6 = append(r.ipv6, &net.IPNet{
+					IP:   net.IP(cidr.Ip),
+					Mask: net.CIDRMask(int(cidr.Prefix), net.IPv6len*8),
+				})
+			default:
+				return nil, newError("invalid IP length in ", geoip.CountryCode)
+			}
+		}
+	}
+	return r, nil
+}
+
+func (r *ipRange) IsEmpty() bool {
+	return r.ipv4.IsEmpty() && len(r.ipv6) == 0
+}
+
+func (r *ipRange) Contains(ip net.IP) bool {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return r.ipv4.Contains(ipv4)
+	}
+	for _, n := range r.ipv6 {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPFilter validates IPs in DNS answers against expected and rejected IP ranges.
+type IPFilter struct {
+	expected *ipRange
+	rejected *ipRange
+}
+
+// NewIPFilter creates a new IPFilter. It returns nil if both expected and rejected are empty.
+func NewIPFilter(expected []*router.GeoIP, rejected []*router.GeoIP) (*IPFilter, error) {
+	f := new(IPFilter)
+	if len(expected) > 0 {
+		r, err := newIPRange(expected)
+		if err != nil {
+			return nil, err
+		}
+		f.expected = r
+	}
+	if len(rejected) > 0 {
+		r, err := newIPRange(rejected)
+		if err != nil {
+			return nil, err
+		}
+		f.rejected = r
+	}
+	if f.expected == nil && f.rejected == nil {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// Accept returns true if all the given IPs are allowed by this filter.
+func (f *IPFilter) Accept(ips []net.IP) bool {
+	if f == nil {
+		return true
+	}
+	for _, ip := range ips {
+		if f.expected != nil && !f.expected.IsEmpty() && !f.expected.Contains(ip) {
+			return false
+		}
+		if f.rejected != nil && f.rejected.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
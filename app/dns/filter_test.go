@@ -0,0 +1,47 @@
+package dns_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common/net"
+	. "v2ray.com/ext/assert"
+)
+
+func TestIPFilter(t *testing.T) {
+	assert := With(t)
+
+	filter, err := NewIPFilter([]*router.GeoIP{
+		{
+			CountryCode: "TEST",
+			Cidr: []*router.CIDR{
+				{Ip: []byte{8, 8, 0, 0}, Prefix: 16},
+				{Ip: net.ParseIP("2001:4860::"), Prefix: 32},
+			},
+		},
+	}, []*router.GeoIP{
+		{
+			CountryCode: "PRIVATE",
+			Cidr: []*router.CIDR{
+				{Ip: []byte{8, 8, 4, 0}, Prefix: 24},
+			},
+		},
+	})
+	assert(err, IsNil)
+
+	assert(filter.Accept([]net.IP{net.ParseIP("8.8.8.8")}), IsTrue)
+	assert(filter.Accept([]net.IP{net.ParseIP("2001:4860:4860::8888")}), IsTrue)
+	assert(filter.Accept([]net.IP{net.ParseIP("8.8.8.8"), net.ParseIP("1.2.3.4")}), IsFalse)
+	assert(filter.Accept([]net.IP{net.ParseIP("8.8.4.4")}), IsFalse)
+	assert(filter.Accept(nil), IsTrue)
+}
+
+func TestEmptyIPFilter(t *testing.T) {
+	assert := With(t)
+
+	filter, err := NewIPFilter(nil, nil)
+	assert(err, IsNil)
+	assert(filter, IsNil)
+	assert(filter.Accept([]net.IP{net.ParseIP("1.2.3.4")}), IsTrue)
+}
@@ -32,12 +32,59 @@ type ARecord struct {
 }
 
 type NameServer interface {
-	QueryA(domain string) <-chan *ARecord
+	// QueryIP queries IPs of the given domain. clientIP is the IP of the client who requests the domain, and may be nil.
+	QueryIP(domain string, ipType Config_IPType, clientIP net.IP) <-chan *ARecord
+}
+
+// mergeRecords merges the records of queries for different types. It returns when all queries finish, or a grace
+// period after the first answer, so that a missing answer of one type doesn't hold back the answers of other types.
+func mergeRecords(responses []<-chan *ARecord, grace time.Duration) <-chan *ARecord {
+	merged := make(chan *ARecord, 1)
+	go func() {
+		defer close(merged)
+
+		answers := make(chan *ARecord, len(responses))
+		for _, response := range responses {
+			go func(response <-chan *ARecord) {
+				answers <- <-response
+			}(response)
+		}
+
+		var record *ARecord
+		var timeout <-chan time.Time
+	L:
+		for remaining := len(responses); remaining > 0; remaining-- {
+			select {
+			case a := <-answers:
+				if a == nil {
+					continue
+				}
+				if record == nil {
+					record = &ARecord{
+						IPs:    make([]net.IP, 0, len(a.IPs)),
+						Expire: a.Expire,
+					}
+					timeout = time.After(grace)
+				}
+				record.IPs = append(record.IPs, a.IPs...)
+				if a.Expire.Before(record.Expire) {
+					record.Expire = a.Expire
+				}
+			case <-timeout:
+				break L
+			}
+		}
+		if record != nil {
+			merged <- record
+		}
+	}()
+	return merged
 }
 
 type PendingRequest struct {
 	expire   time.Time
 	response chan<- *ARecord
+	rejected bool
 }
 
 type UDPNameServer struct {
@@ -46,13 +93,21 @@ type UDPNameServer struct {
 	requests    map[uint16]*PendingRequest
 	udpServer   *udp.Dispatcher
 	nextCleanup time.Time
+	filter      *IPFilter
+	subnet      *clientSubnet
+	// gracePeriod is the time to wait for the answers of other types after the first one.
+	gracePeriod time.Duration
 }
 
-func NewUDPNameServer(address net.Destination, dispatcher dispatcher.Interface) *UDPNameServer {
+// NewUDPNameServer creates a new UDPNameServer. Answers that are not accepted by the filter are discarded.
+// The filter may be nil.
+func NewUDPNameServer(address net.Destination, dispatcher dispatcher.Interface, filter *IPFilter) *UDPNameServer {
 	s := &UDPNameServer{
-		address:   address,
-		requests:  make(map[uint16]*PendingRequest),
-		udpServer: udp.NewDispatcher(dispatcher),
+		address:     address,
+		requests:    make(map[uint16]*PendingRequest),
+		udpServer:   udp.NewDispatcher(dispatcher),
+		filter:      filter,
+		gracePeriod: DefaultGracePeriod,
 	}
 	return s
 }
@@ -115,12 +170,10 @@ func (s *UDPNameServer) HandleResponse(payload *buf.Buffer) {
 
 	s.Lock()
 	request, found := s.requests[id]
+	s.Unlock()
 	if !found {
-		s.Unlock()
 		return
 	}
-	delete(s.requests, id)
-	s.Unlock()
 
 	for _, rr := range msg.Answer {
 		switch rr := rr.(type) {
@@ -138,27 +191,72 @@ func (s *UDPNameServer) HandleResponse(payload *buf.Buffer) {
 	}
 	record.Expire = time.Now().Add(time.Second * time.Duration(ttl))
 
+	if !s.filter.Accept(record.IPs) {
+		newError("discarding unexpected answer from ", s.address, " for id ", id, ": ", record.IPs).AtWarning().WriteToLog()
+		s.Lock()
+		request.rejected = true
+		s.Unlock()
+		return
+	}
+
+	s.Lock()
+	if s.requests[id] != request {
+		s.Unlock()
+		return
+	}
+	delete(s.requests, id)
+	s.Unlock()
+
 	request.response <- record
 	close(request.response)
 }
 
-func (s *UDPNameServer) buildAMsg(domain string, id uint16) *dns.Msg {
+// failRejected closes the pending request of the given id, if only discarded answers are received for it.
+func (s *UDPNameServer) failRejected(id uint16) {
+	s.Lock()
+	defer s.Unlock()
+
+	if request, found := s.requests[id]; found && request.rejected {
+		delete(s.requests, id)
+		close(request.response)
+	}
+}
+
+// questionTypes returns the types of questions to send for the given IPType. Each group of types is sent in one message.
+func (s *UDPNameServer) questionTypes(ipType Config_IPType) [][]uint16 {
+	multiQuestion := multiQuestionDNS[s.address.Address]
+	switch ipType {
+	case Config_IPv4:
+		return [][]uint16{{dns.TypeA}}
+	case Config_IPv6:
+		return [][]uint16{{dns.TypeAAAA}}
+	case Config_Both:
+		if multiQuestion {
+			return [][]uint16{{dns.TypeA, dns.TypeAAAA}}
+		}
+		return [][]uint16{{dns.TypeA}, {dns.TypeAAAA}}
+	default:
+		if multiQuestion {
+			return [][]uint16{{dns.TypeA, dns.TypeAAAA}}
+		}
+		return [][]uint16{{dns.TypeA}}
+	}
+}
+
+func (s *UDPNameServer) buildMsg(domain string, id uint16, qtypes []uint16, clientIP net.IP) *dns.Msg {
 	msg := new(dns.Msg)
 	msg.Id = id
 	msg.RecursionDesired = true
-	msg.Question = []dns.Question{
-		{
-			Name:   dns.Fqdn(domain),
-			Qtype:  dns.TypeA,
-			Qclass: dns.ClassINET,
-		}}
-	if multiQuestionDNS[s.address.Address] {
+	for _, qtype := range qtypes {
 		msg.Question = append(msg.Question, dns.Question{
 			Name:   dns.Fqdn(domain),
-			Qtype:  dns.TypeAAAA,
+			Qtype:  qtype,
 			Qclass: dns.ClassINET,
 		})
 	}
+	if subnet := s.subnet.Subnet(clientIP); subnet != nil {
+		setClientSubnet(msg, subnet)
+	}
 
 	return msg
 }
@@ -174,14 +272,27 @@ func msgToBuffer(msg *dns.Msg) (*buf.Buffer, error) {
 	return buffer, nil
 }
 
-func (s *UDPNameServer) QueryA(domain string) <-chan *ARecord {
+func (s *UDPNameServer) QueryIP(domain string, ipType Config_IPType, clientIP net.IP) <-chan *ARecord {
+	qtypes := s.questionTypes(ipType)
+	if len(qtypes) == 1 {
+		return s.query(domain, qtypes[0], clientIP)
+	}
+
+	responses := make([]<-chan *ARecord, 0, len(qtypes))
+	for _, t := range qtypes {
+		responses = append(responses, s.query(domain, t, clientIP))
+	}
+	return mergeRecords(responses, s.gracePeriod)
+}
+
+func (s *UDPNameServer) query(domain string, qtypes []uint16, clientIP net.IP) <-chan *ARecord {
 	response := make(chan *ARecord, 1)
 	id := s.AssignUnusedID(response)
 
-	msg := s.buildAMsg(domain, id)
+	msg := s.buildMsg(domain, id, qtypes, clientIP)
 	b, err := msgToBuffer(msg)
 	if err != nil {
-		newError("failed to build A query for domain ", domain).Base(err).WriteToLog()
+		newError("failed to build query for domain ", domain).Base(err).WriteToLog()
 		close(response)
 		return response
 	}
@@ -190,27 +301,35 @@ func (s *UDPNameServer) QueryA(domain string) <-chan *ARecord {
 	s.udpServer.Dispatch(ctx, s.address, b, s.HandleResponse)
 
 	go func() {
+		defer cancel()
+
 		for i := 0; i < 2; i++ {
 			time.Sleep(time.Second)
 			s.Lock()
 			_, found := s.requests[id]
 			s.Unlock()
 			if !found {
-				break
+				return
 			}
 			b, _ := msgToBuffer(msg)
 			s.udpServer.Dispatch(ctx, s.address, b, s.HandleResponse)
 		}
-		cancel()
+
+		if s.filter != nil {
+			// Give up early if only forged answers are received, so that the next nameserver can be queried.
+			time.Sleep(time.Second)
+			s.failRejected(id)
+		}
 	}()
 
 	return response
 }
 
 type LocalNameServer struct {
+	filter *IPFilter
 }
 
-func (*LocalNameServer) QueryA(domain string) <-chan *ARecord {
+func (s *LocalNameServer) QueryIP(domain string, ipType Config_IPType, clientIP net.IP) <-chan *ARecord {
 	response := make(chan *ARecord, 1)
 
 	go func() {
@@ -222,6 +341,11 @@ func (*LocalNameServer) QueryA(domain string) <-chan *ARecord {
 			newError("failed to lookup IPs for domain ", domain).Base(err).AtWarning().WriteToLog()
 			return
 		}
+		ips = filterIPs(ips, ipType)
+		if !s.filter.Accept(ips) {
+			newError("discarding unexpected answer for domain ", domain, ": ", ips).AtWarning().WriteToLog()
+			return
+		}
 
 		response <- &ARecord{
 			IPs:    ips,
@@ -231,3 +355,17 @@ func (*LocalNameServer) QueryA(domain string) <-chan *ARecord {
 
 	return response
 }
+
+func filterIPs(ips []net.IP, ipType Config_IPType) []net.IP {
+	if ipType != Config_IPv4 && ipType != Config_IPv6 {
+		return ips
+	}
+	filtered := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		isIPv4 := ip.To4() != nil
+		if isIPv4 == (ipType == Config_IPv4) {
+			filtered = append(filtered, ip)
+		}
+	}
+	return filtered
+}
@@ -4,7 +4,6 @@ package dns
 
 import (
 	"context"
-	"sync"
 	"time"
 
 	dnsmsg "github.com/miekg/dns"
@@ -12,32 +11,30 @@ import (
 	"v2ray.com/core/app/dispatcher"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/proxy"
 )
 
 const (
-	QueryTimeout = time.Second * 8
-)
-
-type DomainRecord struct {
-	IP         []net.IP
-	Expire     time.Time
-	LastAccess time.Time
-}
+	QueryTimeout       = time.Second * 8
+	DefaultGracePeriod = time.Millisecond * 100
 
-func (r *DomainRecord) Expired() bool {
-	return r.Expire.Before(time.Now())
-}
-
-func (r *DomainRecord) Inactive() bool {
-	now := time.Now()
-	return r.Expire.Before(now) || r.LastAccess.Add(time.Minute*5).Before(now)
-}
+	// cacheStatsInterval is the interval to write the cache statistics to the log.
+	cacheStatsInterval = time.Minute * 10
+)
 
 type Server struct {
-	sync.Mutex
-	hosts   map[string]net.IP
-	records map[string]*DomainRecord
-	servers []NameServer
+	hosts       map[string]net.IP
+	cache       *recordCache
+	servers     []NameServer
+	fakeDNS     *FakeDNSPool
+	strategy    Config_QueryStrategy
+	gracePeriod time.Duration
+	ipType      Config_IPType
+	// derivedSubnets are the client subnets that depend on the IP of clients. Records are cached per subnet
+	// if there is any.
+	derivedSubnets []*clientSubnet
+	// done is closed when the server closes.
+	done chan struct{}
 }
 
 func New(ctx context.Context, config *Config) (*Server, error) {
@@ -46,30 +43,50 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 		return nil, newError("no space in context")
 	}
 	server := &Server{
-		records: make(map[string]*DomainRecord),
-		servers: make([]NameServer, len(config.NameServers)),
-		hosts:   config.GetInternalHosts(),
+		cache:       newRecordCache(config.GetCache()),
+		servers:     make([]NameServer, 0, len(config.NameServers)+len(config.NameServer)),
+		hosts:       config.GetInternalHosts(),
+		strategy:    config.QueryStrategy,
+		gracePeriod: DefaultGracePeriod,
+		ipType:      config.IpType,
+	}
+	if config.GracePeriod > 0 {
+		server.gracePeriod = time.Millisecond * time.Duration(config.GracePeriod)
+	}
+	if config.FakeDns != nil {
+		pool, err := NewFakeDNSPool(config.FakeDns)
+		if err != nil {
+			return nil, err
+		}
+		server.fakeDNS = pool
 	}
 	space.On(app.SpaceInitializing, func(interface{}) error {
 		disp := dispatcher.FromSpace(space)
 		if disp == nil {
 			return newError("dispatcher is not found in the space")
 		}
-		for idx, destPB := range config.NameServers {
-			address := destPB.Address.AsAddress()
-			if address.Family().IsDomain() && address.Domain() == "localhost" {
-				server.servers[idx] = &LocalNameServer{}
-			} else {
-				dest := destPB.AsDestination()
-				if dest.Network == net.Network_Unknown {
-					dest.Network = net.Network_UDP
-				}
-				if dest.Network == net.Network_UDP {
-					server.servers[idx] = NewUDPNameServer(dest, disp)
+		for _, destPB := range config.NameServers {
+			if ns := newNameServer(destPB, disp, nil, nil, server.gracePeriod); ns != nil {
+				server.servers = append(server.servers, ns)
+			}
+		}
+		for _, nsConfig := range config.NameServer {
+			filter, err := NewIPFilter(nsConfig.ExpectedIp, nsConfig.RejectedIp)
+			if err != nil {
+				return newError("failed to create IP filter for nameserver ", nsConfig.Address.AsDestination()).Base(err)
+			}
+			subnet, err := newClientSubnet(nsConfig.ClientSubnet)
+			if err != nil {
+				return newError("failed to create client subnet for nameserver ", nsConfig.Address.AsDestination()).Base(err)
+			}
+			if ns := newNameServer(nsConfig.Address, disp, filter, subnet, server.gracePeriod); ns != nil {
+				server.servers = append(server.servers, ns)
+				if subnet.IsDerived() {
+					server.derivedSubnets = append(server.derivedSubnets, subnet)
 				}
 			}
 		}
-		if len(config.NameServers) == 0 {
+		if len(server.servers) == 0 {
 			server.servers = append(server.servers, &LocalNameServer{})
 		}
 		return nil
@@ -77,81 +94,256 @@ func New(ctx context.Context, config *Config) (*Server, error) {
 	return server, nil
 }
 
+func newNameServer(endpoint *net.Endpoint, disp dispatcher.Interface, filter *IPFilter, subnet *clientSubnet, grace time.Duration) NameServer {
+	address := endpoint.Address.AsAddress()
+	if address.Family().IsDomain() && address.Domain() == "localhost" {
+		return &LocalNameServer{
+			filter: filter,
+		}
+	}
+	dest := endpoint.AsDestination()
+	if dest.Network == net.Network_Unknown {
+		dest.Network = net.Network_UDP
+	}
+	if dest.Network != net.Network_UDP {
+		newError("ignoring unsupported nameserver ", dest).AtWarning().WriteToLog()
+		return nil
+	}
+	ns := NewUDPNameServer(dest, disp, filter)
+	ns.subnet = subnet
+	ns.gracePeriod = grace
+	return ns
+}
+
 func (*Server) Interface() interface{} {
 	return (*Server)(nil)
 }
 
 func (s *Server) Start() error {
 	net.RegisterIPResolver(s)
+	s.done = make(chan struct{})
+	go s.logCacheStats(s.done)
 	return nil
 }
 
-func (*Server) Close() {
+func (s *Server) Close() {
 	net.RegisterIPResolver(net.SystemIPResolver())
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
 }
 
-func (s *Server) GetCached(domain string) []net.IP {
-	s.Lock()
-	defer s.Unlock()
+// logCacheStats writes the cache hits and misses to the log periodically, when there are new lookups, until done is
+// closed.
+func (s *Server) logCacheStats(done <-chan struct{}) {
+	ticker := time.NewTicker(cacheStatsInterval)
+	defer ticker.Stop()
 
-	if record, found := s.records[domain]; found && !record.Expired() {
-		record.LastAccess = time.Now()
-		return record.IP
+	var lastHits, lastMisses uint64
+	for {
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+		hits, misses := s.CacheStats()
+		if hits == lastHits && misses == lastMisses {
+			continue
+		}
+		newError("cache statistics: ", hits, " hits, ", misses, " misses").AtInfo().WriteToLog()
+		lastHits, lastMisses = hits, misses
 	}
-	return nil
 }
 
-func (s *Server) tryCleanup() {
-	s.Lock()
-	defer s.Unlock()
+// FakeDNS returns the fake DNS pool of this server, or nil if fake DNS is not enabled.
+func (s *Server) FakeDNS() *FakeDNSPool {
+	return s.fakeDNS
+}
 
-	if len(s.records) > 256 {
-		domains := make([]string, 0, 256)
-		for d, r := range s.records {
-			if r.Expired() {
-				domains = append(domains, d)
-			}
-		}
-		for _, d := range domains {
-			delete(s.records, d)
+func (s *Server) GetCached(domain string) []net.IP {
+	return s.getCached(domain, domain, nil)
+}
+
+func (s *Server) getCached(key string, domain string, clientIP net.IP) []net.IP {
+	ips, refresh := s.cache.Get(key)
+	if refresh {
+		go s.refresh(key, domain, clientIP)
+	}
+	return ips
+}
+
+// cacheKey returns the key of the given domain in cache. Answers for different client subnets are cached separately.
+func (s *Server) cacheKey(domain string, clientIP net.IP) string {
+	key := domain
+	for _, subnet := range s.derivedSubnets {
+		if n := subnet.Subnet(clientIP); n != nil {
+			key += "|" + n.String()
 		}
 	}
+	return key
+}
+
+// CacheStats returns the number of cache hits and misses of this server.
+func (s *Server) CacheStats() (hits uint64, misses uint64) {
+	return s.cache.Stats()
+}
+
+func (s *Server) refresh(key string, domain string, clientIP net.IP) {
+	newError("prefetching domain ", domain).AtDebug().WriteToLog()
+	record := s.query(domain, clientIP)
+	if record == nil {
+		s.cache.EndRefresh(key)
+		return
+	}
+	s.cache.Put(key, record)
 }
 
 func (s *Server) LookupIP(domain string) ([]net.IP, error) {
+	return s.LookupIPWithContext(context.Background(), domain)
+}
+
+// LookupIPWithContext implements net.ContextIPResolver. The source IP in the context is used for EDNS Client Subnet.
+func (s *Server) LookupIPWithContext(ctx context.Context, domain string) ([]net.IP, error) {
 	if ip, found := s.hosts[domain]; found {
 		return []net.IP{ip}, nil
 	}
 
+	var clientIP net.IP
+	if source, ok := proxy.SourceFromContext(ctx); ok && source.Address.Family().IsIP() {
+		clientIP = source.Address.IP()
+	}
+
 	domain = dnsmsg.Fqdn(domain)
-	ips := s.GetCached(domain)
+	key := s.cacheKey(domain, clientIP)
+	ips := s.getCached(key, domain, clientIP)
 	if ips != nil {
 		return ips, nil
 	}
 
-	s.tryCleanup()
+	record := s.query(domain, clientIP)
+	if record == nil {
+		if ips := s.cache.GetStale(key); ips != nil {
+			newError("returning ", len(ips), " stale IPs for domain ", domain).AtDebug().WriteToLog()
+			return ips, nil
+		}
+		return nil, newError("returning nil for domain ", domain)
+	}
+
+	s.cache.Put(key, record)
+	newError("returning ", len(record.IPs), " IPs for domain ", domain).AtDebug().WriteToLog()
+	return record.IPs, nil
+}
+
+func (s *Server) query(domain string, clientIP net.IP) *ARecord {
+	switch s.strategy {
+	case Config_Race:
+		return s.queryParallel(domain, clientIP, false)
+	case Config_Prefer:
+		return s.queryParallel(domain, clientIP, true)
+	default:
+		return s.querySequential(domain, clientIP)
+	}
+}
 
+func (s *Server) querySequential(domain string, clientIP net.IP) *ARecord {
 	for _, server := range s.servers {
-		response := server.QueryA(domain)
+		response := server.QueryIP(domain, s.ipType, clientIP)
 		select {
 		case a, open := <-response:
 			if !open || a == nil {
 				continue
 			}
-			s.Lock()
-			s.records[domain] = &DomainRecord{
-				IP:         a.IPs,
-				Expire:     a.Expire,
-				LastAccess: time.Now(),
-			}
-			s.Unlock()
-			newError("returning ", len(a.IPs), " IPs for domain ", domain).AtDebug().WriteToLog()
-			return a.IPs, nil
+			return a
 		case <-time.After(QueryTimeout):
 		}
 	}
+	return nil
+}
+
+type indexedRecord struct {
+	index  int
+	record *ARecord
+}
+
+// queryParallel queries all name servers at the same time. If preferOrder is false, the first answer with IPs
+// is returned. Otherwise the answer from the first name server in order is returned, as long as it arrives before
+// the grace period after any answer from other name servers.
+func (s *Server) queryParallel(domain string, clientIP net.IP, preferOrder bool) *ARecord {
+	results := make(chan indexedRecord, len(s.servers))
+	for idx, server := range s.servers {
+		go func(idx int, server NameServer) {
+			var record *ARecord
+			select {
+			case a := <-server.QueryIP(domain, s.ipType, clientIP):
+				record = a
+			case <-time.After(QueryTimeout):
+			}
+			results <- indexedRecord{index: idx, record: record}
+		}(idx, server)
+	}
+
+	records := make([]*ARecord, len(s.servers))
+	finished := make([]bool, len(s.servers))
+	var emptyRecord *ARecord
+	var grace <-chan time.Time
+
+	firstRecord := func(stopAtPending bool) *ARecord {
+		for idx, record := range records {
+			if record != nil {
+				return record
+			}
+			if stopAtPending && !finished[idx] {
+				return nil
+			}
+		}
+		return nil
+	}
 
-	return nil, newError("returning nil for domain ", domain)
+	for remaining := len(s.servers); remaining > 0; {
+		select {
+		case r := <-results:
+			remaining--
+			finished[r.index] = true
+			if r.record == nil {
+				break
+			}
+			if len(r.record.IPs) == 0 {
+				if emptyRecord == nil {
+					emptyRecord = r.record
+				}
+				break
+			}
+			if !preferOrder {
+				return r.record
+			}
+			records[r.index] = r.record
+			if grace == nil {
+				grace = time.After(s.gracePeriod)
+			}
+		case <-grace:
+			return firstRecord(false)
+		}
+		if preferOrder {
+			if record := firstRecord(true); record != nil {
+				return record
+			}
+		}
+	}
+
+	if record := firstRecord(false); record != nil {
+		return record
+	}
+	return emptyRecord
+}
+
+// FromSpace returns the DNS server in the given space, or nil if there is none.
+func FromSpace(space app.Space) *Server {
+	app := space.GetApplication((*Server)(nil))
+	if app == nil {
+		return nil
+	}
+	return app.(*Server)
 }
 
 func init() {
@@ -3,6 +3,7 @@ package dns_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"v2ray.com/core/app"
 	"v2ray.com/core/app/dispatcher"
@@ -12,9 +13,11 @@ import (
 	_ "v2ray.com/core/app/policy/manager"
 	"v2ray.com/core/app/proxyman"
 	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/app/router"
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy"
 	"v2ray.com/core/proxy/freedom"
 	"v2ray.com/core/testing/servers/udp"
 	. "v2ray.com/ext/assert"
@@ -35,6 +38,65 @@ func (*staticHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		} else if q.Name == "facebook.com." && q.Qtype == dns.TypeA {
 			rr, _ := dns.NewRR("facebook.com. IN A 9.9.9.9")
 			ans.Answer = append(ans.Answer, rr)
+		} else if q.Name == "ipv6.google.com." && q.Qtype == dns.TypeAAAA {
+			rr, _ := dns.NewRR("ipv6.google.com. IN AAAA 2001:4860:4860::8888")
+			ans.Answer = append(ans.Answer, rr)
+		}
+	}
+	w.WriteMsg(ans)
+}
+
+type poisonedHandler struct {
+}
+
+func (*poisonedHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.Id = r.Id
+	for _, q := range r.Question {
+		if q.Qtype == dns.TypeA {
+			rr, _ := dns.NewRR(q.Name + " IN A 1.2.3.4")
+			ans.Answer = append(ans.Answer, rr)
+		}
+	}
+	w.WriteMsg(ans)
+}
+
+// ipv4OnlyHandler answers A queries with 8.8.8.8, and never answers AAAA queries.
+type ipv4OnlyHandler struct {
+}
+
+func (*ipv4OnlyHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.Id = r.Id
+	for _, q := range r.Question {
+		if q.Qtype != dns.TypeA {
+			return
+		}
+		rr, _ := dns.NewRR(q.Name + " IN A 8.8.8.8")
+		ans.Answer = append(ans.Answer, rr)
+	}
+	w.WriteMsg(ans)
+}
+
+// subnetHandler answers A queries with the address of the client subnet in the query, or 127.0.0.1 if there is none.
+type subnetHandler struct {
+}
+
+func (*subnetHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	ans := new(dns.Msg)
+	ans.Id = r.Id
+	ip := "127.0.0.1"
+	if opt := r.IsEdns0(); opt != nil {
+		for _, option := range opt.Option {
+			if subnet, ok := option.(*dns.EDNS0_SUBNET); ok {
+				ip = subnet.Address.String()
+			}
+		}
+	}
+	for _, q := range r.Question {
+		if q.Qtype == dns.TypeA {
+			rr, _ := dns.NewRR(q.Name + " IN A " + ip)
+			ans.Answer = append(ans.Answer, rr)
 		}
 	}
 	w.WriteMsg(ans)
@@ -102,3 +164,333 @@ func TestUDPServer(t *testing.T) {
 	assert(len(ips), Equals, 1)
 	assert([]byte(ips[0]), Equals, []byte{8, 8, 8, 8})
 }
+
+func localNameServer(port net.Port) *net.Endpoint {
+	return &net.Endpoint{
+		Network: net.Network_UDP,
+		Address: &net.IPOrDomain{
+			Address: &net.IPOrDomain_Ip{
+				Ip: []byte{127, 0, 0, 1},
+			},
+		},
+		Port: uint32(port),
+	}
+}
+
+func startDNSApp(config *Config) *Server {
+	ctx := context.Background()
+	space := app.NewSpace()
+
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, config))
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+
+	return FromSpace(space)
+}
+
+func TestUDPServerRace(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	startDNSApp(&Config{
+		NameServers: []*net.Endpoint{
+			localNameServer(udp.PickPort()),
+			localNameServer(port),
+		},
+		QueryStrategy: Config_Race,
+	})
+
+	start := time.Now()
+	ips, err := net.LookupIP("google.com")
+	assert(err, IsNil)
+	assert(len(ips), Equals, 1)
+	assert([]byte(ips[0]), Equals, []byte{8, 8, 8, 8})
+	assert(time.Since(start) < QueryTimeout, IsTrue)
+}
+
+func TestUDPServerPrefer(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	startDNSApp(&Config{
+		NameServers: []*net.Endpoint{
+			localNameServer(udp.PickPort()),
+			localNameServer(port),
+		},
+		QueryStrategy: Config_Prefer,
+		GracePeriod:   50,
+	})
+
+	start := time.Now()
+	ips, err := net.LookupIP("facebook.com")
+	assert(err, IsNil)
+	assert(len(ips), Equals, 1)
+	assert([]byte(ips[0]), Equals, []byte{9, 9, 9, 9})
+	assert(time.Since(start) < QueryTimeout, IsTrue)
+}
+
+func TestUDPServerIPv6(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	startDNSApp(&Config{
+		NameServers: []*net.Endpoint{
+			localNameServer(port),
+		},
+		IpType: Config_IPv6,
+	})
+
+	ips, err := net.LookupIP("ipv6.google.com")
+	assert(err, IsNil)
+	assert(len(ips), Equals, 1)
+	assert(ips[0].String(), Equals, "2001:4860:4860::8888")
+}
+
+func TestUDPServerBothMissingIPv6(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &ipv4OnlyHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	startDNSApp(&Config{
+		NameServers: []*net.Endpoint{
+			localNameServer(port),
+		},
+		IpType:      Config_Both,
+		GracePeriod: 1500,
+	})
+
+	// The A answer is returned the configured grace period after it arrives, instead of waiting for the AAAA answer
+	// until the query expires.
+	start := time.Now()
+	ips, err := net.LookupIP("google.com")
+	assert(err, IsNil)
+	assert(len(ips), Equals, 1)
+	assert([]byte(ips[0]), Equals, []byte{8, 8, 8, 8})
+	elapsed := time.Since(start)
+	assert(elapsed >= time.Millisecond*1500, IsTrue)
+	assert(elapsed < time.Second*5, IsTrue)
+}
+
+func TestCache(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	server := startDNSApp(&Config{
+		NameServers: []*net.Endpoint{
+			localNameServer(port),
+		},
+		Cache: &CacheConfig{
+			Size:   1,
+			MaxTtl: 1,
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		ips, err := net.LookupIP("google.com")
+		assert(err, IsNil)
+		assert([]byte(ips[0]), Equals, []byte{8, 8, 8, 8})
+	}
+	hits, misses := server.CacheStats()
+	assert(hits, Equals, uint64(1))
+	assert(misses, Equals, uint64(1))
+
+	// google.com is evicted as cache size is 1.
+	_, err := net.LookupIP("facebook.com")
+	assert(err, IsNil)
+	_, err = net.LookupIP("google.com")
+	assert(err, IsNil)
+	hits, misses = server.CacheStats()
+	assert(hits, Equals, uint64(1))
+	assert(misses, Equals, uint64(3))
+
+	// Records expire after max TTL.
+	time.Sleep(time.Millisecond * 1100)
+	ips, err := net.LookupIP("google.com")
+	assert(err, IsNil)
+	assert([]byte(ips[0]), Equals, []byte{8, 8, 8, 8})
+	hits, misses = server.CacheStats()
+	assert(hits, Equals, uint64(1))
+	assert(misses, Equals, uint64(4))
+}
+
+func TestUDPServerIPFilter(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	poisonedPort := udp.PickPort()
+	poisonedServer := dns.Server{
+		Addr:    "127.0.0.1:" + poisonedPort.String(),
+		Net:     "udp",
+		Handler: &poisonedHandler{},
+		UDPSize: 1200,
+	}
+	go poisonedServer.ListenAndServe()
+	defer poisonedServer.Shutdown()
+
+	rejected := []*router.GeoIP{
+		{
+			CountryCode: "POISON",
+			Cidr: []*router.CIDR{
+				{Ip: []byte{1, 2, 3, 4}, Prefix: 32},
+			},
+		},
+	}
+
+	startDNSApp(&Config{
+		NameServer: []*NameServerConfig{
+			{
+				Address:    localNameServer(poisonedPort),
+				RejectedIp: rejected,
+			},
+			{
+				Address:    localNameServer(port),
+				RejectedIp: rejected,
+			},
+		},
+		QueryStrategy: Config_Race,
+	})
+
+	ips, err := net.LookupIP("google.com")
+	assert(err, IsNil)
+	assert(len(ips), Equals, 1)
+	assert([]byte(ips[0]), Equals, []byte{8, 8, 8, 8})
+}
+
+func TestUDPServerClientSubnet(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &subnetHandler{},
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	startDNSApp(&Config{
+		NameServer: []*NameServerConfig{
+			{
+				Address:      localNameServer(port),
+				ClientSubnet: &ClientSubnetConfig{},
+			},
+		},
+	})
+
+	lookup := func(source string) []byte {
+		ctx := proxy.ContextWithSource(context.Background(), net.TCPDestination(net.ParseAddress(source), 1234))
+		ips, err := net.LookupIPWithContext(ctx, "google.com")
+		assert(err, IsNil)
+		assert(len(ips), Equals, 1)
+		return []byte(ips[0].To4())
+	}
+
+	assert(lookup("5.6.7.8"), Equals, []byte{5, 6, 7, 0})
+	assert(lookup("9.10.11.12"), Equals, []byte{9, 10, 11, 0})
+	assert(lookup("192.168.1.1"), Equals, []byte{127, 0, 0, 1})
+	assert(lookup("5.6.7.9"), Equals, []byte{5, 6, 7, 0})
+}
+
+func TestUDPServerFixedClientSubnet(t *testing.T) {
+	assert := With(t)
+
+	port := udp.PickPort()
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &subnetHandler{},
+		UDPSize: 1200,
+	}
+	go dnsServer.ListenAndServe()
+	defer dnsServer.Shutdown()
+
+	startDNSApp(&Config{
+		NameServer: []*NameServerConfig{
+			{
+				Address: localNameServer(port),
+				ClientSubnet: &ClientSubnetConfig{
+					Subnet: "1.2.3.0/24",
+				},
+			},
+		},
+	})
+
+	ips, err := net.LookupIP("google.com")
+	assert(err, IsNil)
+	assert(len(ips), Equals, 1)
+	assert([]byte(ips[0].To4()), Equals, []byte{1, 2, 3, 0})
+}
@@ -0,0 +1,125 @@
+package dns
+
+import (
+	"github.com/miekg/dns"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+)
+
+const (
+	DefaultIPv4SubnetPrefix = 24
+	DefaultIPv6SubnetPrefix = 56
+)
+
+var nonPublicIPRanges []*net.IPNet
+
+func init() {
+	for _, cidr := range []string{
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"fc00::/7",
+	} {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		common.Must(err)
+		nonPublicIPRanges = append(nonPublicIPRanges, ipNet)
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, ipNet := range nonPublicIPRanges {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// clientSubnet decides the EDNS Client Subnet to be sent along with DNS queries.
+type clientSubnet struct {
+	fixed      *net.IPNet
+	ipv4Prefix int
+	ipv6Prefix int
+}
+
+func newClientSubnet(config *ClientSubnetConfig) (*clientSubnet, error) {
+	if config == nil {
+		return nil, nil
+	}
+	s := &clientSubnet{
+		ipv4Prefix: DefaultIPv4SubnetPrefix,
+		ipv6Prefix: DefaultIPv6SubnetPrefix,
+	}
+	if config.Ipv4Prefix > 0 {
+		if config.Ipv4Prefix > net.IPv4len*8 {
+			return nil, newError("invalid IPv4 prefix for client subnet: ", config.Ipv4Prefix)
+		}
+		s.ipv4Prefix = int(config.Ipv4Prefix)
+	}
+	if config.Ipv6Prefix > 0 {
+		if config.Ipv6Prefix > net.IPv6len*8 {
+			return nil, newError("invalid IPv6 prefix for client subnet: ", config.Ipv6Prefix)
+		}
+		s.ipv6Prefix = int(config.Ipv6Prefix)
+	}
+	if len(config.Subnet) > 0 {
+		_, subnet, err := net.ParseCIDR(config.Subnet)
+		if err != nil {
+			return nil, newError("invalid client subnet: ", config.Subnet).Base(err)
+		}
+		s.fixed = subnet
+	}
+	return s, nil
+}
+
+// IsDerived returns true if the subnet is derived from the IP of clients.
+func (s *clientSubnet) IsDerived() bool {
+	return s != nil && s.fixed == nil
+}
+
+// Subnet returns the subnet to be sent for the given client IP, or nil if no subnet should be sent.
+// The client IP may be nil.
+func (s *clientSubnet) Subnet(clientIP net.IP) *net.IPNet {
+	if s == nil {
+		return nil
+	}
+	if s.fixed != nil {
+		return s.fixed
+	}
+	if clientIP == nil || !isPublicIP(clientIP) {
+		return nil
+	}
+	if ipv4 := clientIP.To4(); ipv4 != nil {
+		mask := net.CIDRMask(s.ipv4Prefix, net.IPv4len*8)
+		return &net.IPNet{IP: ipv4.Mask(mask), Mask: mask}
+	}
+	mask := net.CIDRMask(s.ipv6Prefix, net.IPv6len*8)
+	return &net.IPNet{IP: clientIP.Mask(mask), Mask: mask}
+}
+
+// setClientSubnet adds the given subnet as an EDNS0 option into the message.
+func setClientSubnet(msg *dns.Msg, subnet *net.IPNet) {
+	option := &dns.EDNS0_SUBNET{
+		Code:    dns.EDNS0SUBNET,
+		Address: subnet.IP,
+	}
+	ones, _ := subnet.Mask.Size()
+	option.SourceNetmask = uint8(ones)
+	if ipv4 := subnet.IP.To4(); ipv4 != nil {
+		option.Family = 1
+		option.Address = ipv4
+	} else {
+		option.Family = 2
+	}
+
+	opt := new(dns.OPT)
+	opt.Hdr.Name = "."
+	opt.Hdr.Rrtype = dns.TypeOPT
+	opt.SetUDPSize(dns.DefaultMsgSize)
+	opt.Option = append(opt.Option, option)
+	msg.Extra = append(msg.Extra, opt)
+}
@@ -25,4 +25,10 @@ func (p *Policy) OverrideWith(another *Policy) {
 			p.Timeout.DownlinkOnly = another.Timeout.DownlinkOnly
 		}
 	}
+	if another.Bandwidth != nil {
+		p.Bandwidth = another.Bandwidth
+	}
+	if another.Session != nil {
+		p.Session = another.Session
+	}
 }
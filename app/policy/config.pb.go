@@ -32,7 +32,9 @@ func (m *Second) GetValue() uint32 {
 }
 
 type Policy struct {
-	Timeout *Policy_Timeout `protobuf:"bytes,1,opt,name=timeout" json:"timeout,omitempty"`
+	Timeout   *Policy_Timeout   `protobuf:"bytes,1,opt,name=timeout" json:"timeout,omitempty"`
+	Bandwidth *Policy_Bandwidth `protobuf:"bytes,2,opt,name=bandwidth" json:"bandwidth,omitempty"`
+	Session   *Policy_Session   `protobuf:"bytes,3,opt,name=session" json:"session,omitempty"`
 }
 
 func (m *Policy) Reset()                    { *m = Policy{} }
@@ -47,6 +49,20 @@ func (m *Policy) GetTimeout() *Policy_Timeout {
 	return nil
 }
 
+func (m *Policy) GetBandwidth() *Policy_Bandwidth {
+	if m != nil {
+		return m.Bandwidth
+	}
+	return nil
+}
+
+func (m *Policy) GetSession() *Policy_Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 // Timeout is a message for timeout settings in various stages, in seconds.
 type Policy_Timeout struct {
 	Handshake      *Second `protobuf:"bytes,1,opt,name=handshake" json:"handshake,omitempty"`
@@ -88,6 +104,93 @@ func (m *Policy_Timeout) GetDownlinkOnly() *Second {
 	return nil
 }
 
+// Bandwidth is a message for bandwidth limits, in bytes per second. 0 means unlimited.
+type Policy_Bandwidth struct {
+	// Limits of each connection.
+	Uplink   uint64 `protobuf:"varint,1,opt,name=uplink" json:"uplink,omitempty"`
+	Downlink uint64 `protobuf:"varint,2,opt,name=downlink" json:"downlink,omitempty"`
+	// Limits of all connections of a user together.
+	UserUplink   uint64 `protobuf:"varint,3,opt,name=user_uplink,json=userUplink" json:"user_uplink,omitempty"`
+	UserDownlink uint64 `protobuf:"varint,4,opt,name=user_downlink,json=userDownlink" json:"user_downlink,omitempty"`
+	// Max number of bytes that may be sent at once above the rate. Defaults to the bytes of one second.
+	Burst uint64 `protobuf:"varint,5,opt,name=burst" json:"burst,omitempty"`
+}
+
+func (m *Policy_Bandwidth) Reset()                    { *m = Policy_Bandwidth{} }
+func (m *Policy_Bandwidth) String() string            { return proto.CompactTextString(m) }
+func (*Policy_Bandwidth) ProtoMessage()               {}
+func (*Policy_Bandwidth) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 1} }
+
+func (m *Policy_Bandwidth) GetUplink() uint64 {
+	if m != nil {
+		return m.Uplink
+	}
+	return 0
+}
+
+func (m *Policy_Bandwidth) GetDownlink() uint64 {
+	if m != nil {
+		return m.Downlink
+	}
+	return 0
+}
+
+func (m *Policy_Bandwidth) GetUserUplink() uint64 {
+	if m != nil {
+		return m.UserUplink
+	}
+	return 0
+}
+
+func (m *Policy_Bandwidth) GetUserDownlink() uint64 {
+	if m != nil {
+		return m.UserDownlink
+	}
+	return 0
+}
+
+func (m *Policy_Bandwidth) GetBurst() uint64 {
+	if m != nil {
+		return m.Burst
+	}
+	return 0
+}
+
+// Session is a message for limits on the sessions of a user. 0 means unlimited.
+type Policy_Session struct {
+	// Max number of live sessions of a user.
+	MaxConnections uint32 `protobuf:"varint,1,opt,name=max_connections,json=maxConnections" json:"max_connections,omitempty"`
+	// Max number of distinct source IPs of a user, including IPs with live sessions and IPs seen within the window.
+	MaxIps   uint32  `protobuf:"varint,2,opt,name=max_ips,json=maxIps" json:"max_ips,omitempty"`
+	IpWindow *Second `protobuf:"bytes,3,opt,name=ip_window,json=ipWindow" json:"ip_window,omitempty"`
+}
+
+func (m *Policy_Session) Reset()                    { *m = Policy_Session{} }
+func (m *Policy_Session) String() string            { return proto.CompactTextString(m) }
+func (*Policy_Session) ProtoMessage()               {}
+func (*Policy_Session) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 2} }
+
+func (m *Policy_Session) GetMaxConnections() uint32 {
+	if m != nil {
+		return m.MaxConnections
+	}
+	return 0
+}
+
+func (m *Policy_Session) GetMaxIps() uint32 {
+	if m != nil {
+		return m.MaxIps
+	}
+	return 0
+}
+
+func (m *Policy_Session) GetIpWindow() *Second {
+	if m != nil {
+		return m.IpWindow
+	}
+	return nil
+}
+
 type Config struct {
 	Level map[uint32]*Policy `protobuf:"bytes,1,rep,name=level" json:"level,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
@@ -108,33 +211,46 @@ func init() {
 	proto.RegisterType((*Second)(nil), "v2ray.core.app.policy.Second")
 	proto.RegisterType((*Policy)(nil), "v2ray.core.app.policy.Policy")
 	proto.RegisterType((*Policy_Timeout)(nil), "v2ray.core.app.policy.Policy.Timeout")
+	proto.RegisterType((*Policy_Bandwidth)(nil), "v2ray.core.app.policy.Policy.Bandwidth")
+	proto.RegisterType((*Policy_Session)(nil), "v2ray.core.app.policy.Policy.Session")
 	proto.RegisterType((*Config)(nil), "v2ray.core.app.policy.Config")
 }
 
 func init() { proto.RegisterFile("v2ray.com/core/app/policy/config.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 349 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xc1, 0x4a, 0xeb, 0x40,
-	0x14, 0x86, 0x49, 0x7a, 0x9b, 0x72, 0x4f, 0x6f, 0xaf, 0x32, 0x58, 0x88, 0x05, 0xa5, 0x14, 0x94,
-	0xae, 0x26, 0x90, 0x6e, 0x44, 0xb1, 0x62, 0x45, 0x41, 0x10, 0x2c, 0x51, 0x14, 0xdc, 0x94, 0x71,
-	0x32, 0xda, 0xd0, 0xe9, 0x9c, 0x21, 0xa6, 0x95, 0xbc, 0x86, 0x6f, 0xe0, 0xd6, 0x87, 0xf2, 0x59,
-	0x24, 0x99, 0x84, 0x6c, 0x5a, 0xe9, 0x6e, 0x72, 0xf8, 0xfe, 0x8f, 0x43, 0xfe, 0x03, 0x87, 0x4b,
-	0x3f, 0x66, 0x29, 0xe5, 0x38, 0xf7, 0x38, 0xc6, 0xc2, 0x63, 0x5a, 0x7b, 0x1a, 0x65, 0xc4, 0x53,
-	0x8f, 0xa3, 0x7a, 0x89, 0x5e, 0xa9, 0x8e, 0x31, 0x41, 0xd2, 0x2e, 0xb9, 0x58, 0x50, 0xa6, 0x35,
-	0x35, 0x4c, 0x6f, 0x1f, 0x9c, 0x3b, 0xc1, 0x51, 0x85, 0x64, 0x07, 0xea, 0x4b, 0x26, 0x17, 0xc2,
-	0xb5, 0xba, 0x56, 0xbf, 0x15, 0x98, 0x8f, 0xde, 0xb7, 0x0d, 0xce, 0x38, 0x47, 0xc9, 0x19, 0x34,
-	0x92, 0x68, 0x2e, 0x70, 0x91, 0xe4, 0x48, 0xd3, 0x3f, 0xa0, 0x2b, 0x9d, 0xd4, 0xf0, 0xf4, 0xde,
-	0xc0, 0x41, 0x99, 0xea, 0x7c, 0xd8, 0xd0, 0x28, 0x86, 0xe4, 0x04, 0xfe, 0x4e, 0x99, 0x0a, 0xdf,
-	0xa6, 0x6c, 0x26, 0x0a, 0xdd, 0xde, 0x1a, 0x9d, 0xd9, 0x2f, 0xa8, 0x78, 0x72, 0x05, 0x5b, 0x1c,
-	0x95, 0x12, 0x3c, 0x89, 0x50, 0x4d, 0xa2, 0x50, 0x0a, 0xd7, 0xde, 0x44, 0xf1, 0xbf, 0x4a, 0x5d,
-	0x87, 0x52, 0x90, 0x21, 0x34, 0x17, 0x5a, 0x46, 0x6a, 0x36, 0x41, 0x25, 0x53, 0xb7, 0xb6, 0x89,
-	0x03, 0x4c, 0xe2, 0x56, 0xc9, 0x94, 0x8c, 0xa0, 0x15, 0xe2, 0xbb, 0xaa, 0x0c, 0x7f, 0x36, 0x31,
-	0xfc, 0x2b, 0x33, 0x99, 0xa3, 0xf7, 0x69, 0x81, 0x73, 0x91, 0x17, 0x45, 0x86, 0x50, 0x97, 0x62,
-	0x29, 0xa4, 0x6b, 0x75, 0x6b, 0xfd, 0xa6, 0xdf, 0x5f, 0xa3, 0x31, 0x34, 0xbd, 0xc9, 0xd0, 0x4b,
-	0x95, 0xc4, 0x69, 0x60, 0x62, 0x9d, 0x47, 0x80, 0x6a, 0x48, 0xb6, 0xa1, 0x36, 0x13, 0x69, 0xd1,
-	0x66, 0xf6, 0x24, 0x83, 0xb2, 0xe1, 0xdf, 0x7f, 0x96, 0xa9, 0xaf, 0x38, 0x80, 0x63, 0xfb, 0xc8,
-	0x1a, 0x9d, 0xc2, 0x2e, 0xc7, 0xf9, 0x6a, 0x7c, 0x6c, 0x3d, 0x39, 0xe6, 0xf5, 0x65, 0xb7, 0x1f,
-	0xfc, 0x80, 0x65, 0x0b, 0xc6, 0x82, 0x9e, 0x6b, 0x5d, 0x98, 0x9e, 0x9d, 0xfc, 0x02, 0x07, 0x3f,
-	0x01, 0x00, 0x00, 0xff, 0xff, 0xcf, 0x25, 0x25, 0xc2, 0xab, 0x02, 0x00, 0x00,
+	// 516 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x94, 0xcf, 0x6f, 0xd3, 0x30,
+	0x14, 0xc7, 0x95, 0xa4, 0x4d, 0xd7, 0xd7, 0x75, 0x43, 0xd6, 0x06, 0x21, 0x12, 0x30, 0x15, 0xc1,
+	0x7a, 0x4a, 0xa5, 0xee, 0x82, 0x86, 0x18, 0xa2, 0x63, 0x48, 0x93, 0x90, 0x98, 0x3c, 0x60, 0x12,
+	0x97, 0xc8, 0x4d, 0x0c, 0xb5, 0x9a, 0xd8, 0x56, 0x7e, 0xb4, 0xcd, 0x5f, 0xc0, 0x8d, 0x03, 0x57,
+	0x4e, 0x5c, 0xf9, 0x2b, 0x51, 0xec, 0xa4, 0xb9, 0x6c, 0x53, 0x6e, 0xf1, 0xf3, 0xe7, 0xfb, 0xd1,
+	0xf3, 0xab, 0x5d, 0x78, 0xb9, 0x9a, 0x26, 0xa4, 0xf0, 0x02, 0x11, 0x4f, 0x02, 0x91, 0xd0, 0x09,
+	0x91, 0x72, 0x22, 0x45, 0xc4, 0x82, 0x62, 0x12, 0x08, 0xfe, 0x9d, 0xfd, 0xf0, 0x64, 0x22, 0x32,
+	0x81, 0x0e, 0x6b, 0x2e, 0xa1, 0x1e, 0x91, 0xd2, 0xd3, 0xcc, 0xe8, 0x29, 0xd8, 0xd7, 0x34, 0x10,
+	0x3c, 0x44, 0x07, 0xd0, 0x5d, 0x91, 0x28, 0xa7, 0x8e, 0x71, 0x64, 0x8c, 0x87, 0x58, 0x2f, 0x46,
+	0xbf, 0x6c, 0xb0, 0xaf, 0x14, 0x8a, 0xde, 0x42, 0x2f, 0x63, 0x31, 0x15, 0x79, 0xa6, 0x90, 0xc1,
+	0xf4, 0x85, 0x77, 0xab, 0xd3, 0xd3, 0xbc, 0xf7, 0x59, 0xc3, 0xb8, 0x4e, 0xa1, 0x0b, 0xe8, 0xcf,
+	0x09, 0x0f, 0xd7, 0x2c, 0xcc, 0x16, 0x8e, 0xa9, 0x14, 0xc7, 0xf7, 0x2b, 0x66, 0x35, 0x8e, 0x9b,
+	0x64, 0xd9, 0x47, 0x4a, 0xd3, 0x94, 0x09, 0xee, 0x58, 0x6d, 0xfa, 0xb8, 0xd6, 0x30, 0xae, 0x53,
+	0xee, 0x6f, 0x13, 0x7a, 0x55, 0x73, 0xe8, 0x35, 0xf4, 0x17, 0x84, 0x87, 0xe9, 0x82, 0x2c, 0x69,
+	0x75, 0xac, 0x27, 0x77, 0xe8, 0xf4, 0x9c, 0x70, 0xc3, 0xa3, 0x0f, 0xb0, 0x1f, 0x08, 0xce, 0x69,
+	0x90, 0x31, 0xc1, 0x7d, 0x16, 0x46, 0xd4, 0x31, 0xdb, 0x28, 0xf6, 0x9a, 0xd4, 0x65, 0x18, 0x51,
+	0x74, 0x06, 0x83, 0x5c, 0x46, 0x8c, 0x2f, 0x7d, 0xc1, 0xa3, 0xc2, 0xb1, 0xda, 0x38, 0x40, 0x27,
+	0x3e, 0xf1, 0xa8, 0x40, 0x33, 0x18, 0x86, 0x62, 0xcd, 0x1b, 0x43, 0xa7, 0x8d, 0x61, 0xb7, 0xce,
+	0x94, 0x0e, 0xf7, 0x8f, 0x01, 0xfd, 0xed, 0xb8, 0xd1, 0x43, 0xb0, 0xb5, 0x5f, 0xcd, 0xa4, 0x83,
+	0xab, 0x15, 0x72, 0x61, 0xa7, 0x4e, 0xa9, 0xa3, 0x76, 0xf0, 0x76, 0x8d, 0x9e, 0xc1, 0x20, 0x4f,
+	0x69, 0xe2, 0x57, 0x41, 0x4b, 0x6d, 0x43, 0x59, 0xfa, 0xa2, 0xc3, 0xcf, 0x61, 0xa8, 0x80, 0xad,
+	0xa1, 0xa3, 0x90, 0xdd, 0xb2, 0xf8, 0xbe, 0xb6, 0x1c, 0x40, 0x77, 0x9e, 0x27, 0x69, 0xe6, 0x74,
+	0xd5, 0xa6, 0x5e, 0xb8, 0x3f, 0x0d, 0xe8, 0x55, 0xbf, 0x23, 0x3a, 0x86, 0xfd, 0x98, 0x6c, 0xfc,
+	0x66, 0x86, 0x69, 0x75, 0x65, 0xf7, 0x62, 0xb2, 0x39, 0x6f, 0xaa, 0xe8, 0x11, 0xf4, 0x4a, 0x90,
+	0xc9, 0x54, 0xf5, 0x3a, 0xc4, 0x76, 0x4c, 0x36, 0x97, 0x32, 0x45, 0xa7, 0xd0, 0x67, 0xd2, 0x5f,
+	0x33, 0x1e, 0x8a, 0x75, 0xbb, 0x69, 0xef, 0x30, 0x79, 0xa3, 0xf0, 0xd1, 0x5f, 0x03, 0xec, 0x73,
+	0xf5, 0xb0, 0xd0, 0x19, 0x74, 0x23, 0xba, 0xa2, 0x91, 0x63, 0x1c, 0x59, 0xe3, 0xc1, 0x74, 0x7c,
+	0x87, 0x42, 0xd3, 0xde, 0xc7, 0x12, 0xbd, 0xe0, 0x59, 0x52, 0x60, 0x1d, 0x73, 0x6f, 0x00, 0x9a,
+	0x22, 0x7a, 0x00, 0xd6, 0x92, 0x16, 0xd5, 0x51, 0xca, 0x4f, 0x74, 0x52, 0xbf, 0xc8, 0xfb, 0x2f,
+	0x95, 0xbe, 0xe6, 0xd5, 0x83, 0x3d, 0x35, 0x5f, 0x19, 0xb3, 0x37, 0xf0, 0x38, 0x10, 0xf1, 0xed,
+	0xf8, 0x95, 0xf1, 0xcd, 0xd6, 0x5f, 0xff, 0xcc, 0xc3, 0xaf, 0x53, 0x4c, 0xca, 0x06, 0x13, 0xea,
+	0xbd, 0x93, 0xb2, 0x32, 0xcd, 0x6d, 0xf5, 0x8f, 0x71, 0xf2, 0x7f, 0x00, 0x99, 0xea, 0x5e, 0x7b,
+	0x5b, 0x04, 0x00, 0x00,
 }
@@ -1,15 +1,20 @@
 package manager
 
+//go:generate go run $GOPATH/src/v2ray.com/core/common/errors/errorgen/main.go -pkg manager -path App,Policy,Manager
+
 import (
 	"context"
 
 	"v2ray.com/core/app/policy"
 	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
 )
 
 // Instance is an instance of Policy manager.
 type Instance struct {
-	levels map[uint32]*policy.Policy
+	levels   map[uint32]*policy.Policy
+	sessions *sessionTracker
 }
 
 // New creates new Policy manager instance.
@@ -24,7 +29,8 @@ func New(ctx context.Context, config *policy.Config) (*Instance, error) {
 		*p = g
 	}
 	return &Instance{
-		levels: levels,
+		levels:   levels,
+		sessions: newSessionTracker(),
 	}, nil
 }
 
@@ -36,6 +42,8 @@ func global() policy.Policy {
 			UplinkOnly:     &policy.Second{Value: 5},
 			DownlinkOnly:   &policy.Second{Value: 30},
 		},
+		Bandwidth: &policy.Policy_Bandwidth{},
+		Session:   &policy.Policy_Session{},
 	}
 }
 
@@ -47,6 +55,14 @@ func (m *Instance) GetPolicy(level uint32) policy.Policy {
 	return global()
 }
 
+// AcquireSession implements policy.Manager.
+func (m *Instance) AcquireSession(user *protocol.User, source net.Address) (func(), error) {
+	if user == nil || len(user.Email) == 0 {
+		return func() {}, nil
+	}
+	return m.sessions.acquire(user.Email, m.GetPolicy(user.Level).Session, source)
+}
+
 // Start implements app.Application.Start().
 func (m *Instance) Start() error {
 	return nil
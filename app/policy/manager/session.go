@@ -0,0 +1,127 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/common/net"
+)
+
+// sessionCleanupInterval is the interval to remove users without live sessions or recent IPs.
+const sessionCleanupInterval = time.Minute
+
+// ipSessions is the sessions of a user from one IP.
+type ipSessions struct {
+	connections int
+	// lastSeen is the time when the last session from the IP ended.
+	lastSeen time.Time
+}
+
+// userSessions is the sessions of a user from all IPs.
+type userSessions struct {
+	connections int
+	ips         map[string]*ipSessions
+	window      time.Duration
+}
+
+// prune removes the IPs without live sessions, whose last session ended before the window.
+func (u *userSessions) prune(now time.Time) {
+	for ip, s := range u.ips {
+		if s.connections == 0 && now.Sub(s.lastSeen) >= u.window {
+			delete(u.ips, ip)
+		}
+	}
+}
+
+func (u *userSessions) isEmpty() bool {
+	return u.connections == 0 && len(u.ips) == 0
+}
+
+// sessionTracker tracks the live sessions of users by their emails.
+type sessionTracker struct {
+	sync.Mutex
+	users       map[string]*userSessions
+	lastCleanup time.Time
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{
+		users:       make(map[string]*userSessions),
+		lastCleanup: time.Now(),
+	}
+}
+
+func (t *sessionTracker) cleanup(now time.Time) {
+	if now.Sub(t.lastCleanup) < sessionCleanupInterval {
+		return
+	}
+	for email, u := range t.users {
+		u.prune(now)
+		if u.isEmpty() {
+			delete(t.users, email)
+		}
+	}
+	t.lastCleanup = now
+}
+
+// acquire registers a new session of the user with the given email. The source may be nil if it is unknown, in which
+// case the session doesn't count for the IP limit.
+func (t *sessionTracker) acquire(email string, config *policy.Policy_Session, source net.Address) (func(), error) {
+	if config.GetMaxConnections() == 0 && config.GetMaxIps() == 0 {
+		return func() {}, nil
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	now := time.Now()
+	t.cleanup(now)
+
+	u := t.users[email]
+	if u == nil {
+		u = &userSessions{
+			ips: make(map[string]*ipSessions),
+		}
+		t.users[email] = u
+	}
+	u.window = time.Second * time.Duration(config.GetIpWindow().GetValue())
+	u.prune(now)
+
+	if config.MaxConnections > 0 && u.connections >= int(config.MaxConnections) {
+		return nil, newError("too many connections of user ", email)
+	}
+	var s *ipSessions
+	if source != nil {
+		ip := source.String()
+		s = u.ips[ip]
+		if s == nil {
+			if config.MaxIps > 0 && len(u.ips) >= int(config.MaxIps) {
+				return nil, newError("too many IPs of user ", email)
+			}
+			s = &ipSessions{}
+			u.ips[ip] = s
+		}
+		s.connections++
+	}
+	u.connections++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.Lock()
+			defer t.Unlock()
+
+			now := time.Now()
+			u.connections--
+			if s != nil {
+				s.connections--
+				s.lastSeen = now
+			}
+			u.prune(now)
+			if u.isEmpty() && t.users[email] == u {
+				delete(t.users, email)
+			}
+		})
+	}, nil
+}
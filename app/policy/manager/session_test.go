@@ -0,0 +1,101 @@
+package manager_test
+
+import (
+	"context"
+	"testing"
+
+	"v2ray.com/core/app/policy"
+	. "v2ray.com/core/app/policy/manager"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	. "v2ray.com/ext/assert"
+)
+
+func TestSessionMaxConnections(t *testing.T) {
+	assert := With(t)
+
+	m, err := New(context.Background(), &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			1: {Session: &policy.Policy_Session{MaxConnections: 2}},
+		},
+	})
+	assert(err, IsNil)
+
+	user := &protocol.User{Level: 1, Email: "love@v2ray.com"}
+	source := net.ParseAddress("1.2.3.4")
+
+	release1, err := m.AcquireSession(user, source)
+	assert(err, IsNil)
+	release2, err := m.AcquireSession(user, source)
+	assert(err, IsNil)
+	_, err = m.AcquireSession(user, source)
+	assert(err, IsNotNil)
+
+	// Other users and users without email are not affected.
+	_, err = m.AcquireSession(&protocol.User{Level: 1, Email: "other@v2ray.com"}, source)
+	assert(err, IsNil)
+	for i := 0; i < 3; i++ {
+		_, err = m.AcquireSession(&protocol.User{Level: 1}, source)
+		assert(err, IsNil)
+	}
+
+	release1()
+	release1()
+	release3, err := m.AcquireSession(user, source)
+	assert(err, IsNil)
+	_, err = m.AcquireSession(user, source)
+	assert(err, IsNotNil)
+
+	release2()
+	release3()
+}
+
+func TestSessionMaxIPs(t *testing.T) {
+	assert := With(t)
+
+	m, err := New(context.Background(), &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {Session: &policy.Policy_Session{MaxIps: 1}},
+			1: {Session: &policy.Policy_Session{MaxIps: 1, IpWindow: &policy.Second{Value: 60}}},
+		},
+	})
+	assert(err, IsNil)
+
+	ip1 := net.ParseAddress("1.2.3.4")
+	ip2 := net.ParseAddress("5.6.7.8")
+
+	// Without a window, an IP doesn't count after its sessions end.
+	user := &protocol.User{Level: 0, Email: "love@v2ray.com"}
+	release, err := m.AcquireSession(user, ip1)
+	assert(err, IsNil)
+	_, err = m.AcquireSession(user, ip2)
+	assert(err, IsNotNil)
+	release()
+	release, err = m.AcquireSession(user, ip2)
+	assert(err, IsNil)
+	release()
+
+	// With a window, an IP counts until the window passes.
+	user = &protocol.User{Level: 1, Email: "v2ray@v2ray.com"}
+	release, err = m.AcquireSession(user, ip1)
+	assert(err, IsNil)
+	release2, err := m.AcquireSession(user, ip1)
+	assert(err, IsNil)
+	release()
+	release2()
+	_, err = m.AcquireSession(user, ip2)
+	assert(err, IsNotNil)
+}
+
+func TestSessionNoLimit(t *testing.T) {
+	assert := With(t)
+
+	m, err := New(context.Background(), &policy.Config{})
+	assert(err, IsNil)
+
+	user := &protocol.User{Email: "love@v2ray.com"}
+	for i := 0; i < 100; i++ {
+		_, err := m.AcquireSession(user, net.IPAddress([]byte{10, 0, 0, byte(i)}))
+		assert(err, IsNil)
+	}
+}
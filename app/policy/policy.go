@@ -2,12 +2,19 @@ package policy
 
 import (
 	"v2ray.com/core/app"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
 )
 
 // Manager is an utility to manage policy per user level.
 type Manager interface {
 	// GetPolicy returns the Policy for the given user level.
 	GetPolicy(level uint32) Policy
+
+	// AcquireSession registers a new session of the user from the source, across all inbound handlers. It returns a
+	// function to be called when the session ends, or an error if the session exceeds the limits in the policy of the
+	// user. Users without an email are not limited.
+	AcquireSession(user *protocol.User, source net.Address) (func(), error)
 }
 
 // FromSpace returns the policy.Manager in a space.
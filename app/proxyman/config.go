@@ -2,6 +2,7 @@ package proxyman
 
 import (
 	"context"
+	"time"
 
 	"v2ray.com/core/proxy"
 )
@@ -20,6 +21,38 @@ func (s *AllocationStrategy) GetRefreshValue() uint32 {
 	return s.Refresh.Value
 }
 
+// GetIdleTimeoutValue returns the duration that a Mux connection without sessions stays open.
+func (c *MultiplexingConfig) GetIdleTimeoutValue() time.Duration {
+	if c == nil || c.IdleTimeout == 0 {
+		return time.Second * 16
+	}
+	return time.Second * time.Duration(c.IdleTimeout)
+}
+
+// GetMaxLifetimeValue returns the duration before a Mux connection stops accepting new sessions, or 0 if unlimited.
+func (c *MultiplexingConfig) GetMaxLifetimeValue() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return time.Second * time.Duration(c.MaxLifetime)
+}
+
+// GetMaxSessionsValue returns the max number of sessions over the lifetime of a Mux connection.
+func (c *MultiplexingConfig) GetMaxSessionsValue() uint32 {
+	if c == nil || c.MaxSessions == 0 {
+		return 128
+	}
+	return c.MaxSessions
+}
+
+// GetMaxFrameSizeValue returns the max number of payload bytes in a Mux frame.
+func (c *MultiplexingConfig) GetMaxFrameSizeValue() uint32 {
+	if c == nil || c.MaxFrameSize == 0 {
+		return 8 * 1024
+	}
+	return c.MaxFrameSize
+}
+
 func (c *OutboundHandlerConfig) GetProxyHandler(ctx context.Context) (proxy.Outbound, error) {
 	if c == nil {
 		return nil, newError("OutboundHandlerConfig is nil")
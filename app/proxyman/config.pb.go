@@ -22,17 +22,23 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 type KnownProtocols int32
 
 const (
-	KnownProtocols_HTTP KnownProtocols = 0
-	KnownProtocols_TLS  KnownProtocols = 1
+	KnownProtocols_HTTP       KnownProtocols = 0
+	KnownProtocols_TLS        KnownProtocols = 1
+	KnownProtocols_QUIC       KnownProtocols = 2
+	KnownProtocols_BitTorrent KnownProtocols = 3
 )
 
 var KnownProtocols_name = map[int32]string{
 	0: "HTTP",
 	1: "TLS",
+	2: "QUIC",
+	3: "BitTorrent",
 }
 var KnownProtocols_value = map[string]int32{
-	"HTTP": 0,
-	"TLS":  1,
+	"HTTP":       0,
+	"TLS":        1,
+	"QUIC":       2,
+	"BitTorrent": 3,
 }
 
 func (x KnownProtocols) String() string {
@@ -47,7 +53,8 @@ const (
 	AllocationStrategy_Always AllocationStrategy_Type = 0
 	// Randomly allocate specific range of handlers.
 	AllocationStrategy_Random AllocationStrategy_Type = 1
-	// External. Not supported yet.
+	// Allocate handlers on the ports set by external controllers, through the HTTP API of InboundConfig or
+	// port_file.
 	AllocationStrategy_External AllocationStrategy_Type = 2
 )
 
@@ -67,7 +74,67 @@ func (x AllocationStrategy_Type) String() string {
 }
 func (AllocationStrategy_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 0} }
 
+type ConnectionLimit_Action int32
+
+const (
+	// Connections over the limits are closed immediately.
+	ConnectionLimit_Reject ConnectionLimit_Action = 0
+	// Connections over the limits wait up to queue_timeout for the limits to allow them.
+	ConnectionLimit_Queue ConnectionLimit_Action = 1
+)
+
+var ConnectionLimit_Action_name = map[int32]string{
+	0: "Reject",
+	1: "Queue",
+}
+var ConnectionLimit_Action_value = map[string]int32{
+	"Reject": 0,
+	"Queue":  1,
+}
+
+func (x ConnectionLimit_Action) String() string {
+	return proto.EnumName(ConnectionLimit_Action_name, int32(x))
+}
+func (ConnectionLimit_Action) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{3, 0} }
+
+type SenderConfig_ViaStrategy int32
+
+const (
+	// A random address for each connection.
+	SenderConfig_Random SenderConfig_ViaStrategy = 0
+	// Addresses in turn.
+	SenderConfig_RoundRobin SenderConfig_ViaStrategy = 1
+	// The same address for the same destination.
+	SenderConfig_StickyDestination SenderConfig_ViaStrategy = 2
+	// The same address for the same user.
+	SenderConfig_StickyUser SenderConfig_ViaStrategy = 3
+)
+
+var SenderConfig_ViaStrategy_name = map[int32]string{
+	0: "Random",
+	1: "RoundRobin",
+	2: "StickyDestination",
+	3: "StickyUser",
+}
+var SenderConfig_ViaStrategy_value = map[string]int32{
+	"Random":            0,
+	"RoundRobin":        1,
+	"StickyDestination": 2,
+	"StickyUser":        3,
+}
+
+func (x SenderConfig_ViaStrategy) String() string {
+	return proto.EnumName(SenderConfig_ViaStrategy_name, int32(x))
+}
+func (SenderConfig_ViaStrategy) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{6, 0} }
+
 type InboundConfig struct {
+	// Address of the HTTP API to set the ports of inbounds with External allocation. Default value is 127.0.0.1.
+	ApiListen *v2ray_core_common_net.IPOrDomain `protobuf:"bytes,1,opt,name=api_listen,json=apiListen" json:"api_listen,omitempty"`
+	// Port of the HTTP API. The API is disabled if 0. A PUT request to /inbounds/<tag>/ports, whose body is the ports
+	// in the format of port_file, sets the ports of the inbound. The API has no authentication, so it should only be
+	// reachable by the external controller.
+	ApiPort uint32 `protobuf:"varint,2,opt,name=api_port,json=apiPort" json:"api_port,omitempty"`
 }
 
 func (m *InboundConfig) Reset()                    { *m = InboundConfig{} }
@@ -75,6 +142,20 @@ func (m *InboundConfig) String() string            { return proto.CompactTextStr
 func (*InboundConfig) ProtoMessage()               {}
 func (*InboundConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+func (m *InboundConfig) GetApiListen() *v2ray_core_common_net.IPOrDomain {
+	if m != nil {
+		return m.ApiListen
+	}
+	return nil
+}
+
+func (m *InboundConfig) GetApiPort() uint32 {
+	if m != nil {
+		return m.ApiPort
+	}
+	return 0
+}
+
 type AllocationStrategy struct {
 	Type AllocationStrategy_Type `protobuf:"varint,1,opt,name=type,enum=v2ray.core.app.proxyman.AllocationStrategy_Type" json:"type,omitempty"`
 	// Number of handlers (ports) running in parallel.
@@ -83,6 +164,9 @@ type AllocationStrategy struct {
 	// Number of minutes before a handler is regenerated.
 	// Default value is 5 if unset.
 	Refresh *AllocationStrategy_AllocationStrategyRefresh `protobuf:"bytes,3,opt,name=refresh" json:"refresh,omitempty"`
+	// Path of a file with the ports to listen on, when type is External. Ports are separated by whitespaces or
+	// commas, and ranges such as "10000-10010" are allowed. The file is reloaded when modified.
+	PortFile string `protobuf:"bytes,4,opt,name=port_file,json=portFile" json:"port_file,omitempty"`
 }
 
 func (m *AllocationStrategy) Reset()                    { *m = AllocationStrategy{} }
@@ -111,6 +195,13 @@ func (m *AllocationStrategy) GetRefresh() *AllocationStrategy_AllocationStrategy
 	return nil
 }
 
+func (m *AllocationStrategy) GetPortFile() string {
+	if m != nil {
+		return m.PortFile
+	}
+	return ""
+}
+
 type AllocationStrategy_AllocationStrategyConcurrency struct {
 	Value uint32 `protobuf:"varint,1,opt,name=value" json:"value,omitempty"`
 }
@@ -162,6 +253,14 @@ type ReceiverConfig struct {
 	StreamSettings             *v2ray_core_transport_internet.StreamConfig `protobuf:"bytes,4,opt,name=stream_settings,json=streamSettings" json:"stream_settings,omitempty"`
 	ReceiveOriginalDestination bool                                        `protobuf:"varint,5,opt,name=receive_original_destination,json=receiveOriginalDestination" json:"receive_original_destination,omitempty"`
 	DomainOverride             []KnownProtocols                            `protobuf:"varint,7,rep,packed,name=domain_override,json=domainOverride,enum=v2ray.core.app.proxyman.KnownProtocols" json:"domain_override,omitempty"`
+	// If true, sniffed domains are only used for routing. Connections are still made to the original destination.
+	DomainOverrideRouteOnly bool `protobuf:"varint,8,opt,name=domain_override_route_only,json=domainOverrideRouteOnly" json:"domain_override_route_only,omitempty"`
+	// Sniffed domains that are never used. A domain is excluded if it is, or is a subdomain of, any of the entries.
+	DomainOverrideExcluded []string `protobuf:"bytes,9,rep,name=domain_override_excluded,json=domainOverrideExcluded" json:"domain_override_excluded,omitempty"`
+	// Permission bits of the socket file, such as 0666, when listen is the path of a Unix domain socket. If 0, the
+	// permission is decided by the umask.
+	UnixSocketMode  uint32           `protobuf:"varint,10,opt,name=unix_socket_mode,json=unixSocketMode" json:"unix_socket_mode,omitempty"`
+	ConnectionLimit *ConnectionLimit `protobuf:"bytes,11,opt,name=connection_limit,json=connectionLimit" json:"connection_limit,omitempty"`
 }
 
 func (m *ReceiverConfig) Reset()                    { *m = ReceiverConfig{} }
@@ -211,6 +310,88 @@ func (m *ReceiverConfig) GetDomainOverride() []KnownProtocols {
 	return nil
 }
 
+func (m *ReceiverConfig) GetDomainOverrideRouteOnly() bool {
+	if m != nil {
+		return m.DomainOverrideRouteOnly
+	}
+	return false
+}
+
+func (m *ReceiverConfig) GetDomainOverrideExcluded() []string {
+	if m != nil {
+		return m.DomainOverrideExcluded
+	}
+	return nil
+}
+
+func (m *ReceiverConfig) GetUnixSocketMode() uint32 {
+	if m != nil {
+		return m.UnixSocketMode
+	}
+	return 0
+}
+
+func (m *ReceiverConfig) GetConnectionLimit() *ConnectionLimit {
+	if m != nil {
+		return m.ConnectionLimit
+	}
+	return nil
+}
+
+// ConnectionLimit limits the TCP connections accepted by an inbound handler.
+type ConnectionLimit struct {
+	// Maximum number of concurrent connections. 0 means unlimited.
+	MaxConnections uint32 `protobuf:"varint,1,opt,name=max_connections,json=maxConnections" json:"max_connections,omitempty"`
+	// Maximum number of new connections per second. 0 means unlimited.
+	Rate uint32 `protobuf:"varint,2,opt,name=rate" json:"rate,omitempty"`
+	// Maximum number of new connections per second from each source IP. 0 means unlimited.
+	// With PROXY protocol, the source is the one in the header.
+	RatePerIp uint32                 `protobuf:"varint,3,opt,name=rate_per_ip,json=ratePerIp" json:"rate_per_ip,omitempty"`
+	Action    ConnectionLimit_Action `protobuf:"varint,4,opt,name=action,enum=v2ray.core.app.proxyman.ConnectionLimit_Action" json:"action,omitempty"`
+	// Time in milliseconds for queued connections to wait. Default value is 1000.
+	QueueTimeout uint32 `protobuf:"varint,5,opt,name=queue_timeout,json=queueTimeout" json:"queue_timeout,omitempty"`
+}
+
+func (m *ConnectionLimit) Reset()                    { *m = ConnectionLimit{} }
+func (m *ConnectionLimit) String() string            { return proto.CompactTextString(m) }
+func (*ConnectionLimit) ProtoMessage()               {}
+func (*ConnectionLimit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *ConnectionLimit) GetMaxConnections() uint32 {
+	if m != nil {
+		return m.MaxConnections
+	}
+	return 0
+}
+
+func (m *ConnectionLimit) GetRate() uint32 {
+	if m != nil {
+		return m.Rate
+	}
+	return 0
+}
+
+func (m *ConnectionLimit) GetRatePerIp() uint32 {
+	if m != nil {
+		return m.RatePerIp
+	}
+	return 0
+}
+
+func (m *ConnectionLimit) GetAction() ConnectionLimit_Action {
+	if m != nil {
+		return m.Action
+	}
+	return ConnectionLimit_Reject
+}
+
+func (m *ConnectionLimit) GetQueueTimeout() uint32 {
+	if m != nil {
+		return m.QueueTimeout
+	}
+	return 0
+}
+
 type InboundHandlerConfig struct {
 	Tag              string                                 `protobuf:"bytes,1,opt,name=tag" json:"tag,omitempty"`
 	ReceiverSettings *v2ray_core_common_serial.TypedMessage `protobuf:"bytes,2,opt,name=receiver_settings,json=receiverSettings" json:"receiver_settings,omitempty"`
@@ -220,7 +401,7 @@ type InboundHandlerConfig struct {
 func (m *InboundHandlerConfig) Reset()                    { *m = InboundHandlerConfig{} }
 func (m *InboundHandlerConfig) String() string            { return proto.CompactTextString(m) }
 func (*InboundHandlerConfig) ProtoMessage()               {}
-func (*InboundHandlerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*InboundHandlerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
 
 func (m *InboundHandlerConfig) GetTag() string {
 	if m != nil {
@@ -249,7 +430,7 @@ type OutboundConfig struct {
 func (m *OutboundConfig) Reset()                    { *m = OutboundConfig{} }
 func (m *OutboundConfig) String() string            { return proto.CompactTextString(m) }
 func (*OutboundConfig) ProtoMessage()               {}
-func (*OutboundConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*OutboundConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 type SenderConfig struct {
 	// Send traffic through the given IP. Only IP is allowed.
@@ -257,12 +438,23 @@ type SenderConfig struct {
 	StreamSettings    *v2ray_core_transport_internet.StreamConfig `protobuf:"bytes,2,opt,name=stream_settings,json=streamSettings" json:"stream_settings,omitempty"`
 	ProxySettings     *v2ray_core_transport_internet.ProxyConfig  `protobuf:"bytes,3,opt,name=proxy_settings,json=proxySettings" json:"proxy_settings,omitempty"`
 	MultiplexSettings *MultiplexingConfig                         `protobuf:"bytes,4,opt,name=multiplex_settings,json=multiplexSettings" json:"multiplex_settings,omitempty"`
+	// Version of the PROXY protocol header, 1 or 2, to be sent at the beginning of TCP connections. The header carries
+	// the source of the inbound connection. 0 means no header.
+	ProxyProtocol uint32 `protobuf:"varint,5,opt,name=proxy_protocol,json=proxyProtocol" json:"proxy_protocol,omitempty"`
+	// IPs and CIDRs, such as "2001:db8::/64", to send traffic through. An address is chosen for each connection, of
+	// the family of the destination. Domains are resolved first, to choose the family. It takes precedence over via.
+	// Sockets are bound to addresses in CIDRs with IP_FREEBIND, so they need not be configured on any interface, but
+	// they must still be routed to this machine. Without IP_FREEBIND, such as elsewhere than Linux, the addresses must
+	// be configured, or be in a local route, such as the AnyIP route "ip -6 route add local 2001:db8::/64 dev lo".
+	ViaPool []string `protobuf:"bytes,6,rep,name=via_pool,json=viaPool" json:"via_pool,omitempty"`
+	// How to choose an address from via_pool.
+	ViaStrategy SenderConfig_ViaStrategy `protobuf:"varint,7,opt,name=via_strategy,json=viaStrategy,enum=v2ray.core.app.proxyman.SenderConfig_ViaStrategy" json:"via_strategy,omitempty"`
 }
 
 func (m *SenderConfig) Reset()                    { *m = SenderConfig{} }
 func (m *SenderConfig) String() string            { return proto.CompactTextString(m) }
 func (*SenderConfig) ProtoMessage()               {}
-func (*SenderConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*SenderConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 func (m *SenderConfig) GetVia() *v2ray_core_common_net.IPOrDomain {
 	if m != nil {
@@ -292,18 +484,42 @@ func (m *SenderConfig) GetMultiplexSettings() *MultiplexingConfig {
 	return nil
 }
 
+func (m *SenderConfig) GetProxyProtocol() uint32 {
+	if m != nil {
+		return m.ProxyProtocol
+	}
+	return 0
+}
+
+func (m *SenderConfig) GetViaPool() []string {
+	if m != nil {
+		return m.ViaPool
+	}
+	return nil
+}
+
+func (m *SenderConfig) GetViaStrategy() SenderConfig_ViaStrategy {
+	if m != nil {
+		return m.ViaStrategy
+	}
+	return SenderConfig_Random
+}
+
 type OutboundHandlerConfig struct {
 	Tag            string                                 `protobuf:"bytes,1,opt,name=tag" json:"tag,omitempty"`
 	SenderSettings *v2ray_core_common_serial.TypedMessage `protobuf:"bytes,2,opt,name=sender_settings,json=senderSettings" json:"sender_settings,omitempty"`
 	ProxySettings  *v2ray_core_common_serial.TypedMessage `protobuf:"bytes,3,opt,name=proxy_settings,json=proxySettings" json:"proxy_settings,omitempty"`
 	Expire         int64                                  `protobuf:"varint,4,opt,name=expire" json:"expire,omitempty"`
 	Comment        string                                 `protobuf:"bytes,5,opt,name=comment" json:"comment,omitempty"`
+	// Tags of outbound handlers to try in order, if this handler fails to connect. Fallback tags in routing rules
+	// take precedence.
+	FallbackTag []string `protobuf:"bytes,6,rep,name=fallback_tag,json=fallbackTag" json:"fallback_tag,omitempty"`
 }
 
 func (m *OutboundHandlerConfig) Reset()                    { *m = OutboundHandlerConfig{} }
 func (m *OutboundHandlerConfig) String() string            { return proto.CompactTextString(m) }
 func (*OutboundHandlerConfig) ProtoMessage()               {}
-func (*OutboundHandlerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*OutboundHandlerConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *OutboundHandlerConfig) GetTag() string {
 	if m != nil {
@@ -340,17 +556,38 @@ func (m *OutboundHandlerConfig) GetComment() string {
 	return ""
 }
 
+func (m *OutboundHandlerConfig) GetFallbackTag() []string {
+	if m != nil {
+		return m.FallbackTag
+	}
+	return nil
+}
+
 type MultiplexingConfig struct {
 	// Whether or not Mux is enabled.
 	Enabled bool `protobuf:"varint,1,opt,name=enabled" json:"enabled,omitempty"`
 	// Max number of concurrent connections that one Mux connection can handle.
 	Concurrency uint32 `protobuf:"varint,2,opt,name=concurrency" json:"concurrency,omitempty"`
+	// Whether UDP sessions are full-cone. Each packet is sent with its own address, and the server accepts responses
+	// from any peer, instead of the session target only.
+	FullCone bool `protobuf:"varint,3,opt,name=full_cone,json=fullCone" json:"full_cone,omitempty"`
+	// Seconds that a Mux connection without sessions stays open. Default value is 16 if unset.
+	IdleTimeout uint32 `protobuf:"varint,4,opt,name=idle_timeout,json=idleTimeout" json:"idle_timeout,omitempty"`
+	// Seconds since a Mux connection is created before it stops accepting new sessions. 0 means unlimited.
+	MaxLifetime uint32 `protobuf:"varint,5,opt,name=max_lifetime,json=maxLifetime" json:"max_lifetime,omitempty"`
+	// Max number of sessions that one Mux connection can handle over its lifetime. Default value is 128 if unset.
+	MaxSessions uint32 `protobuf:"varint,6,opt,name=max_sessions,json=maxSessions" json:"max_sessions,omitempty"`
+	// Whether to create a spare Mux connection in advance, when no existing connection can take a new session.
+	Prewarm bool `protobuf:"varint,7,opt,name=prewarm" json:"prewarm,omitempty"`
+	// Max number of payload bytes in a Mux frame. Sessions take turns to send frames, so smaller frames reduce the
+	// latency of interactive sessions next to bulk transfers. Default value is 8192 if unset.
+	MaxFrameSize uint32 `protobuf:"varint,8,opt,name=max_frame_size,json=maxFrameSize" json:"max_frame_size,omitempty"`
 }
 
 func (m *MultiplexingConfig) Reset()                    { *m = MultiplexingConfig{} }
 func (m *MultiplexingConfig) String() string            { return proto.CompactTextString(m) }
 func (*MultiplexingConfig) ProtoMessage()               {}
-func (*MultiplexingConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*MultiplexingConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *MultiplexingConfig) GetEnabled() bool {
 	if m != nil {
@@ -366,12 +603,55 @@ func (m *MultiplexingConfig) GetConcurrency() uint32 {
 	return 0
 }
 
+func (m *MultiplexingConfig) GetFullCone() bool {
+	if m != nil {
+		return m.FullCone
+	}
+	return false
+}
+
+func (m *MultiplexingConfig) GetIdleTimeout() uint32 {
+	if m != nil {
+		return m.IdleTimeout
+	}
+	return 0
+}
+
+func (m *MultiplexingConfig) GetMaxLifetime() uint32 {
+	if m != nil {
+		return m.MaxLifetime
+	}
+	return 0
+}
+
+func (m *MultiplexingConfig) GetMaxSessions() uint32 {
+	if m != nil {
+		return m.MaxSessions
+	}
+	return 0
+}
+
+func (m *MultiplexingConfig) GetPrewarm() bool {
+	if m != nil {
+		return m.Prewarm
+	}
+	return false
+}
+
+func (m *MultiplexingConfig) GetMaxFrameSize() uint32 {
+	if m != nil {
+		return m.MaxFrameSize
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*InboundConfig)(nil), "v2ray.core.app.proxyman.InboundConfig")
 	proto.RegisterType((*AllocationStrategy)(nil), "v2ray.core.app.proxyman.AllocationStrategy")
 	proto.RegisterType((*AllocationStrategy_AllocationStrategyConcurrency)(nil), "v2ray.core.app.proxyman.AllocationStrategy.AllocationStrategyConcurrency")
 	proto.RegisterType((*AllocationStrategy_AllocationStrategyRefresh)(nil), "v2ray.core.app.proxyman.AllocationStrategy.AllocationStrategyRefresh")
 	proto.RegisterType((*ReceiverConfig)(nil), "v2ray.core.app.proxyman.ReceiverConfig")
+	proto.RegisterType((*ConnectionLimit)(nil), "v2ray.core.app.proxyman.ConnectionLimit")
 	proto.RegisterType((*InboundHandlerConfig)(nil), "v2ray.core.app.proxyman.InboundHandlerConfig")
 	proto.RegisterType((*OutboundConfig)(nil), "v2ray.core.app.proxyman.OutboundConfig")
 	proto.RegisterType((*SenderConfig)(nil), "v2ray.core.app.proxyman.SenderConfig")
@@ -379,62 +659,95 @@ func init() {
 	proto.RegisterType((*MultiplexingConfig)(nil), "v2ray.core.app.proxyman.MultiplexingConfig")
 	proto.RegisterEnum("v2ray.core.app.proxyman.KnownProtocols", KnownProtocols_name, KnownProtocols_value)
 	proto.RegisterEnum("v2ray.core.app.proxyman.AllocationStrategy_Type", AllocationStrategy_Type_name, AllocationStrategy_Type_value)
+	proto.RegisterEnum("v2ray.core.app.proxyman.ConnectionLimit_Action", ConnectionLimit_Action_name, ConnectionLimit_Action_value)
+	proto.RegisterEnum("v2ray.core.app.proxyman.SenderConfig_ViaStrategy", SenderConfig_ViaStrategy_name, SenderConfig_ViaStrategy_value)
 }
 
 func init() { proto.RegisterFile("v2ray.com/core/app/proxyman/config.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 822 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xd1, 0x8e, 0xdb, 0x44,
-	0x14, 0xad, 0xe3, 0x34, 0xc9, 0xde, 0xed, 0x7a, 0xdd, 0xa1, 0xd0, 0x10, 0x40, 0x0a, 0x01, 0xd1,
-	0xa8, 0x20, 0xa7, 0xa4, 0xe2, 0x81, 0x27, 0x58, 0x76, 0x2b, 0x75, 0x81, 0x55, 0xcc, 0x24, 0xe2,
-	0xa1, 0x42, 0xb2, 0x66, 0xed, 0xa9, 0x19, 0x61, 0xcf, 0x58, 0x33, 0x93, 0x74, 0xfd, 0x4b, 0x7c,
-	0x05, 0x8f, 0x3c, 0xf0, 0x05, 0xfc, 0x0a, 0x2f, 0xc8, 0x9e, 0x71, 0x76, 0xb7, 0x49, 0x5a, 0x96,
-	0xaa, 0x6f, 0x33, 0xc9, 0x39, 0xc7, 0x73, 0xcf, 0x3d, 0x77, 0x06, 0xc6, 0xab, 0xa9, 0x24, 0x65,
-	0x10, 0x8b, 0x7c, 0x12, 0x0b, 0x49, 0x27, 0xa4, 0x28, 0x26, 0x85, 0x14, 0x17, 0x65, 0x4e, 0xf8,
-	0x24, 0x16, 0xfc, 0x39, 0x4b, 0x83, 0x42, 0x0a, 0x2d, 0xd0, 0xfd, 0x06, 0x29, 0x69, 0x40, 0x8a,
-	0x22, 0x68, 0x50, 0x83, 0x47, 0x2f, 0x49, 0xc4, 0x22, 0xcf, 0x05, 0x9f, 0x28, 0x2a, 0x19, 0xc9,
-	0x26, 0xba, 0x2c, 0x68, 0x12, 0xe5, 0x54, 0x29, 0x92, 0x52, 0x23, 0x35, 0x78, 0xb0, 0x9d, 0xc1,
-	0xa9, 0x9e, 0x90, 0x24, 0x91, 0x54, 0x29, 0x0b, 0xfc, 0x74, 0x37, 0xb0, 0x10, 0x52, 0x5b, 0x54,
-	0xf0, 0x12, 0x4a, 0x4b, 0xc2, 0x55, 0xf5, 0xff, 0x84, 0x71, 0x4d, 0x65, 0x85, 0xbe, 0x5a, 0xc9,
-	0xe8, 0x10, 0x0e, 0x4e, 0xf9, 0xb9, 0x58, 0xf2, 0xe4, 0xb8, 0xfe, 0x79, 0xf4, 0x87, 0x0b, 0xe8,
-	0x28, 0xcb, 0x44, 0x4c, 0x34, 0x13, 0x7c, 0xae, 0x25, 0xd1, 0x34, 0x2d, 0xd1, 0x09, 0xb4, 0xab,
-	0xd3, 0xf7, 0x9d, 0xa1, 0x33, 0xf6, 0xa6, 0x8f, 0x82, 0x1d, 0x06, 0x04, 0x9b, 0xd4, 0x60, 0x51,
-	0x16, 0x14, 0xd7, 0x6c, 0xf4, 0x1b, 0xec, 0xc7, 0x82, 0xc7, 0x4b, 0x29, 0x29, 0x8f, 0xcb, 0x7e,
-	0x6b, 0xe8, 0x8c, 0xf7, 0xa7, 0xa7, 0x37, 0x11, 0xdb, 0xfc, 0xe9, 0xf8, 0x52, 0x10, 0x5f, 0x55,
-	0x47, 0x11, 0x74, 0x25, 0x7d, 0x2e, 0xa9, 0xfa, 0xb5, 0xef, 0xd6, 0x1f, 0x7a, 0xf2, 0x66, 0x1f,
-	0xc2, 0x46, 0x0c, 0x37, 0xaa, 0x83, 0xaf, 0xe0, 0xa3, 0x57, 0x1e, 0x07, 0xdd, 0x83, 0xdb, 0x2b,
-	0x92, 0x2d, 0x8d, 0x6b, 0x07, 0xd8, 0x6c, 0x06, 0x5f, 0xc2, 0xfb, 0x3b, 0xc5, 0xb7, 0x53, 0x46,
-	0x5f, 0x40, 0xbb, 0x72, 0x11, 0x01, 0x74, 0x8e, 0xb2, 0x17, 0xa4, 0x54, 0xfe, 0xad, 0x6a, 0x8d,
-	0x09, 0x4f, 0x44, 0xee, 0x3b, 0xe8, 0x0e, 0xf4, 0x9e, 0x5c, 0x54, 0xed, 0x25, 0x99, 0xdf, 0x1a,
-	0xfd, 0xed, 0x82, 0x87, 0x69, 0x4c, 0xd9, 0x8a, 0x4a, 0xd3, 0x55, 0xf4, 0x0d, 0x40, 0x15, 0x82,
-	0x48, 0x12, 0x9e, 0x1a, 0xed, 0xfd, 0xe9, 0xf0, 0xaa, 0x1d, 0x26, 0x4d, 0x01, 0xa7, 0x3a, 0x08,
-	0x85, 0xd4, 0xb8, 0xc2, 0xe1, 0xbd, 0xa2, 0x59, 0xa2, 0xaf, 0xa1, 0x93, 0x31, 0xa5, 0x29, 0xb7,
-	0x4d, 0xfb, 0x78, 0x07, 0xf9, 0x34, 0x9c, 0xc9, 0x13, 0x91, 0x13, 0xc6, 0xb1, 0x25, 0xa0, 0x5f,
-	0xe0, 0x1d, 0xb2, 0xae, 0x37, 0x52, 0xb6, 0x60, 0xdb, 0x93, 0xcf, 0x6f, 0xd0, 0x13, 0x8c, 0xc8,
-	0x66, 0x30, 0x17, 0x70, 0xa8, 0xb4, 0xa4, 0x24, 0x8f, 0x14, 0xd5, 0x9a, 0xf1, 0x54, 0xf5, 0xdb,
-	0x9b, 0xca, 0xeb, 0x31, 0x08, 0x9a, 0x31, 0x08, 0xe6, 0x35, 0xcb, 0xf8, 0x83, 0x3d, 0xa3, 0x31,
-	0xb7, 0x12, 0xe8, 0x5b, 0xf8, 0x50, 0x1a, 0x07, 0x23, 0x21, 0x59, 0xca, 0x38, 0xc9, 0xa2, 0x84,
-	0x2a, 0xcd, 0x78, 0xfd, 0xf5, 0xfe, 0xed, 0xa1, 0x33, 0xee, 0xe1, 0x81, 0xc5, 0xcc, 0x2c, 0xe4,
-	0xe4, 0x12, 0x81, 0x42, 0x38, 0x4c, 0x6a, 0x1f, 0x22, 0xb1, 0xa2, 0x52, 0xb2, 0x84, 0xf6, 0xbb,
-	0x43, 0x77, 0xec, 0x4d, 0x1f, 0xec, 0xac, 0xf8, 0x07, 0x2e, 0x5e, 0xf0, 0xb0, 0x1a, 0xcb, 0x58,
-	0x64, 0x0a, 0x7b, 0x86, 0x3f, 0xb3, 0xf4, 0xef, 0xdb, 0xbd, 0x8e, 0xdf, 0x1d, 0xfd, 0xe5, 0xc0,
-	0x3d, 0x3b, 0xb1, 0x4f, 0x09, 0x4f, 0xb2, 0x75, 0x8b, 0x7d, 0x70, 0x35, 0x49, 0xeb, 0xde, 0xee,
-	0xe1, 0x6a, 0x89, 0xe6, 0x70, 0xd7, 0x1e, 0x50, 0x5e, 0x9a, 0x63, 0xda, 0xf7, 0xd9, 0x96, 0xf6,
-	0x99, 0x4b, 0xaa, 0x1e, 0xd7, 0xe4, 0xcc, 0xdc, 0x51, 0xd8, 0x6f, 0x04, 0xd6, 0xce, 0x9c, 0x81,
-	0x57, 0x1f, 0xf8, 0x52, 0xd1, 0xbd, 0x91, 0xe2, 0x41, 0xcd, 0x6e, 0xe4, 0x46, 0x3e, 0x78, 0xb3,
-	0xa5, 0xbe, 0x7a, 0x01, 0xfd, 0xd9, 0x82, 0x3b, 0x73, 0xca, 0x93, 0x75, 0x61, 0x8f, 0xc1, 0x5d,
-	0x31, 0x62, 0x43, 0xfb, 0x1f, 0x72, 0x57, 0xa1, 0xb7, 0xc5, 0xa2, 0xf5, 0xe6, 0xb1, 0xf8, 0x69,
-	0x47, 0xf1, 0x0f, 0x5f, 0x23, 0x1a, 0x56, 0x24, 0xab, 0x79, 0xdd, 0x00, 0xf4, 0x0c, 0x50, 0xbe,
-	0xcc, 0x34, 0x2b, 0x32, 0x7a, 0xf1, 0xca, 0x08, 0x5f, 0x8b, 0xca, 0x59, 0x43, 0x61, 0x3c, 0xb5,
-	0xba, 0x77, 0xd7, 0x32, 0x6b, 0x73, 0xff, 0x71, 0xe0, 0xdd, 0xc6, 0xdd, 0xd7, 0x85, 0x65, 0x06,
-	0x87, 0xaa, 0x76, 0xfd, 0xff, 0x46, 0xc5, 0x33, 0xf4, 0xb7, 0x14, 0x14, 0xf4, 0x1e, 0x74, 0xe8,
-	0x45, 0xc1, 0x24, 0xad, 0xbd, 0x71, 0xb1, 0xdd, 0xa1, 0x3e, 0x74, 0x2b, 0x11, 0xca, 0x75, 0x3d,
-	0x94, 0x7b, 0xb8, 0xd9, 0x8e, 0x42, 0x40, 0x9b, 0x36, 0x55, 0x78, 0xca, 0xc9, 0x79, 0x46, 0x93,
-	0xba, 0xfa, 0x1e, 0x6e, 0xb6, 0x68, 0xb8, 0xf9, 0x38, 0x1d, 0x5c, 0x7b, 0x51, 0x1e, 0x7e, 0x02,
-	0xde, 0xf5, 0x19, 0x45, 0x3d, 0x68, 0x3f, 0x5d, 0x2c, 0x42, 0xff, 0x16, 0xea, 0x82, 0xbb, 0xf8,
-	0x71, 0xee, 0x3b, 0xdf, 0x1d, 0xc3, 0x07, 0xb1, 0xc8, 0x77, 0x75, 0x2e, 0x74, 0x9e, 0xf5, 0x9a,
-	0xf5, 0xef, 0xad, 0xfb, 0x3f, 0x4f, 0x31, 0x29, 0x83, 0xe3, 0x0a, 0x75, 0x54, 0x14, 0x26, 0x27,
-	0x39, 0xe1, 0xe7, 0x9d, 0xfa, 0x75, 0x7e, 0xfc, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x25, 0xdf,
-	0x6a, 0xb2, 0x93, 0x08, 0x00, 0x00,
+	// 1326 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0xd9, 0x6e, 0x1b, 0x37,
+	0x14, 0xb5, 0x16, 0x6b, 0xb9, 0xb2, 0xe4, 0x09, 0x9b, 0x45, 0x71, 0xba, 0x28, 0x6a, 0xda, 0x08,
+	0x69, 0x31, 0x4a, 0x1c, 0x14, 0x68, 0x51, 0x14, 0x8d, 0xe3, 0x24, 0x8d, 0x5b, 0x1b, 0x56, 0x28,
+	0x25, 0x0f, 0x41, 0x81, 0x01, 0x3d, 0x43, 0xab, 0xac, 0x39, 0xe4, 0x94, 0x43, 0x29, 0x52, 0x3e,
+	0xa4, 0x8f, 0xfd, 0x80, 0x3e, 0xf6, 0x3b, 0xfa, 0xd0, 0x1f, 0xe8, 0xa7, 0x14, 0x28, 0xc8, 0x99,
+	0xd1, 0x62, 0x5b, 0x59, 0x1a, 0xf4, 0x49, 0xe4, 0x9d, 0x73, 0x0e, 0x79, 0xc9, 0x73, 0x2f, 0x05,
+	0x9d, 0xf1, 0xb6, 0x22, 0x53, 0xd7, 0x97, 0x61, 0xd7, 0x97, 0x8a, 0x76, 0x49, 0x14, 0x75, 0x23,
+	0x25, 0x27, 0xd3, 0x90, 0x88, 0xae, 0x2f, 0xc5, 0x31, 0x1b, 0xba, 0x91, 0x92, 0x5a, 0xa2, 0x2b,
+	0x19, 0x52, 0x51, 0x97, 0x44, 0x91, 0x9b, 0xa1, 0xb6, 0x6e, 0x9f, 0x92, 0xf0, 0x65, 0x18, 0x4a,
+	0xd1, 0x8d, 0xa9, 0x62, 0x84, 0x77, 0xf5, 0x34, 0xa2, 0x81, 0x17, 0xd2, 0x38, 0x26, 0x43, 0x9a,
+	0x48, 0x6d, 0xdd, 0x3c, 0x9f, 0x21, 0xa8, 0xee, 0x92, 0x20, 0x50, 0x34, 0x8e, 0x53, 0xe0, 0x8d,
+	0xd5, 0xc0, 0x48, 0x2a, 0x9d, 0xa2, 0xdc, 0x53, 0x28, 0xad, 0x88, 0x88, 0xcd, 0xf7, 0x2e, 0x13,
+	0x9a, 0x2a, 0x83, 0x5e, 0xcc, 0xa4, 0xcd, 0xa1, 0xbe, 0x27, 0x8e, 0xe4, 0x48, 0x04, 0xbb, 0x36,
+	0x8c, 0xee, 0x01, 0x90, 0x88, 0x79, 0x9c, 0xc5, 0x9a, 0x8a, 0x66, 0xae, 0x95, 0xeb, 0xd4, 0xb6,
+	0xaf, 0xbb, 0x0b, 0xf9, 0x26, 0xeb, 0xba, 0x82, 0x6a, 0x77, 0xaf, 0x77, 0xa8, 0x1e, 0xc8, 0x90,
+	0x30, 0x81, 0xab, 0x24, 0x62, 0xfb, 0x96, 0x83, 0xae, 0x42, 0xc5, 0x28, 0x98, 0x45, 0x9b, 0xf9,
+	0x56, 0xae, 0x53, 0xc7, 0x65, 0x12, 0xb1, 0x9e, 0x54, 0xba, 0xfd, 0x77, 0x01, 0xd0, 0x0e, 0xe7,
+	0xd2, 0x27, 0x9a, 0x49, 0xd1, 0xd7, 0x8a, 0x68, 0x3a, 0x9c, 0xa2, 0x07, 0x50, 0x34, 0x47, 0x63,
+	0x57, 0x6b, 0x6c, 0xdf, 0x76, 0x57, 0x9c, 0xae, 0x7b, 0x96, 0xea, 0x0e, 0xa6, 0x11, 0xc5, 0x96,
+	0x8d, 0x4e, 0xa0, 0xe6, 0x4b, 0xe1, 0x8f, 0x94, 0xa2, 0xc2, 0x9f, 0xda, 0xa5, 0x6b, 0xdb, 0x7b,
+	0x6f, 0x23, 0x76, 0x36, 0xb4, 0x3b, 0x17, 0xc4, 0x8b, 0xea, 0xc8, 0x83, 0xb2, 0xa2, 0xc7, 0x8a,
+	0xc6, 0x3f, 0x35, 0x0b, 0x76, 0xa1, 0x87, 0xef, 0xb6, 0x10, 0x4e, 0xc4, 0x70, 0xa6, 0x8a, 0xae,
+	0x41, 0xd5, 0x9c, 0xa0, 0x77, 0xcc, 0x38, 0x6d, 0x16, 0x5b, 0xb9, 0x4e, 0x15, 0x57, 0x4c, 0xe0,
+	0x11, 0xe3, 0x74, 0xeb, 0x0b, 0xf8, 0xe0, 0x95, 0x7b, 0x45, 0x17, 0x61, 0x7d, 0x4c, 0xf8, 0x28,
+	0x39, 0xd2, 0x3a, 0x4e, 0x26, 0x5b, 0x77, 0xe0, 0xea, 0xca, 0x95, 0xcf, 0xa7, 0xb4, 0x3f, 0x87,
+	0xa2, 0x39, 0x62, 0x04, 0x50, 0xda, 0xe1, 0x2f, 0xc8, 0x34, 0x76, 0xd6, 0xcc, 0x18, 0x13, 0x11,
+	0xc8, 0xd0, 0xc9, 0xa1, 0x0d, 0xa8, 0x3c, 0x9c, 0x18, 0x63, 0x11, 0xee, 0xe4, 0xdb, 0x7f, 0xad,
+	0x43, 0x03, 0x53, 0x9f, 0xb2, 0x31, 0x55, 0xa9, 0x9f, 0xbe, 0x05, 0xb0, 0x79, 0x28, 0x22, 0x86,
+	0x34, 0xf5, 0x53, 0x6b, 0x85, 0x9f, 0x8c, 0x47, 0xb0, 0xc1, 0xe1, 0x6a, 0x94, 0x0d, 0xd1, 0x57,
+	0x50, 0x4a, 0xcd, 0x98, 0x7f, 0x53, 0x33, 0xa6, 0x04, 0xf4, 0x23, 0xbc, 0x47, 0x66, 0xf9, 0x7a,
+	0x71, 0x9a, 0x70, 0x7a, 0x61, 0x9f, 0xbd, 0xc5, 0x85, 0x61, 0x44, 0xce, 0xba, 0x76, 0x00, 0x9b,
+	0xb1, 0x56, 0x94, 0x84, 0x5e, 0x4c, 0xb5, 0x66, 0x62, 0x18, 0x37, 0x8b, 0x67, 0x95, 0x67, 0x05,
+	0xe8, 0x66, 0x05, 0xe8, 0xf6, 0x2d, 0x2b, 0x39, 0x1f, 0xdc, 0x48, 0x34, 0xfa, 0xa9, 0x04, 0xba,
+	0x07, 0xef, 0xab, 0xe4, 0x04, 0x3d, 0xa9, 0xd8, 0x90, 0x09, 0xc2, 0xbd, 0x80, 0xc6, 0x9a, 0x09,
+	0xbb, 0x7a, 0x73, 0xbd, 0x95, 0xeb, 0x54, 0xf0, 0x56, 0x8a, 0x39, 0x4c, 0x21, 0x0f, 0xe6, 0x08,
+	0xd4, 0x83, 0xcd, 0xc0, 0x9e, 0x83, 0x27, 0xc7, 0x54, 0x29, 0x16, 0xd0, 0x66, 0xb9, 0x55, 0xe8,
+	0x34, 0xb6, 0x6f, 0xae, 0xcc, 0xf8, 0x07, 0x21, 0x5f, 0x88, 0x9e, 0x92, 0x5a, 0xfa, 0x92, 0xc7,
+	0xb8, 0x91, 0xf0, 0x0f, 0x53, 0x3a, 0xfa, 0x1a, 0xb6, 0x4e, 0x29, 0x7a, 0x4a, 0x8e, 0x34, 0xf5,
+	0xa4, 0xe0, 0xd3, 0x66, 0xc5, 0xee, 0xe8, 0xca, 0x32, 0x07, 0x9b, 0xef, 0x87, 0x82, 0x4f, 0xd1,
+	0x97, 0xd0, 0x3c, 0x4d, 0xa6, 0x13, 0x9f, 0x8f, 0x02, 0x1a, 0x34, 0xab, 0xad, 0x42, 0xa7, 0x8a,
+	0x2f, 0x2f, 0x53, 0x1f, 0xa6, 0x5f, 0x51, 0x07, 0x9c, 0x91, 0x60, 0x13, 0x2f, 0x96, 0xfe, 0x09,
+	0xd5, 0x5e, 0x28, 0x03, 0xda, 0x04, 0x6b, 0xce, 0x86, 0x89, 0xf7, 0x6d, 0xf8, 0x40, 0x06, 0x14,
+	0xf5, 0xc1, 0xf1, 0xa5, 0x10, 0xd4, 0xb7, 0x17, 0xcd, 0x59, 0xc8, 0x74, 0xb3, 0x66, 0xef, 0xa2,
+	0xb3, 0x32, 0xe7, 0xdd, 0x19, 0x61, 0xdf, 0xe0, 0xf1, 0xa6, 0xbf, 0x1c, 0xf8, 0xbe, 0x58, 0x29,
+	0x39, 0xe5, 0xf6, 0x3f, 0x39, 0xd8, 0x3c, 0x05, 0x45, 0x37, 0x61, 0x33, 0x24, 0x13, 0x6f, 0x4e,
+	0x88, 0xd3, 0xa2, 0x69, 0x84, 0x64, 0x32, 0x07, 0xc7, 0x08, 0x41, 0xd1, 0x98, 0x25, 0x6d, 0x83,
+	0x76, 0x8c, 0x3e, 0x84, 0x9a, 0xf9, 0xf5, 0x22, 0xaa, 0x3c, 0x16, 0x59, 0x33, 0xd6, 0x71, 0xd5,
+	0x84, 0x7a, 0x54, 0xed, 0x45, 0xe8, 0x3b, 0x28, 0x11, 0x4b, 0xb7, 0x6e, 0x6a, 0x6c, 0x77, 0xdf,
+	0x34, 0x03, 0x77, 0xc7, 0x8e, 0x71, 0x4a, 0x47, 0x1f, 0x43, 0xfd, 0x97, 0x11, 0x1d, 0x51, 0x4f,
+	0xb3, 0x90, 0xca, 0x91, 0xb6, 0xd6, 0xa9, 0xe3, 0x0d, 0x1b, 0x1c, 0x24, 0xb1, 0xf6, 0x47, 0x50,
+	0x4a, 0x68, 0xb6, 0xaa, 0xe9, 0xcf, 0xd4, 0xd7, 0xce, 0x1a, 0xaa, 0xc2, 0xfa, 0x13, 0x83, 0x72,
+	0x72, 0xed, 0x3f, 0x73, 0x70, 0x31, 0x7d, 0x21, 0x1e, 0x13, 0x11, 0xf0, 0x59, 0x61, 0x3b, 0x50,
+	0xd0, 0x64, 0x68, 0x13, 0xaf, 0x62, 0x33, 0x44, 0x7d, 0xb8, 0x90, 0xda, 0x52, 0xcd, 0x4b, 0x22,
+	0x29, 0xda, 0x4f, 0xcf, 0x29, 0xda, 0xe4, 0x51, 0xb4, 0x1d, 0x3c, 0x38, 0x48, 0xde, 0x44, 0xec,
+	0x64, 0x02, 0xb3, 0x7a, 0x38, 0x80, 0x86, 0x4d, 0x78, 0xae, 0x58, 0x78, 0x2b, 0xc5, 0xba, 0x65,
+	0x67, 0x72, 0x6d, 0x07, 0x1a, 0x87, 0x23, 0xbd, 0xf0, 0xe0, 0xb5, 0xff, 0x28, 0xc2, 0x46, 0x9f,
+	0x8a, 0x60, 0x96, 0xd8, 0x5d, 0x28, 0x8c, 0x19, 0x79, 0xf3, 0xa7, 0xcf, 0xa0, 0xcf, 0x6b, 0x06,
+	0xf9, 0x77, 0x6f, 0x06, 0x4f, 0x56, 0x24, 0x7f, 0xeb, 0x35, 0xa2, 0x3d, 0x43, 0x4a, 0x35, 0x97,
+	0x0f, 0x00, 0x3d, 0x07, 0x14, 0x8e, 0xb8, 0x66, 0x11, 0xa7, 0x93, 0x57, 0x36, 0xae, 0x25, 0xab,
+	0x1d, 0x64, 0x14, 0x26, 0x86, 0xa9, 0xee, 0x85, 0x99, 0xcc, 0x4c, 0xfb, 0x93, 0x6c, 0xbb, 0x51,
+	0xda, 0x4a, 0x52, 0xcb, 0x25, 0x5b, 0xc8, 0xfa, 0x8b, 0xf9, 0x83, 0x30, 0x66, 0xc4, 0x8b, 0xa4,
+	0xe4, 0xcd, 0x92, 0xed, 0x00, 0xe5, 0x31, 0x23, 0x3d, 0x29, 0x39, 0x1a, 0xc0, 0x86, 0xf9, 0x34,
+	0x6b, 0xd5, 0x65, 0x5b, 0x02, 0x77, 0x56, 0xee, 0x6b, 0xf1, 0xe2, 0xdc, 0x67, 0x8c, 0xcc, 0x1a,
+	0x76, 0x6d, 0x3c, 0x9f, 0xb4, 0x7b, 0x50, 0x5b, 0xf8, 0xb6, 0xf0, 0x7e, 0xad, 0xa1, 0x06, 0x00,
+	0x36, 0x66, 0xc0, 0xf2, 0x88, 0x09, 0x27, 0x87, 0x2e, 0xc1, 0x85, 0xbe, 0x66, 0xfe, 0xc9, 0x74,
+	0xa1, 0xa3, 0x3a, 0x79, 0x03, 0x4b, 0xc2, 0x4f, 0x63, 0xaa, 0x9c, 0x42, 0xfb, 0xb7, 0x3c, 0x5c,
+	0xca, 0x7c, 0xf4, 0xba, 0xb2, 0x38, 0x84, 0xcd, 0xd8, 0x6e, 0xf3, 0xbf, 0x16, 0x45, 0x23, 0xa1,
+	0xff, 0x4f, 0x25, 0x81, 0x2e, 0x43, 0x89, 0x4e, 0x22, 0xa6, 0x92, 0xbf, 0x19, 0x05, 0x9c, 0xce,
+	0x50, 0x13, 0xca, 0x46, 0x84, 0x8a, 0xa4, 0x73, 0x54, 0x71, 0x36, 0x45, 0xd7, 0x61, 0xe3, 0x98,
+	0x70, 0x7e, 0x44, 0xfc, 0x13, 0xcf, 0x24, 0x9b, 0x5c, 0x62, 0x2d, 0x8b, 0x0d, 0xc8, 0xb0, 0xfd,
+	0x6b, 0x1e, 0xd0, 0x59, 0xd3, 0x18, 0x4d, 0x2a, 0xc8, 0x11, 0xa7, 0x81, 0x3d, 0xa1, 0x0a, 0xce,
+	0xa6, 0xa8, 0x75, 0xf6, 0xdf, 0x5b, 0x7d, 0xf9, 0x2f, 0xd7, 0x35, 0xa8, 0x1e, 0x8f, 0x38, 0x37,
+	0x6d, 0x97, 0xda, 0x8c, 0x2b, 0xb8, 0x62, 0x02, 0xbb, 0x52, 0x50, 0xb3, 0x25, 0x16, 0xf0, 0x79,
+	0xaf, 0x2b, 0x26, 0x7c, 0x13, 0x4b, 0x5b, 0x9d, 0x81, 0x98, 0xae, 0xcd, 0xd9, 0x31, 0x35, 0xa8,
+	0xd4, 0x9b, 0xb5, 0x90, 0x4c, 0xf6, 0xd3, 0x50, 0x06, 0x89, 0x69, 0x1c, 0xdb, 0xae, 0x5e, 0x9a,
+	0x41, 0xfa, 0x69, 0xc8, 0x64, 0x10, 0x29, 0xfa, 0x82, 0xa8, 0xd0, 0x9a, 0xb3, 0x82, 0xb3, 0x29,
+	0xba, 0x01, 0xa6, 0xfd, 0x7b, 0xc7, 0x8a, 0x84, 0xd4, 0x8b, 0xd9, 0x4b, 0x6a, 0x5f, 0xc6, 0x3a,
+	0x36, 0x92, 0x8f, 0x4c, 0xb0, 0xcf, 0x5e, 0xd2, 0x5b, 0xdf, 0x40, 0x63, 0xf9, 0xb5, 0x45, 0x15,
+	0x28, 0x3e, 0x1e, 0x0c, 0x7a, 0xce, 0x1a, 0x2a, 0x43, 0x61, 0xb0, 0xdf, 0x77, 0x72, 0x26, 0xf4,
+	0xe4, 0xe9, 0xde, 0x6e, 0x62, 0xbc, 0xfb, 0x4c, 0x0f, 0xa4, 0x39, 0x03, 0xed, 0x14, 0xee, 0xef,
+	0xc2, 0x35, 0x5f, 0x86, 0xab, 0xea, 0xa1, 0x97, 0x7b, 0x5e, 0xc9, 0xc6, 0xbf, 0xe7, 0xaf, 0x3c,
+	0xdb, 0xc6, 0x64, 0xea, 0xee, 0x1a, 0xd4, 0x4e, 0x14, 0x25, 0x5d, 0x21, 0x24, 0xe2, 0xa8, 0x64,
+	0xeb, 0xf3, 0xee, 0xbf, 0x03, 0x00, 0x96, 0xbd, 0xf7, 0x8b, 0xf1, 0x0c, 0x00, 0x00,
 }
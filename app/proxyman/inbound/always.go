@@ -2,12 +2,14 @@ package inbound
 
 import (
 	"context"
+	"os"
 
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/app/proxyman/mux"
 	"v2ray.com/core/common/dice"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/internet"
 )
 
 type AlwaysOnInboundHandler struct {
@@ -29,10 +31,33 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 
 	nl := p.Network()
 	pr := receiverConfig.PortRange
+	limiter := proxyman.NewConnectionLimiter(receiverConfig.ConnectionLimit)
 	address := receiverConfig.Listen.AsAddress()
 	if address == nil {
 		address = net.AnyIP
 	}
+	if internet.IsUnixSocketAddress(address) {
+		if !nl.HasNetwork(net.Network_TCP) {
+			return nil, newError("Unix domain socket ", address, " is not supported by the inbound proxy")
+		}
+		// mKCP runs on UDP, which can't listen on a Unix domain socket.
+		if receiverConfig.StreamSettings.GetEffectiveProtocol() == internet.TransportProtocol_MKCP {
+			return nil, newError("Unix domain socket ", address, " is not supported by mKCP")
+		}
+		newError("creating stream worker on ", address).AtDebug().WriteToLog()
+		h.workers = append(h.workers, &tcpWorker{
+			address:      address,
+			proxy:        p,
+			stream:       receiverConfig.StreamSettings,
+			recvOrigDest: receiverConfig.ReceiveOriginalDestination,
+			tag:          tag,
+			dispatcher:   h.mux,
+			sniffers:     proxyman.NewDomainOverride(receiverConfig),
+			socketMode:   os.FileMode(receiverConfig.UnixSocketMode),
+			limiter:      limiter,
+		})
+		return h, nil
+	}
 	for port := pr.From; port <= pr.To; port++ {
 		if nl.HasNetwork(net.Network_TCP) {
 			newError("creating stream worker on ", address, ":", port).AtDebug().WriteToLog()
@@ -44,7 +69,8 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 				recvOrigDest: receiverConfig.ReceiveOriginalDestination,
 				tag:          tag,
 				dispatcher:   h.mux,
-				sniffers:     receiverConfig.DomainOverride,
+				sniffers:     proxyman.NewDomainOverride(receiverConfig),
+				limiter:      limiter,
 			}
 			h.workers = append(h.workers, worker)
 		}
@@ -57,6 +83,8 @@ func NewAlwaysOnInboundHandler(ctx context.Context, tag string, receiverConfig *
 				port:         net.Port(port),
 				recvOrigDest: receiverConfig.ReceiveOriginalDestination,
 				dispatcher:   h.mux,
+				sniffers:     proxyman.NewDomainOverride(receiverConfig),
+				stream:       receiverConfig.StreamSettings,
 			}
 			h.workers = append(h.workers, worker)
 		}
@@ -0,0 +1,40 @@
+package inbound_test
+
+import (
+	"context"
+	"testing"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/transport/internet"
+	. "v2ray.com/ext/assert"
+)
+
+func TestUnixSocketWithMKCP(t *testing.T) {
+	assert := With(t)
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.InboundConfig{}))
+
+	im := proxyman.InboundHandlerManagerFromSpace(space)
+	err := im.AddHandler(ctx, &proxyman.InboundHandlerConfig{
+		ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+			Listen: net.NewIPOrDomain(net.DomainAddress("/tmp/v2ray-mkcp.sock")),
+			StreamSettings: &internet.StreamConfig{
+				Protocol: internet.TransportProtocol_MKCP,
+			},
+		}),
+		ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+			Address:     net.NewIPOrDomain(net.LocalHostIP),
+			Port:        80,
+			NetworkList: &net.NetworkList{Network: []net.Network{net.Network_TCP}},
+		}),
+	})
+	assert(err, IsNotNil)
+}
@@ -0,0 +1,89 @@
+package inbound
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/net"
+)
+
+// maxPortListSize is the maximum size of a request body of the API.
+const maxPortListSize = 64 * 1024
+
+// apiServer is the HTTP API for external controllers to set the ports of inbounds with External allocation.
+type apiServer struct {
+	manager *Manager
+	server  *http.Server
+}
+
+func newAPIServer(manager *Manager, config *proxyman.InboundConfig) (*apiServer, error) {
+	address := net.LocalHostIP
+	if config.ApiListen != nil {
+		address = config.ApiListen.AsAddress()
+	}
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{
+		IP:   address.IP(),
+		Port: int(config.ApiPort),
+	})
+	if err != nil {
+		return nil, newError("failed to listen on API port ", config.ApiPort).Base(err)
+	}
+	newError("inbound API listening on ", listener.Addr()).WriteToLog()
+	s := &apiServer{
+		manager: manager,
+	}
+	s.server = &http.Server{
+		Handler: s,
+	}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler. The only request is PUT /inbounds/<tag>/ports.
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) != 3 || path[0] != "inbounds" || path[2] != "ports" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPut {
+		w.Header().Set("Allow", http.MethodPut)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	handler, found := s.manager.taggedHandlers[path[1]]
+	if !found {
+		http.Error(w, "inbound not found: "+path[1], http.StatusNotFound)
+		return
+	}
+	external, ok := handler.(*DynamicInboundHandler)
+	if !ok || !external.isExternal() {
+		http.Error(w, "ports of inbound "+path[1]+" are not allocated externally", http.StatusConflict)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPortListSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ports, err := parsePorts(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := external.SetPorts(ports); err != nil {
+		newError("failed to set ports through API").Base(err).AtWarning().WriteToLog()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Close closes the listener and all connections of the API.
+func (s *apiServer) Close() {
+	s.server.Close()
+}
@@ -10,6 +10,7 @@ import (
 	"v2ray.com/core/common/dice"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/internet"
 )
 
 type DynamicInboundHandler struct {
@@ -24,9 +25,14 @@ type DynamicInboundHandler struct {
 	worker         []worker
 	lastRefresh    time.Time
 	mux            *mux.Server
+	limiter        *proxyman.ConnectionLimiter
+	externalPorts  map[net.Port]*externalPort
 }
 
 func NewDynamicInboundHandler(ctx context.Context, tag string, receiverConfig *proxyman.ReceiverConfig, proxyConfig interface{}) (*DynamicInboundHandler, error) {
+	if internet.IsUnixSocketAddress(receiverConfig.Listen.AsAddress()) {
+		return nil, newError("Unix domain socket is not supported by dynamic port allocation")
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	h := &DynamicInboundHandler{
 		ctx:            ctx,
@@ -36,6 +42,8 @@ func NewDynamicInboundHandler(ctx context.Context, tag string, receiverConfig *p
 		receiverConfig: receiverConfig,
 		portsInUse:     make(map[net.Port]bool),
 		mux:            mux.NewServer(ctx),
+		limiter:        proxyman.NewConnectionLimiter(receiverConfig.ConnectionLimit),
+		externalPorts:  make(map[net.Port]*externalPort),
 	}
 
 	return h, nil
@@ -75,6 +83,61 @@ func (h *DynamicInboundHandler) waitAnyCloseWorkers(ctx context.Context, cancel
 	h.portMutex.Unlock()
 }
 
+// createWorkers creates and starts the workers on the given port. If any of them fails to start, the others are closed.
+func (h *DynamicInboundHandler) createWorkers(ctx context.Context, port net.Port) ([]worker, error) {
+	address := h.receiverConfig.Listen.AsAddress()
+	if address == nil {
+		address = net.AnyIP
+	}
+	p, err := proxy.CreateInboundHandler(ctx, h.proxyConfig)
+	if err != nil {
+		return nil, newError("failed to create proxy instance").Base(err)
+	}
+	var workers []worker
+	closeWorkers := func() {
+		for _, worker := range workers {
+			worker.Close()
+		}
+	}
+	nl := p.Network()
+	if nl.HasNetwork(net.Network_TCP) {
+		worker := &tcpWorker{
+			tag:          h.tag,
+			address:      address,
+			port:         port,
+			proxy:        p,
+			stream:       h.receiverConfig.StreamSettings,
+			recvOrigDest: h.receiverConfig.ReceiveOriginalDestination,
+			dispatcher:   h.mux,
+			sniffers:     proxyman.NewDomainOverride(h.receiverConfig),
+			limiter:      h.limiter,
+		}
+		if err := worker.Start(); err != nil {
+			return nil, newError("failed to create TCP worker on port ", port).Base(err)
+		}
+		workers = append(workers, worker)
+	}
+
+	if nl.HasNetwork(net.Network_UDP) {
+		worker := &udpWorker{
+			tag:          h.tag,
+			proxy:        p,
+			address:      address,
+			port:         port,
+			recvOrigDest: h.receiverConfig.ReceiveOriginalDestination,
+			dispatcher:   h.mux,
+			sniffers:     proxyman.NewDomainOverride(h.receiverConfig),
+			stream:       h.receiverConfig.StreamSettings,
+		}
+		if err := worker.Start(); err != nil {
+			closeWorkers()
+			return nil, newError("failed to create UDP worker on port ", port).Base(err)
+		}
+		workers = append(workers, worker)
+	}
+	return workers, nil
+}
+
 func (h *DynamicInboundHandler) refresh() error {
 	h.lastRefresh = time.Now()
 
@@ -83,51 +146,13 @@ func (h *DynamicInboundHandler) refresh() error {
 	ctx, cancel := context.WithTimeout(h.ctx, timeout)
 	workers := make([]worker, 0, concurrency)
 
-	address := h.receiverConfig.Listen.AsAddress()
-	if address == nil {
-		address = net.AnyIP
-	}
 	for i := uint32(0); i < concurrency; i++ {
-		port := h.allocatePort()
-		p, err := proxy.CreateInboundHandler(ctx, h.proxyConfig)
+		w, err := h.createWorkers(ctx, h.allocatePort())
 		if err != nil {
-			newError("failed to create proxy instance").Base(err).AtWarning().WriteToLog()
+			newError("failed to create workers").Base(err).AtWarning().WriteToLog()
 			continue
 		}
-		nl := p.Network()
-		if nl.HasNetwork(net.Network_TCP) {
-			worker := &tcpWorker{
-				tag:          h.tag,
-				address:      address,
-				port:         port,
-				proxy:        p,
-				stream:       h.receiverConfig.StreamSettings,
-				recvOrigDest: h.receiverConfig.ReceiveOriginalDestination,
-				dispatcher:   h.mux,
-				sniffers:     h.receiverConfig.DomainOverride,
-			}
-			if err := worker.Start(); err != nil {
-				newError("failed to create TCP worker").Base(err).AtWarning().WriteToLog()
-				continue
-			}
-			workers = append(workers, worker)
-		}
-
-		if nl.HasNetwork(net.Network_UDP) {
-			worker := &udpWorker{
-				tag:          h.tag,
-				proxy:        p,
-				address:      address,
-				port:         port,
-				recvOrigDest: h.receiverConfig.ReceiveOriginalDestination,
-				dispatcher:   h.mux,
-			}
-			if err := worker.Start(); err != nil {
-				newError("failed to create UDP worker").Base(err).AtWarning().WriteToLog()
-				continue
-			}
-			workers = append(workers, worker)
-		}
+		workers = append(workers, w...)
 	}
 
 	h.workerMutex.Lock()
@@ -154,6 +179,9 @@ func (h *DynamicInboundHandler) monitor() {
 }
 
 func (h *DynamicInboundHandler) Start() error {
+	if h.isExternal() {
+		return h.startExternal()
+	}
 	err := h.refresh()
 	go h.monitor()
 	return err
@@ -161,6 +189,9 @@ func (h *DynamicInboundHandler) Start() error {
 
 func (h *DynamicInboundHandler) Close() {
 	h.cancel()
+	if h.isExternal() {
+		h.SetPorts(nil)
+	}
 }
 
 func (h *DynamicInboundHandler) GetRandomInboundProxy() (proxy.Inbound, net.Port, int) {
@@ -171,6 +202,10 @@ func (h *DynamicInboundHandler) GetRandomInboundProxy() (proxy.Inbound, net.Port
 		return nil, 0, 0
 	}
 	w := h.worker[dice.Roll(len(h.worker))]
+	if h.isExternal() {
+		// External ports are expected to stay open for at least one refresh period.
+		return w.Proxy(), w.Port(), int(h.receiverConfig.AllocationStrategy.GetRefreshValue())
+	}
 	expire := h.receiverConfig.AllocationStrategy.GetRefreshValue() - uint32(time.Since(h.lastRefresh)/time.Minute)
 	return w.Proxy(), w.Port(), int(expire)
 }
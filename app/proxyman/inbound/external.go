@@ -0,0 +1,187 @@
+package inbound
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/net"
+)
+
+// portFileCheckInterval is the interval to check whether the port file is modified.
+const portFileCheckInterval = time.Second * 5
+
+// externalPort is a port opened by external controllers.
+type externalPort struct {
+	workers []worker
+	cancel  context.CancelFunc
+}
+
+func (p *externalPort) close() {
+	for _, worker := range p.workers {
+		worker.Close()
+	}
+	p.cancel()
+}
+
+func (h *DynamicInboundHandler) isExternal() bool {
+	return h.receiverConfig.AllocationStrategy.GetType() == proxyman.AllocationStrategy_External
+}
+
+// SetPorts implements proxyman.ExternalInboundHandler.
+func (h *DynamicInboundHandler) SetPorts(ports []net.Port) error {
+	if !h.isExternal() {
+		return newError("ports of inbound ", h.tag, " are not allocated externally")
+	}
+	if pr := h.receiverConfig.PortRange; pr != nil {
+		for _, port := range ports {
+			if !pr.Contains(port) {
+				return newError("port ", port, " is out of range ", pr.FromPort(), "-", pr.ToPort())
+			}
+		}
+	}
+
+	h.portMutex.Lock()
+	defer h.portMutex.Unlock()
+
+	select {
+	case <-h.ctx.Done():
+		if len(ports) > 0 {
+			return newError("inbound ", h.tag, " is closed")
+		}
+	default:
+	}
+
+	wanted := make(map[net.Port]bool, len(ports))
+	for _, port := range ports {
+		wanted[port] = true
+	}
+	for port, p := range h.externalPorts {
+		if !wanted[port] {
+			newError("closing port ", port, " of inbound ", h.tag).AtDebug().WriteToLog()
+			p.close()
+			delete(h.externalPorts, port)
+		}
+	}
+	// Ports that fail to open are not recorded, so that they are opened again by the next call.
+	var failures []string
+	for port := range wanted {
+		if _, found := h.externalPorts[port]; found {
+			continue
+		}
+		newError("opening port ", port, " of inbound ", h.tag).AtDebug().WriteToLog()
+		ctx, cancel := context.WithCancel(h.ctx)
+		workers, err := h.createWorkers(ctx, port)
+		if err != nil {
+			cancel()
+			failures = append(failures, err.Error())
+			continue
+		}
+		h.externalPorts[port] = &externalPort{
+			workers: workers,
+			cancel:  cancel,
+		}
+	}
+
+	workers := make([]worker, 0, len(h.externalPorts))
+	for _, p := range h.externalPorts {
+		workers = append(workers, p.workers...)
+	}
+	h.workerMutex.Lock()
+	h.worker = workers
+	h.lastRefresh = time.Now()
+	h.workerMutex.Unlock()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return newError("failed to open ports of inbound ", h.tag, ": ", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// parsePorts parses ports separated by whitespaces or commas. Port ranges such as "10000-10010" are allowed.
+func parsePorts(s string) ([]net.Port, error) {
+	var ports []net.Port
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	}) {
+		from, to := field, field
+		if idx := strings.Index(field, "-"); idx > 0 {
+			from, to = field[:idx], field[idx+1:]
+		}
+		fromPort, err := strconv.ParseUint(from, 10, 16)
+		if err != nil {
+			return nil, newError("invalid port: ", field).Base(err)
+		}
+		toPort, err := strconv.ParseUint(to, 10, 16)
+		if err != nil {
+			return nil, newError("invalid port: ", field).Base(err)
+		}
+		if fromPort == 0 || fromPort > toPort {
+			return nil, newError("invalid port: ", field)
+		}
+		for port := fromPort; port <= toPort; port++ {
+			ports = append(ports, net.Port(port))
+		}
+	}
+	return ports, nil
+}
+
+func (h *DynamicInboundHandler) loadPortFile(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return newError("failed to read port file ", path).Base(err)
+	}
+	ports, err := parsePorts(string(content))
+	if err != nil {
+		return newError("invalid port file ", path).Base(err)
+	}
+	return h.SetPorts(ports)
+}
+
+// watchPortFile reloads the port file when it is modified.
+func (h *DynamicInboundHandler) watchPortFile(path string, modTime time.Time) {
+	ticker := time.NewTicker(portFileCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			newError("failed to check port file ", path).Base(err).AtWarning().WriteToLog()
+			continue
+		}
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = info.ModTime()
+		if err := h.loadPortFile(path); err != nil {
+			newError("failed to reload port file").Base(err).AtWarning().WriteToLog()
+		}
+	}
+}
+
+func (h *DynamicInboundHandler) startExternal() error {
+	path := h.receiverConfig.AllocationStrategy.GetPortFile()
+	if len(path) == 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return newError("failed to read port file ", path).Base(err)
+	}
+	if err := h.loadPortFile(path); err != nil {
+		return err
+	}
+	go h.watchPortFile(path, info.ModTime())
+	return nil
+}
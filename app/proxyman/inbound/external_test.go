@@ -0,0 +1,261 @@
+package inbound_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	_ "v2ray.com/core/app/dispatcher/impl"
+	"v2ray.com/core/app/policy"
+	_ "v2ray.com/core/app/policy/manager"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/inbound"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	_ "v2ray.com/core/transport/internet/tcp"
+	. "v2ray.com/ext/assert"
+)
+
+func canDial(port net.Port) bool {
+	conn, err := net.DialTCP("tcp", nil, &net.TCPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func TestExternalInboundHandler(t *testing.T) {
+	assert := With(t)
+
+	port1 := tcp.PickPort()
+	port2 := tcp.PickPort()
+
+	portFile, err := ioutil.TempFile("", "v2ray")
+	assert(err, IsNil)
+	defer os.Remove(portFile.Name())
+	_, err = portFile.WriteString(port1.String() + "\n")
+	assert(err, IsNil)
+	assert(portFile.Close(), IsNil)
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.InboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	im := proxyman.InboundHandlerManagerFromSpace(space)
+	common.Must(im.AddHandler(ctx, &proxyman.InboundHandlerConfig{
+		Tag: "external",
+		ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+			Listen: net.NewIPOrDomain(net.LocalHostIP),
+			AllocationStrategy: &proxyman.AllocationStrategy{
+				Type:     proxyman.AllocationStrategy_External,
+				PortFile: portFile.Name(),
+			},
+		}),
+		ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+			Address:     net.NewIPOrDomain(net.LocalHostIP),
+			Port:        80,
+			NetworkList: &net.NetworkList{Network: []net.Network{net.Network_TCP}},
+		}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	defer space.Close()
+
+	assert(canDial(port1), IsTrue)
+	assert(canDial(port2), IsFalse)
+
+	handler, err := im.GetHandler(ctx, "external")
+	assert(err, IsNil)
+	_, port, _ := handler.GetRandomInboundProxy()
+	assert(port, Equals, port1)
+
+	external := handler.(proxyman.ExternalInboundHandler)
+	assert(external.SetPorts([]net.Port{port2}), IsNil)
+	assert(canDial(port1), IsFalse)
+	assert(canDial(port2), IsTrue)
+	_, port, _ = handler.GetRandomInboundProxy()
+	assert(port, Equals, port2)
+
+	assert(external.SetPorts(nil), IsNil)
+	assert(canDial(port2), IsFalse)
+	proxy, _, _ := handler.GetRandomInboundProxy()
+	assert(proxy, IsNil)
+}
+
+func TestExternalInboundHandlerPortInUse(t *testing.T) {
+	assert := With(t)
+
+	port1 := tcp.PickPort()
+	busy, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.LocalHostIP.IP()})
+	assert(err, IsNil)
+	port2 := net.Port(busy.Addr().(*net.TCPAddr).Port)
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.InboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	im := proxyman.InboundHandlerManagerFromSpace(space)
+	common.Must(im.AddHandler(ctx, &proxyman.InboundHandlerConfig{
+		Tag: "external",
+		ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+			Listen: net.NewIPOrDomain(net.LocalHostIP),
+			AllocationStrategy: &proxyman.AllocationStrategy{
+				Type: proxyman.AllocationStrategy_External,
+			},
+		}),
+		ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+			Address:     net.NewIPOrDomain(net.LocalHostIP),
+			Port:        80,
+			NetworkList: &net.NetworkList{Network: []net.Network{net.Network_TCP}},
+		}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	defer space.Close()
+
+	handler, err := im.GetHandler(ctx, "external")
+	assert(err, IsNil)
+	external := handler.(proxyman.ExternalInboundHandler)
+
+	// Only the port that opens is used.
+	assert(external.SetPorts([]net.Port{port1, port2}), IsNotNil)
+	assert(canDial(port1), IsTrue)
+	for i := 0; i < 10; i++ {
+		_, port, _ := handler.GetRandomInboundProxy()
+		assert(port, Equals, port1)
+	}
+
+	// The failed port is opened again once it is free.
+	assert(busy.Close(), IsNil)
+	assert(external.SetPorts([]net.Port{port1, port2}), IsNil)
+	assert(canDial(port2), IsTrue)
+}
+
+// putPorts sets the ports of the inbound through the API, and returns the status code.
+func putPorts(apiPort net.Port, tag string, ports string) (int, error) {
+	url := "http://" + net.TCPDestination(net.LocalHostIP, apiPort).NetAddr() + "/inbounds/" + tag + "/ports"
+	request, err := http.NewRequest(http.MethodPut, url, strings.NewReader(ports))
+	if err != nil {
+		return 0, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	response.Body.Close()
+	return response.StatusCode, nil
+}
+
+func TestExternalInboundHandlerAPI(t *testing.T) {
+	assert := With(t)
+
+	port1 := tcp.PickPort()
+	port2 := tcp.PickPort()
+	apiPort := tcp.PickPort()
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.InboundConfig{
+		ApiPort: uint32(apiPort),
+	}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	im := proxyman.InboundHandlerManagerFromSpace(space)
+	for _, strategy := range []proxyman.AllocationStrategy_Type{proxyman.AllocationStrategy_External, proxyman.AllocationStrategy_Always} {
+		var portRange *net.PortRange
+		if strategy == proxyman.AllocationStrategy_Always {
+			portRange = net.SinglePortRange(tcp.PickPort())
+		}
+		common.Must(im.AddHandler(ctx, &proxyman.InboundHandlerConfig{
+			Tag: strings.ToLower(strategy.String()),
+			ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+				Listen:    net.NewIPOrDomain(net.LocalHostIP),
+				PortRange: portRange,
+				AllocationStrategy: &proxyman.AllocationStrategy{
+					Type: strategy,
+				},
+			}),
+			ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+				Address:     net.NewIPOrDomain(net.LocalHostIP),
+				Port:        80,
+				NetworkList: &net.NetworkList{Network: []net.Network{net.Network_TCP}},
+			}),
+		}))
+	}
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+
+	status, err := putPorts(apiPort, "external", port1.String()+","+port2.String())
+	assert(err, IsNil)
+	assert(status, Equals, http.StatusNoContent)
+	assert(canDial(port1), IsTrue)
+	assert(canDial(port2), IsTrue)
+
+	status, err = putPorts(apiPort, "external", port2.String())
+	assert(err, IsNil)
+	assert(status, Equals, http.StatusNoContent)
+	assert(canDial(port1), IsFalse)
+	assert(canDial(port2), IsTrue)
+
+	status, err = putPorts(apiPort, "external", "not a port")
+	assert(err, IsNil)
+	assert(status, Equals, http.StatusBadRequest)
+	assert(canDial(port2), IsTrue)
+
+	status, err = putPorts(apiPort, "always", port1.String())
+	assert(err, IsNil)
+	assert(status, Equals, http.StatusConflict)
+
+	status, err = putPorts(apiPort, "unknown", port1.String())
+	assert(err, IsNil)
+	assert(status, Equals, http.StatusNotFound)
+
+	// The API closes with the inbounds.
+	space.Close()
+	_, err = putPorts(apiPort, "external", port1.String())
+	assert(err, IsNotNil)
+	assert(canDial(port2), IsFalse)
+}
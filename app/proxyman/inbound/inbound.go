@@ -11,12 +11,15 @@ import (
 
 // Manager is to manage all inbound handlers.
 type Manager struct {
+	config         *proxyman.InboundConfig
 	handlers       []proxyman.InboundHandler
 	taggedHandlers map[string]proxyman.InboundHandler
+	api            *apiServer
 }
 
 func New(ctx context.Context, config *proxyman.InboundConfig) (*Manager, error) {
 	return &Manager{
+		config:         config,
 		taggedHandlers: make(map[string]proxyman.InboundHandler),
 	}, nil
 }
@@ -43,7 +46,7 @@ func (m *Manager) AddHandler(ctx context.Context, config *proxyman.InboundHandle
 			return err
 		}
 		handler = h
-	} else if allocStrategy.Type == proxyman.AllocationStrategy_Random {
+	} else if allocStrategy.Type == proxyman.AllocationStrategy_Random || allocStrategy.Type == proxyman.AllocationStrategy_External {
 		h, err := NewDynamicInboundHandler(ctx, tag, receiverSettings, proxySettings)
 		if err != nil {
 			return err
@@ -76,10 +79,21 @@ func (m *Manager) Start() error {
 			return err
 		}
 	}
+	if m.config.GetApiPort() > 0 {
+		api, err := newAPIServer(m, m.config)
+		if err != nil {
+			return err
+		}
+		m.api = api
+	}
 	return nil
 }
 
 func (m *Manager) Close() {
+	if m.api != nil {
+		m.api.Close()
+		m.api = nil
+	}
 	for _, handler := range m.handlers {
 		handler.Close()
 	}
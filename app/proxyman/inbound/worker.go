@@ -3,6 +3,7 @@ package inbound
 import (
 	"context"
 	"io"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"v2ray.com/core/app/dispatcher"
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/log"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/internet"
@@ -32,14 +34,47 @@ type tcpWorker struct {
 	recvOrigDest bool
 	tag          string
 	dispatcher   dispatcher.Interface
-	sniffers     []proxyman.KnownProtocols
+	sniffers     *proxyman.DomainOverride
+	socketMode   os.FileMode
+	limiter      *proxyman.ConnectionLimiter
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	hub     internet.Listener
+	hubOnce sync.Once
+}
 
-	ctx    context.Context
-	cancel context.CancelFunc
-	hub    internet.Listener
+// reject closes a connection refused by the connection limits. The connection is closed before its remote address
+// is read, so that the PROXY protocol header is not waited for.
+func (w *tcpWorker) reject(conn internet.Connection, err error) {
+	conn.Close()
+	source := conn.RemoteAddr()
+	log.Record(&log.AccessMessage{
+		From:   source,
+		To:     "",
+		Status: log.AccessRejected,
+		Reason: err,
+	})
+	newError("connection from ", source, " rejected").Base(err).AtInfo().WriteToLog()
 }
 
 func (w *tcpWorker) callback(conn internet.Connection) {
+	// Reading the remote address may wait for the PROXY protocol header. The connection is counted before that, so
+	// that clients can't bypass the limits by not sending the header. The rate limit per IP applies to the source
+	// in the header.
+	release, err := w.limiter.Acquire(w.ctx, nil)
+	if err != nil {
+		w.reject(conn, err)
+		return
+	}
+	defer release()
+
+	source := net.DestinationFromAddr(conn.RemoteAddr())
+	if err := w.limiter.AcquireSource(w.ctx, source.Address); err != nil {
+		w.reject(conn, err)
+		return
+	}
+
 	ctx, cancel := context.WithCancel(w.ctx)
 	if w.recvOrigDest {
 		dest, err := tcp.GetOriginalDestination(conn)
@@ -54,9 +89,9 @@ func (w *tcpWorker) callback(conn internet.Connection) {
 		ctx = proxy.ContextWithInboundTag(ctx, w.tag)
 	}
 	ctx = proxy.ContextWithInboundEntryPoint(ctx, net.TCPDestination(w.address, w.port))
-	ctx = proxy.ContextWithSource(ctx, net.DestinationFromAddr(conn.RemoteAddr()))
-	if len(w.sniffers) > 0 {
-		ctx = proxyman.ContextWithProtocolSniffers(ctx, w.sniffers)
+	ctx = proxy.ContextWithSource(ctx, source)
+	if w.sniffers != nil {
+		ctx = proxyman.ContextWithDomainOverride(ctx, w.sniffers)
 	}
 	if err := w.proxy.Process(ctx, net.Network_TCP, conn, w.dispatcher); err != nil {
 		newError("connection ends").Base(err).WriteToLog()
@@ -74,21 +109,29 @@ func (w *tcpWorker) Start() error {
 	w.ctx = ctx
 	w.cancel = cancel
 	ctx = internet.ContextWithStreamSettings(ctx, w.stream)
+	ctx = internet.ContextWithUnixSocketMode(ctx, w.socketMode)
 	conns := make(chan internet.Connection, 16)
 	hub, err := internet.ListenTCP(ctx, w.address, w.port, conns)
 	if err != nil {
 		return newError("failed to listen TCP on ", w.port).AtWarning().Base(err)
 	}
-	go w.handleConnections(conns)
 	w.hub = hub
+	go w.handleConnections(conns)
 	return nil
 }
 
+// closeHub closes the listener. It may be called more than once.
+func (w *tcpWorker) closeHub() {
+	w.hubOnce.Do(func() {
+		w.hub.Close()
+	})
+}
+
 func (w *tcpWorker) handleConnections(conns <-chan internet.Connection) {
 	for {
 		select {
 		case <-w.ctx.Done():
-			w.hub.Close()
+			w.closeHub()
 		L:
 			for {
 				select {
@@ -108,6 +151,8 @@ func (w *tcpWorker) handleConnections(conns <-chan internet.Connection) {
 func (w *tcpWorker) Close() {
 	if w.hub != nil {
 		w.cancel()
+		// Close the listener right away, so that the port is released when Close returns.
+		w.closeHub()
 	}
 }
 
@@ -186,6 +231,8 @@ type udpWorker struct {
 	recvOrigDest bool
 	tag          string
 	dispatcher   dispatcher.Interface
+	sniffers     *proxyman.DomainOverride
+	stream       *internet.StreamConfig
 
 	ctx        context.Context
 	cancel     context.CancelFunc
@@ -245,6 +292,9 @@ func (w *udpWorker) callback(b *buf.Buffer, source net.Destination, originalDest
 			}
 			ctx = proxy.ContextWithSource(ctx, source)
 			ctx = proxy.ContextWithInboundEntryPoint(ctx, net.UDPDestination(w.address, w.port))
+			if w.sniffers != nil {
+				ctx = proxyman.ContextWithDomainOverride(ctx, w.sniffers)
+			}
 			if err := w.proxy.Process(ctx, net.Network_UDP, conn, w.dispatcher); err != nil {
 				newError("connection ends").Base(err).WriteToLog()
 			}
@@ -265,7 +315,7 @@ func (w *udpWorker) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	w.ctx = ctx
 	w.cancel = cancel
-	h, err := udp.ListenUDP(w.address, w.port, udp.ListenOption{
+	h, err := udp.ListenUDP(internet.ContextWithStreamSettings(ctx, w.stream), w.address, w.port, udp.ListenOption{
 		Callback:            w.callback,
 		ReceiveOriginalDest: w.recvOrigDest,
 	})
@@ -0,0 +1,152 @@
+package inbound_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	_ "v2ray.com/core/app/dispatcher/impl"
+	"v2ray.com/core/app/policy"
+	_ "v2ray.com/core/app/policy/manager"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/inbound"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/proxyproto"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/dokodemo"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/transport/internet"
+	tcptransport "v2ray.com/core/transport/internet/tcp"
+	. "v2ray.com/ext/assert"
+)
+
+// dialWithProxyHeader connects to the port, and sends a PROXY protocol header with the given source.
+func dialWithProxyHeader(port net.Port, source net.Destination) (net.Conn, error) {
+	conn, err := net.DialTCP("tcp", nil, &net.TCPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := proxyproto.WriteHeader(conn, &proxyproto.Header{
+		Version:     2,
+		Source:      source,
+		Destination: net.TCPDestination(net.LocalHostIP, port),
+	}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// echo sends a message through the connection, and returns the response.
+func echo(conn net.Conn) (string, error) {
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		return "", err
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func TestMaxConnectionsWithoutProxyHeader(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.InboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	// Connections end as soon as clients close them, so that the slot is released.
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {
+				Timeout: &policy.Policy_Timeout{
+					DownlinkOnly: &policy.Second{Value: 0},
+				},
+			},
+		},
+	}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	port := tcp.PickPort()
+	im := proxyman.InboundHandlerManagerFromSpace(space)
+	common.Must(im.AddHandler(ctx, &proxyman.InboundHandlerConfig{
+		ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+			Listen:    net.NewIPOrDomain(net.LocalHostIP),
+			PortRange: net.SinglePortRange(port),
+			StreamSettings: &internet.StreamConfig{
+				Protocol: internet.TransportProtocol_TCP,
+				TransportSettings: []*internet.TransportConfig{
+					{
+						Protocol: internet.TransportProtocol_TCP,
+						Settings: serial.ToTypedMessage(&tcptransport.Config{
+							AcceptProxyProtocol: true,
+						}),
+					},
+				},
+			},
+			ConnectionLimit: &proxyman.ConnectionLimit{
+				MaxConnections: 1,
+			},
+		}),
+		ProxySettings: serial.ToTypedMessage(&dokodemo.Config{
+			Address:     net.NewIPOrDomain(dest.Address),
+			Port:        uint32(dest.Port),
+			NetworkList: &net.NetworkList{Network: []net.Network{net.Network_TCP}},
+		}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	defer space.Close()
+
+	// The client never sends the PROXY protocol header, but still takes the only connection.
+	silent, err := net.DialTCP("tcp", nil, &net.TCPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	assert(err, IsNil)
+	time.Sleep(time.Millisecond * 100)
+
+	conn, err := dialWithProxyHeader(port, net.TCPDestination(net.ParseAddress("1.2.3.4"), 1234))
+	assert(err, IsNil)
+	_, err = echo(conn)
+	assert(err, IsNotNil)
+	conn.Close()
+
+	// The header can't be read after the client goes away, so the connection is released.
+	silent.Close()
+	time.Sleep(time.Millisecond * 100)
+
+	conn, err = dialWithProxyHeader(port, net.TCPDestination(net.ParseAddress("1.2.3.4"), 1234))
+	assert(err, IsNil)
+	defer conn.Close()
+	response, err := echo(conn)
+	assert(err, IsNil)
+	assert(response, Equals, "ping")
+}
@@ -0,0 +1,212 @@
+package proxyman
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/signal"
+)
+
+const (
+	DefaultQueueTimeout = time.Second
+
+	// bucketCleanupInterval is the interval to remove idle per IP buckets.
+	bucketCleanupInterval = time.Minute
+)
+
+// tokenBucket allows a number of events per second, with bursts up to the same number.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate uint32, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+}
+
+// reserve takes a token from the bucket. It returns the time to wait until the token is available, or false if the
+// time is longer than maxWait, in which case no token is taken.
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	b.refill(now)
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		if wait > maxWait {
+			return 0, false
+		}
+	}
+	b.tokens--
+	return wait, true
+}
+
+// isFull returns true if the bucket is in the same state as a new one.
+func (b *tokenBucket) isFull(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.rate
+}
+
+// ConnectionLimiter decides whether new connections of an inbound handler are allowed.
+type ConnectionLimiter struct {
+	sync.Mutex
+	config      *ConnectionLimit
+	queue       time.Duration
+	connections *signal.Semaphore
+	bucket      *tokenBucket
+	ipBuckets   map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+// NewConnectionLimiter creates a new ConnectionLimiter. It returns nil if there is no limit in the config.
+func NewConnectionLimiter(config *ConnectionLimit) *ConnectionLimiter {
+	if config == nil || (config.MaxConnections == 0 && config.Rate == 0 && config.RatePerIp == 0) {
+		return nil
+	}
+	now := time.Now()
+	l := &ConnectionLimiter{
+		config:      config,
+		ipBuckets:   make(map[string]*tokenBucket),
+		lastCleanup: now,
+	}
+	if config.Action == ConnectionLimit_Queue {
+		l.queue = DefaultQueueTimeout
+		if config.QueueTimeout > 0 {
+			l.queue = time.Millisecond * time.Duration(config.QueueTimeout)
+		}
+	}
+	if config.MaxConnections > 0 {
+		l.connections = signal.NewSemaphore(int(config.MaxConnections))
+	}
+	if config.Rate > 0 {
+		l.bucket = newTokenBucket(config.Rate, now)
+	}
+	return l
+}
+
+// reserve takes tokens for a new connection from the source, and returns the time to wait before accepting it. The
+// rate limit of the inbound handler is skipped if global is false.
+func (l *ConnectionLimiter) reserve(source net.Address, global bool) (time.Duration, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	var ipBucket *tokenBucket
+	if l.config.RatePerIp > 0 && source != nil {
+		if now.Sub(l.lastCleanup) > bucketCleanupInterval {
+			for ip, bucket := range l.ipBuckets {
+				if bucket.isFull(now) {
+					delete(l.ipBuckets, ip)
+				}
+			}
+			l.lastCleanup = now
+		}
+		ip := source.String()
+		ipBucket = l.ipBuckets[ip]
+		if ipBucket == nil {
+			ipBucket = newTokenBucket(l.config.RatePerIp, now)
+			l.ipBuckets[ip] = ipBucket
+		}
+	}
+
+	var wait time.Duration
+	if ipBucket != nil {
+		w, ok := ipBucket.reserve(now, l.queue)
+		if !ok {
+			return 0, newError("too many new connections from ", source)
+		}
+		wait = w
+	}
+	if l.bucket != nil && global {
+		w, ok := l.bucket.reserve(now, l.queue)
+		if !ok {
+			if ipBucket != nil {
+				// Return the token taken above.
+				ipBucket.tokens++
+			}
+			return 0, newError("too many new connections")
+		}
+		if w > wait {
+			wait = w
+		}
+	}
+	return wait, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// Acquire waits until a new connection from the source is allowed. It returns a function to be called when the
+// connection ends, or an error if the connection should be rejected. The rate limit per IP is skipped if source is
+// nil.
+func (l *ConnectionLimiter) Acquire(ctx context.Context, source net.Address) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	deadline := time.Now().Add(l.queue)
+	wait, err := l.reserve(source, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, wait); err != nil {
+		return nil, err
+	}
+
+	if l.connections == nil {
+		return func() {}, nil
+	}
+	select {
+	case <-l.connections.Wait():
+		return l.connections.Signal, nil
+	default:
+	}
+	if l.queue == 0 {
+		return nil, newError("too many connections")
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.connections.Wait():
+		return l.connections.Signal, nil
+	case <-timer.C:
+		return nil, newError("too many connections")
+	}
+}
+
+// AcquireSource waits until a new connection from the source is allowed by the rate limit per IP. It is for
+// connections whose source is only known after Acquire, such as those with PROXY protocol headers.
+func (l *ConnectionLimiter) AcquireSource(ctx context.Context, source net.Address) error {
+	if l == nil || l.config.RatePerIp == 0 {
+		return nil
+	}
+	wait, err := l.reserve(source, false)
+	if err != nil {
+		return err
+	}
+	return sleep(ctx, wait)
+}
@@ -0,0 +1,137 @@
+package proxyman_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/net"
+	. "v2ray.com/ext/assert"
+)
+
+func TestConnectionLimiterMaxConnections(t *testing.T) {
+	assert := With(t)
+
+	assert(NewConnectionLimiter(&ConnectionLimit{}), IsNil)
+
+	limiter := NewConnectionLimiter(&ConnectionLimit{
+		MaxConnections: 2,
+	})
+	source := net.ParseAddress("1.2.3.4")
+
+	release1, err := limiter.Acquire(context.Background(), source)
+	assert(err, IsNil)
+	release2, err := limiter.Acquire(context.Background(), source)
+	assert(err, IsNil)
+	_, err = limiter.Acquire(context.Background(), source)
+	assert(err, IsNotNil)
+
+	release1()
+	release3, err := limiter.Acquire(context.Background(), source)
+	assert(err, IsNil)
+	release2()
+	release3()
+}
+
+func TestConnectionLimiterQueue(t *testing.T) {
+	assert := With(t)
+
+	limiter := NewConnectionLimiter(&ConnectionLimit{
+		MaxConnections: 1,
+		Action:         ConnectionLimit_Queue,
+		QueueTimeout:   500,
+	})
+	source := net.ParseAddress("1.2.3.4")
+
+	release, err := limiter.Acquire(context.Background(), source)
+	assert(err, IsNil)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		release()
+	}()
+
+	start := time.Now()
+	release, err = limiter.Acquire(context.Background(), source)
+	assert(err, IsNil)
+	assert(time.Since(start) >= 100*time.Millisecond, IsTrue)
+
+	_, err = limiter.Acquire(context.Background(), source)
+	assert(err, IsNotNil)
+	assert(time.Since(start) >= 500*time.Millisecond, IsTrue)
+	release()
+}
+
+func TestConnectionLimiterRatePerIP(t *testing.T) {
+	assert := With(t)
+
+	limiter := NewConnectionLimiter(&ConnectionLimit{
+		Rate:      4,
+		RatePerIp: 2,
+	})
+	source1 := net.ParseAddress("1.2.3.4")
+	source2 := net.ParseAddress("5.6.7.8")
+
+	for i := 0; i < 2; i++ {
+		_, err := limiter.Acquire(context.Background(), source1)
+		assert(err, IsNil)
+	}
+	_, err := limiter.Acquire(context.Background(), source1)
+	assert(err, IsNotNil)
+
+	for i := 0; i < 2; i++ {
+		_, err := limiter.Acquire(context.Background(), source2)
+		assert(err, IsNil)
+	}
+	// The global rate is used up.
+	_, err = limiter.Acquire(context.Background(), net.ParseAddress("9.9.9.9"))
+	assert(err, IsNotNil)
+
+	time.Sleep(600 * time.Millisecond)
+	_, err = limiter.Acquire(context.Background(), source1)
+	assert(err, IsNil)
+}
+
+func TestConnectionLimiterRateQueue(t *testing.T) {
+	assert := With(t)
+
+	limiter := NewConnectionLimiter(&ConnectionLimit{
+		Rate:   10,
+		Action: ConnectionLimit_Queue,
+	})
+	source := net.ParseAddress("1.2.3.4")
+
+	for i := 0; i < 10; i++ {
+		_, err := limiter.Acquire(context.Background(), source)
+		assert(err, IsNil)
+	}
+	start := time.Now()
+	_, err := limiter.Acquire(context.Background(), source)
+	assert(err, IsNil)
+	assert(time.Since(start) >= 50*time.Millisecond, IsTrue)
+}
+
+func TestConnectionLimiterAcquireSource(t *testing.T) {
+	assert := With(t)
+
+	limiter := NewConnectionLimiter(&ConnectionLimit{
+		MaxConnections: 2,
+		Rate:           2,
+		RatePerIp:      1,
+	})
+	source1 := net.ParseAddress("1.2.3.4")
+	source2 := net.ParseAddress("5.6.7.8")
+
+	// Connections are counted before their sources are known.
+	_, err := limiter.Acquire(context.Background(), nil)
+	assert(err, IsNil)
+	assert(limiter.AcquireSource(context.Background(), source1), IsNil)
+	assert(limiter.AcquireSource(context.Background(), source1), IsNotNil)
+
+	// The global rate is not taken again by AcquireSource.
+	assert(limiter.AcquireSource(context.Background(), source2), IsNil)
+	_, err = limiter.Acquire(context.Background(), nil)
+	assert(err, IsNil)
+	_, err = limiter.Acquire(context.Background(), nil)
+	assert(err, IsNotNil)
+}
@@ -0,0 +1,219 @@
+package mux
+
+import (
+	"io"
+	"sync"
+
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/transport/ray"
+)
+
+const (
+	// initialWindow is the number of bytes a session may send before receiving any window update from the peer.
+	initialWindow = 1024 * 1024
+
+	// windowUpdateThreshold is the number of delivered bytes before a window update is sent to the peer. As it is
+	// smaller than initialWindow, the peer is never blocked when all received data is delivered.
+	windowUpdateThreshold = initialWindow / 2
+)
+
+// sendWindow is the credit of a session for sending data to the peer. Bytes are counted since the beginning of
+// the session, but the window only blocks after the peer confirms that it supports flow control.
+type sendWindow struct {
+	sync.Mutex
+	credit  int64
+	enabled bool
+	closed  bool
+	update  *signal.Notifier
+}
+
+func newSendWindow() *sendWindow {
+	return &sendWindow{
+		credit: initialWindow,
+		update: signal.NewNotifier(),
+	}
+}
+
+// Grant enables flow control of the window, and adds credits from a window update of the peer.
+func (w *sendWindow) Grant(n uint32) {
+	if w == nil {
+		return
+	}
+	w.Lock()
+	w.enabled = true
+	w.credit += int64(n)
+	w.Unlock()
+	w.update.Signal()
+}
+
+// Wait blocks until there is credit for sending, and returns the number of bytes that can be sent, but at most max.
+// It returns max right away if the window is nil or not enabled.
+func (w *sendWindow) Wait(max int) (int, error) {
+	if w == nil {
+		return max, nil
+	}
+	for {
+		w.Lock()
+		enabled, credit, closed := w.enabled, w.credit, w.closed
+		w.Unlock()
+
+		if closed {
+			return 0, io.ErrClosedPipe
+		}
+		if !enabled {
+			return max, nil
+		}
+		if credit > 0 {
+			if credit < int64(max) {
+				return int(credit), nil
+			}
+			return max, nil
+		}
+		<-w.update.Wait()
+	}
+}
+
+// Enabled returns true if the peer supports flow control, which is known from its first window update.
+func (w *sendWindow) Enabled() bool {
+	if w == nil {
+		return false
+	}
+	w.Lock()
+	defer w.Unlock()
+	return w.enabled
+}
+
+// Consume takes the given number of bytes from the window.
+func (w *sendWindow) Consume(n int) {
+	if w == nil {
+		return
+	}
+	w.Lock()
+	w.credit -= int64(n)
+	w.Unlock()
+}
+
+// Close releases all goroutines waiting for credits.
+func (w *sendWindow) Close() {
+	if w == nil {
+		return
+	}
+	w.Lock()
+	w.closed = true
+	w.Unlock()
+	w.update.Signal()
+}
+
+var errWindowExceeded = newError("peer sends more than its window")
+
+// receiver queues the data received for a session, and delivers it to the output of the session in its own
+// goroutine, so that a slow session doesn't block the others. Window updates are sent to the peer as the data is
+// delivered. The queue is bounded by the window, as the peer may not send more than it is granted.
+type receiver struct {
+	sync.Mutex
+	queue     buf.MultiBuffer
+	closed    bool
+	signal    *signal.Notifier
+	output    ray.OutputStream
+	onDeliver func(n uint32)
+	// received is the number of bytes pushed since the beginning of the session, and granted is the number of bytes
+	// the peer is allowed to send.
+	received int64
+	granted  int64
+	// err is the error to close the output with, if the receiver is closed with error.
+	err error
+}
+
+func newReceiver(output ray.OutputStream, onDeliver func(n uint32)) *receiver {
+	r := &receiver{
+		signal:    signal.NewNotifier(),
+		output:    output,
+		onDeliver: onDeliver,
+		granted:   initialWindow,
+	}
+	go r.run()
+	return r
+}
+
+// Push queues data for delivering. Data pushed after the receiver is closed is discarded. It returns an error if the
+// data exceeds the window granted to the peer, in which case the data is discarded.
+func (r *receiver) Push(mb buf.MultiBuffer) error {
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		mb.Release()
+		return nil
+	}
+	r.received += int64(mb.Len())
+	if r.received > r.granted {
+		r.Unlock()
+		mb.Release()
+		return errWindowExceeded
+	}
+	if r.queue == nil {
+		r.queue = buf.NewMultiBufferCap(16)
+	}
+	r.queue.AppendMulti(mb)
+	r.Unlock()
+	r.signal.Signal()
+	return nil
+}
+
+// Close closes the output of the receiver, after all queued data are delivered.
+func (r *receiver) Close() {
+	r.Lock()
+	r.closed = true
+	r.Unlock()
+	r.signal.Signal()
+}
+
+// CloseError closes the output of the receiver with the given error. Queued data are discarded.
+func (r *receiver) CloseError(err error) {
+	r.Lock()
+	r.closed = true
+	r.err = err
+	r.queue.Release()
+	r.queue = nil
+	r.Unlock()
+	r.signal.Signal()
+}
+
+func (r *receiver) run() {
+	var delivered uint32
+	for {
+		r.Lock()
+		mb, closed, err := r.queue, r.closed, r.err
+		r.queue = nil
+		r.Unlock()
+
+		if mb.IsEmpty() {
+			if !closed {
+				<-r.signal.Wait()
+				continue
+			}
+			if err != nil {
+				ray.CloseWithError(r.output, err)
+			} else {
+				r.output.Close()
+			}
+			return
+		}
+
+		n := uint32(mb.Len())
+		if err := r.output.WriteMultiBuffer(mb); err != nil {
+			// The output is gone. Remaining data is discarded, but still credited, so that the peer is not
+			// blocked before it learns the end of the session.
+			newError("failed to deliver session data").Base(err).AtDebug().WriteToLog()
+		}
+		delivered += n
+		if delivered >= windowUpdateThreshold {
+			// The window is extended before the peer learns it.
+			r.Lock()
+			r.granted += int64(delivered)
+			r.Unlock()
+			r.onDeliver(delivered)
+			delivered = 0
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package mux_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/proxyman"
+	. "v2ray.com/core/app/proxyman/mux"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/ray"
+	. "v2ray.com/ext/assert"
+)
+
+// testDispatcher sends bulk data to "bulk.v2ray.com", never reads data to "sink.v2ray.com", and echoes everything
+// else.
+type testDispatcher struct{}
+
+func (testDispatcher) Interface() interface{} { return (*dispatcher.Interface)(nil) }
+func (testDispatcher) Start() error           { return nil }
+func (testDispatcher) Close()                 {}
+
+func (testDispatcher) Dispatch(ctx context.Context, dest net.Destination) (ray.InboundRay, error) {
+	link := ray.NewRay(ctx)
+	if dest.Address.Family().IsDomain() && dest.Address.Domain() == "sink.v2ray.com" {
+		return link, nil
+	}
+	if dest.Address.Family().IsDomain() && dest.Address.Domain() == "bulk.v2ray.com" {
+		go func() {
+			for i := 0; i < 16*1024; i++ {
+				b := buf.New()
+				b.AppendBytes(make([]byte, 1024)...)
+				if err := link.OutboundOutput().WriteMultiBuffer(buf.NewMultiBufferValue(b)); err != nil {
+					return
+				}
+			}
+		}()
+	} else {
+		go buf.Copy(link.OutboundInput(), link.OutboundOutput())
+	}
+	return link, nil
+}
+
+// testOutbound connects mux clients to the mux server directly. If rate is set, the responses are limited to rate
+// bytes per second. If frames is set, it records the frames of the responses.
+type testOutbound struct {
+	server *Server
+	rate   int
+	frames *frameRecorder
+}
+
+func (o *testOutbound) Process(ctx context.Context, outboundRay ray.OutboundRay, dialer proxy.Dialer) error {
+	link, err := o.server.Dispatch(ctx, net.TCPDestination(net.DomainAddress("v1.mux.cool"), 9527))
+	if err != nil {
+		return err
+	}
+	go buf.Copy(outboundRay.OutboundInput(), link.InboundInput())
+	var output buf.Writer = outboundRay.OutboundOutput()
+	if o.rate > 0 {
+		output = &throttledWriter{Writer: output, rate: o.rate}
+	}
+	if o.frames != nil {
+		output = &recordingWriter{Writer: output, frames: o.frames}
+	}
+	return buf.Copy(link.InboundOutput(), output)
+}
+
+// throttledWriter writes rate bytes per second at most.
+type throttledWriter struct {
+	buf.Writer
+	rate int
+}
+
+func (w *throttledWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	time.Sleep(time.Second * time.Duration(mb.Len()) / time.Duration(w.rate))
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func TestFlowControl(t *testing.T) {
+	assert := With(t)
+
+	space := app.NewSpace()
+	assert(space.AddApplication(testDispatcher{}), IsNil)
+	ctx := app.ContextWithSpace(context.Background(), space)
+	server := NewServer(ctx)
+	assert(space.Initialize(), IsNil)
+
+	manager := NewClientManager(&testOutbound{server: server}, nil, &proxyman.MultiplexingConfig{
+		Enabled:     true,
+		Concurrency: 8,
+	})
+
+	// The bulk session is never read by the client.
+	bulk := ray.NewRay(context.Background())
+	bulkCtx := proxy.ContextWithTarget(context.Background(), net.TCPDestination(net.DomainAddress("bulk.v2ray.com"), 80))
+	assert(manager.Dispatch(bulkCtx, bulk), IsNil)
+	b := buf.New()
+	b.AppendBytes('a')
+	assert(bulk.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	// Let the bulk session fill up its output.
+	time.Sleep(time.Second)
+
+	echo := ray.NewRay(context.Background())
+	echoCtx := proxy.ContextWithTarget(context.Background(), net.TCPDestination(net.DomainAddress("echo.v2ray.com"), 80))
+	assert(manager.Dispatch(echoCtx, echo), IsNil)
+	b = buf.New()
+	b.AppendBytes('b')
+	assert(echo.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	mb, err := echo.InboundOutput().ReadTimeout(time.Second * 5)
+	assert(err, IsNil)
+	assert(mb.Len(), Equals, 1)
+	mb.Release()
+
+	// The bulk session still gets all its data when it is read.
+	total := 0
+	for total < 16*1024*1024 {
+		mb, err := bulk.InboundOutput().ReadTimeout(time.Second * 5)
+		assert(err, IsNil)
+		total += mb.Len()
+		mb.Release()
+	}
+
+	bulk.InboundInput().Close()
+	echo.InboundInput().Close()
+}
+
+// writeFrame writes a frame of the given metadata, and of the given data if it is not empty.
+func writeFrame(writer buf.Writer, meta FrameMetadata, data []byte) error {
+	frame := buf.NewLocal(len(data) + 512)
+	if len(data) > 0 {
+		meta.Option.Set(OptionData)
+	}
+	if err := frame.Reset(meta.AsSupplier()); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if err := frame.AppendSupplier(serial.WriteUint16(uint16(len(data)))); err != nil {
+			return err
+		}
+		frame.Append(data)
+	}
+	return writer.WriteMultiBuffer(buf.NewMultiBufferValue(frame))
+}
+
+func TestFlowControlWindowExceeded(t *testing.T) {
+	assert := With(t)
+
+	space := app.NewSpace()
+	assert(space.AddApplication(testDispatcher{}), IsNil)
+	ctx := app.ContextWithSpace(context.Background(), space)
+	server := NewServer(ctx)
+	assert(space.Initialize(), IsNil)
+
+	link, err := server.Dispatch(context.Background(), net.TCPDestination(net.DomainAddress("v1.mux.cool"), 9527))
+	assert(err, IsNil)
+
+	// The client supports flow control, but ignores the window. As the sink never reads, the server grants little
+	// more than the initial window and the buffer of the sink.
+	meta := FrameMetadata{
+		SessionID:     1,
+		SessionStatus: SessionStatusNew,
+		Target:        net.TCPDestination(net.DomainAddress("sink.v2ray.com"), 80),
+	}
+	meta.Option.Set(OptionFlowControl)
+	assert(writeFrame(link.InboundInput(), meta, nil), IsNil)
+	go func() {
+		data := make([]byte, 60000)
+		for i := 0; i < 400; i++ {
+			meta := FrameMetadata{
+				SessionID:     1,
+				SessionStatus: SessionStatusKeep,
+			}
+			if err := writeFrame(link.InboundInput(), meta, data); err != nil {
+				return
+			}
+		}
+	}()
+
+	// The server ends the session, and sends nothing else than window updates before that.
+	ended := make(chan *FrameMetadata, 1)
+	go func() {
+		reader := buf.NewBufferedReader(link.InboundOutput())
+		for {
+			meta, err := ReadMetadata(reader)
+			if err != nil || meta.Option.Has(OptionData) || meta.SessionStatus == SessionStatusEnd {
+				ended <- meta
+				return
+			}
+		}
+	}()
+	select {
+	case meta := <-ended:
+		assert(meta, IsNotNil)
+		assert(meta.SessionID, Equals, uint16(1))
+		assert(meta.SessionStatus, Equals, SessionStatusEnd)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timeout")
+	}
+	link.InboundInput().Close()
+}
+
+func TestWindowUpdateFrame(t *testing.T) {
+	assert := With(t)
+
+	meta := FrameMetadata{
+		SessionID:     3,
+		SessionStatus: SessionStatusKeep,
+		WindowUpdate:  65536,
+	}
+	meta.Option.Set(OptionFlowControl)
+
+	b := buf.New()
+	assert(b.Reset(meta.AsSupplier()), IsNil)
+
+	meta2, err := ReadMetadata(b)
+	assert(err, IsNil)
+	assert(meta2.SessionID, Equals, uint16(3))
+	assert(meta2.Option.Has(OptionFlowControl), IsTrue)
+	assert(meta2.Option.Has(OptionData), IsFalse)
+	assert(meta2.WindowUpdate, Equals, uint32(65536))
+}
@@ -19,6 +19,13 @@ const (
 
 const (
 	OptionData bitmask.Byte = 0x01
+	// OptionFlowControl indicates that the frame carries a window update. In the first frame of a session from the
+	// client, it indicates that the client supports flow control. Older peers ignore it.
+	OptionFlowControl bitmask.Byte = 0x02
+	// OptionAddress indicates that the frame carries the address of its packet in a UDP session. In the first frame of
+	// a session from the client, it requests a full-cone session, where the server sends packets to the address of each
+	// frame, and responds with the address each packet comes from. Older peers ignore it.
+	OptionAddress bitmask.Byte = 0x04
 )
 
 type TargetNetwork byte
@@ -35,10 +42,12 @@ Frame format
 1 bytes - status
 1 bytes - option
 
-1 byte - network
+1 byte - network, if status is New or OptionAddress is set
 2 bytes - port
 n bytes - address
 
+4 bytes - window update, if OptionFlowControl is set
+
 */
 
 type FrameMetadata struct {
@@ -46,6 +55,12 @@ type FrameMetadata struct {
 	SessionID     uint16
 	Option        bitmask.Byte
 	SessionStatus SessionStatus
+	WindowUpdate  uint32
+}
+
+// hasTarget returns true if the frame carries a target address.
+func (f FrameMetadata) hasTarget() bool {
+	return f.SessionStatus == SessionStatusNew || (f.SessionStatus == SessionStatusKeep && f.Option.Has(OptionAddress))
 }
 
 func (f FrameMetadata) AsSupplier() buf.Supplier {
@@ -57,38 +72,19 @@ func (f FrameMetadata) AsSupplier() buf.Supplier {
 		b = append(b, byte(f.SessionStatus), byte(f.Option))
 		length := 4
 
-		if f.SessionStatus == SessionStatusNew {
-			switch f.Target.Network {
-			case net.Network_TCP:
-				b = append(b, byte(TargetNetworkTCP))
-			case net.Network_UDP:
-				b = append(b, byte(TargetNetworkUDP))
-			}
-			length++
-
-			b = serial.Uint16ToBytes(f.Target.Port.Value(), b)
-			length += 2
-
-			addr := f.Target.Address
-			switch addr.Family() {
-			case net.AddressFamilyIPv4:
-				b = append(b, byte(protocol.AddressTypeIPv4))
-				b = append(b, addr.IP()...)
-				length += 5
-			case net.AddressFamilyIPv6:
-				b = append(b, byte(protocol.AddressTypeIPv6))
-				b = append(b, addr.IP()...)
-				length += 17
-			case net.AddressFamilyDomain:
-				domain := addr.Domain()
-				if protocol.IsDomainTooLong(domain) {
-					return 0, newError("domain name too long: ", domain)
-				}
-				nDomain := len(domain)
-				b = append(b, byte(protocol.AddressTypeDomain), byte(nDomain))
-				b = append(b, domain...)
-				length += nDomain + 2
+		if f.hasTarget() {
+			var n int
+			var err error
+			b, n, err = appendTarget(b, f.Target)
+			if err != nil {
+				return 0, err
 			}
+			length += n
+		}
+
+		if f.Option.Has(OptionFlowControl) {
+			b = serial.Uint32ToBytes(f.WindowUpdate, b)
+			length += 4
 		}
 
 		serial.Uint16ToBytes(uint16(length), lengthBytes[:0])
@@ -109,36 +105,93 @@ func ReadFrameFrom(b []byte) (*FrameMetadata, error) {
 
 	b = b[4:]
 
-	if f.SessionStatus == SessionStatusNew {
-		network := TargetNetwork(b[0])
-		port := net.PortFromBytes(b[1:3])
-		addrType := protocol.AddressType(b[3])
-		b = b[4:]
-
-		var addr net.Address
-		switch addrType {
-		case protocol.AddressTypeIPv4:
-			addr = net.IPAddress(b[0:4])
-			b = b[4:]
-		case protocol.AddressTypeIPv6:
-			addr = net.IPAddress(b[0:16])
-			b = b[16:]
-		case protocol.AddressTypeDomain:
-			nDomain := int(b[0])
-			addr = net.DomainAddress(string(b[1 : 1+nDomain]))
-			b = b[nDomain+1:]
-		default:
-			return nil, newError("unknown address type: ", addrType)
+	if f.hasTarget() {
+		target, rest, err := readTarget(b)
+		if err != nil {
+			return nil, err
 		}
-		switch network {
-		case TargetNetworkTCP:
-			f.Target = net.TCPDestination(addr, port)
-		case TargetNetworkUDP:
-			f.Target = net.UDPDestination(addr, port)
-		default:
-			return nil, newError("unknown network type: ", network)
+		f.Target = target
+		b = rest
+	}
+
+	if f.Option.Has(OptionFlowControl) {
+		if len(b) < 4 {
+			return nil, newError("insufficient buffer for window update: ", len(b))
 		}
+		f.WindowUpdate = serial.BytesToUint32(b[:4])
 	}
 
 	return f, nil
 }
+
+// appendTarget appends the given target to b, and returns the number of bytes appended.
+func appendTarget(b []byte, target net.Destination) ([]byte, int, error) {
+	length := 0
+	switch target.Network {
+	case net.Network_TCP:
+		b = append(b, byte(TargetNetworkTCP))
+	case net.Network_UDP:
+		b = append(b, byte(TargetNetworkUDP))
+	}
+	length++
+
+	b = serial.Uint16ToBytes(target.Port.Value(), b)
+	length += 2
+
+	addr := target.Address
+	switch addr.Family() {
+	case net.AddressFamilyIPv4:
+		b = append(b, byte(protocol.AddressTypeIPv4))
+		b = append(b, addr.IP()...)
+		length += 5
+	case net.AddressFamilyIPv6:
+		b = append(b, byte(protocol.AddressTypeIPv6))
+		b = append(b, addr.IP()...)
+		length += 17
+	case net.AddressFamilyDomain:
+		domain := addr.Domain()
+		if protocol.IsDomainTooLong(domain) {
+			return nil, 0, newError("domain name too long: ", domain)
+		}
+		nDomain := len(domain)
+		b = append(b, byte(protocol.AddressTypeDomain), byte(nDomain))
+		b = append(b, domain...)
+		length += nDomain + 2
+	}
+	return b, length, nil
+}
+
+// readTarget reads a target from the beginning of b, and returns the rest of b.
+func readTarget(b []byte) (net.Destination, []byte, error) {
+	if len(b) < 4 {
+		return net.Destination{}, nil, newError("insufficient buffer for target: ", len(b))
+	}
+	network := TargetNetwork(b[0])
+	port := net.PortFromBytes(b[1:3])
+	addrType := protocol.AddressType(b[3])
+	b = b[4:]
+
+	var addr net.Address
+	switch addrType {
+	case protocol.AddressTypeIPv4:
+		addr = net.IPAddress(b[0:4])
+		b = b[4:]
+	case protocol.AddressTypeIPv6:
+		addr = net.IPAddress(b[0:16])
+		b = b[16:]
+	case protocol.AddressTypeDomain:
+		nDomain := int(b[0])
+		addr = net.DomainAddress(string(b[1 : 1+nDomain]))
+		b = b[nDomain+1:]
+	default:
+		return net.Destination{}, nil, newError("unknown address type: ", addrType)
+	}
+	switch network {
+	case TargetNetworkTCP:
+		return net.TCPDestination(addr, port), b, nil
+	case TargetNetworkUDP:
+		return net.UDPDestination(addr, port), b, nil
+	default:
+		return net.Destination{}, nil, newError("unknown network type: ", network)
+	}
+}
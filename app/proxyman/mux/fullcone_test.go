@@ -0,0 +1,111 @@
+package mux_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	"v2ray.com/core/app/proxyman"
+	. "v2ray.com/core/app/proxyman/mux"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/ray"
+	. "v2ray.com/ext/assert"
+)
+
+var otherPeer = net.UDPDestination(net.IPAddress([]byte{1, 2, 3, 4}), 5678)
+
+// fullConeDispatcher echoes every packet, and also responds from otherPeer in full-cone sessions.
+type fullConeDispatcher struct{}
+
+func (fullConeDispatcher) Interface() interface{} { return (*dispatcher.Interface)(nil) }
+func (fullConeDispatcher) Start() error           { return nil }
+func (fullConeDispatcher) Close()                 {}
+
+func (fullConeDispatcher) Dispatch(ctx context.Context, dest net.Destination) (ray.InboundRay, error) {
+	link := ray.NewRay(ctx)
+	fullCone := proxy.FullConeFromContext(ctx)
+	go func() {
+		for {
+			mb, err := link.OutboundInput().ReadMultiBuffer()
+			if err != nil {
+				return
+			}
+			if fullCone {
+				b := buf.New()
+				b.AppendBytes('p')
+				b.SetDestination(otherPeer)
+				mb.Append(b)
+			}
+			if err := link.OutboundOutput().WriteMultiBuffer(mb); err != nil {
+				return
+			}
+		}
+	}()
+	return link, nil
+}
+
+func TestFullConeUDP(t *testing.T) {
+	assert := With(t)
+
+	space := app.NewSpace()
+	assert(space.AddApplication(fullConeDispatcher{}), IsNil)
+	ctx := app.ContextWithSpace(context.Background(), space)
+	server := NewServer(ctx)
+	assert(space.Initialize(), IsNil)
+
+	manager := NewClientManager(&testOutbound{server: server}, nil, &proxyman.MultiplexingConfig{
+		Enabled:     true,
+		Concurrency: 8,
+		FullCone:    true,
+	})
+
+	link := ray.NewRay(context.Background())
+	target := net.UDPDestination(net.DomainAddress("stun.v2ray.com"), 3478)
+	assert(manager.Dispatch(proxy.ContextWithTarget(context.Background(), target), link), IsNil)
+
+	b := buf.New()
+	b.AppendBytes('a')
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	var packets buf.MultiBuffer
+	for len(packets) < 2 {
+		mb, err := link.InboundOutput().ReadTimeout(time.Second * 5)
+		assert(err, IsNil)
+		packets.AppendMulti(mb)
+	}
+
+	assert(packets[0].String(), Equals, "a")
+	assert(packets[0].Destination().IsValid(), IsFalse)
+	assert(packets[1].String(), Equals, "p")
+	assert(packets[1].Destination(), Equals, otherPeer)
+	packets.Release()
+
+	link.InboundInput().Close()
+}
+
+func TestAddressFrame(t *testing.T) {
+	assert := With(t)
+
+	meta := FrameMetadata{
+		SessionID:     5,
+		SessionStatus: SessionStatusKeep,
+		Target:        otherPeer,
+		WindowUpdate:  1024,
+	}
+	meta.Option.Set(OptionAddress)
+	meta.Option.Set(OptionFlowControl)
+
+	b := buf.New()
+	assert(b.Reset(meta.AsSupplier()), IsNil)
+
+	meta2, err := ReadMetadata(b)
+	assert(err, IsNil)
+	assert(meta2.SessionID, Equals, uint16(5))
+	assert(meta2.Option.Has(OptionAddress), IsTrue)
+	assert(meta2.Target, Equals, otherPeer)
+	assert(meta2.WindowUpdate, Equals, uint32(1024))
+}
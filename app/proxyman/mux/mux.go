@@ -13,16 +13,13 @@ import (
 	"v2ray.com/core/app/proxyman"
 	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/errors"
+	"v2ray.com/core/common/log"
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/ray"
 )
 
-const (
-	maxTotal = 128
-)
-
 type ClientManager struct {
 	access  sync.Mutex
 	clients []*Client
@@ -43,6 +40,8 @@ func (m *ClientManager) Dispatch(ctx context.Context, outboundRay ray.OutboundRa
 	m.access.Lock()
 	defer m.access.Unlock()
 
+	defer m.prewarm()
+
 	for _, client := range m.clients {
 		if client.Dispatch(ctx, outboundRay) {
 			return nil
@@ -58,6 +57,27 @@ func (m *ClientManager) Dispatch(ctx context.Context, outboundRay ray.OutboundRa
 	return nil
 }
 
+// prewarm creates a spare client, if prewarming is enabled and no client can take a new session. Caller must hold
+// the lock.
+func (m *ClientManager) prewarm() {
+	if !m.config.Prewarm {
+		return
+	}
+
+	for _, client := range m.clients {
+		if client.available() {
+			return
+		}
+	}
+
+	client, err := NewClient(m.proxy, m.dialer, m)
+	if err != nil {
+		newError("failed to create spare client").Base(err).WriteToLog()
+		return
+	}
+	m.clients = append(m.clients, client)
+}
+
 func (m *ClientManager) onClientFinish() {
 	m.access.Lock()
 	defer m.access.Unlock()
@@ -78,7 +98,13 @@ type Client struct {
 	ctx            context.Context
 	cancel         context.CancelFunc
 	manager        *ClientManager
+	scheduler      *scheduler
+	maxFrameSize   int
 	concurrency    uint32
+	maxSessions    uint32
+	idleTimeout    time.Duration
+	expire         time.Time
+	fullCone       bool
 }
 
 var muxCoolAddress = net.DomainAddress("v1.mux.cool")
@@ -104,8 +130,16 @@ func NewClient(p proxy.Outbound, dialer proxy.Dialer, m *ClientManager) (*Client
 		ctx:            ctx,
 		cancel:         cancel,
 		manager:        m,
+		maxFrameSize:   int(m.config.GetMaxFrameSizeValue()),
 		concurrency:    m.config.Concurrency,
+		maxSessions:    m.config.GetMaxSessionsValue(),
+		idleTimeout:    m.config.GetIdleTimeoutValue(),
+		fullCone:       m.config.FullCone,
 	}
+	if lifetime := m.config.GetMaxLifetimeValue(); lifetime > 0 {
+		c.expire = time.Now().Add(lifetime)
+	}
+	c.scheduler = newScheduler(pipe.InboundInput(), c.maxFrameSize)
 	go c.fetchOutput()
 	go c.monitor()
 	return c, nil
@@ -121,36 +155,55 @@ func (m *Client) Closed() bool {
 	}
 }
 
+// expired returns true if this Client has reached its max lifetime.
+func (m *Client) expired() bool {
+	return !m.expire.IsZero() && time.Now().After(m.expire)
+}
+
+// available returns true if this Client can take a new session.
+func (m *Client) available() bool {
+	sm := m.sessionManager
+	if sm.Size() >= int(m.concurrency) || sm.Count() >= int(m.maxSessions) || m.expired() {
+		return false
+	}
+	return !m.Closed()
+}
+
 func (m *Client) monitor() {
 	defer m.manager.onClientFinish()
 
-	timer := time.NewTicker(time.Second * 16)
+	timer := time.NewTicker(time.Second)
 	defer timer.Stop()
 
+	idleSince := time.Now()
 	for {
 		select {
 		case <-m.ctx.Done():
 			m.sessionManager.Close()
+			m.scheduler.Close()
 			m.inboundRay.InboundInput().Close()
 			m.inboundRay.InboundOutput().CloseError()
 			return
-		case <-timer.C:
-			size := m.sessionManager.Size()
-			if size == 0 && m.sessionManager.CloseIfNoSession() {
+		case now := <-timer.C:
+			if m.sessionManager.Size() > 0 {
+				idleSince = now
+				continue
+			}
+			// An expired Client never takes new sessions, so it closes as soon as all its sessions end.
+			idle := now.Sub(idleSince) >= m.idleTimeout || m.expired()
+			if idle && m.sessionManager.CloseIfNoSession() {
 				m.cancel()
 			}
 		}
 	}
 }
 
-func fetchInput(ctx context.Context, s *Session, output buf.Writer) {
+func (m *Client) fetchInput(ctx context.Context, s *Session) {
 	dest, _ := proxy.TargetFromContext(ctx)
-	transferType := protocol.TransferTypeStream
-	if dest.Network == net.Network_UDP {
-		transferType = protocol.TransferTypePacket
-	}
-	s.transferType = transferType
-	writer := NewWriter(s.ID, dest, output, transferType)
+	writer := NewWriter(s.ID, dest, m.scheduler.NewWriter(), s.transferType)
+	writer.window = s.window
+	writer.maxFrameSize = m.maxFrameSize
+	writer.packetAddress = m.fullCone && s.transferType == protocol.TransferTypePacket
 	defer writer.Close()
 	defer s.Close()
 
@@ -166,24 +219,23 @@ func fetchInput(ctx context.Context, s *Session, output buf.Writer) {
 }
 
 func (m *Client) Dispatch(ctx context.Context, outboundRay ray.OutboundRay) bool {
-	sm := m.sessionManager
-	if sm.Size() >= int(m.concurrency) || sm.Count() >= maxTotal {
+	if !m.available() {
 		return false
 	}
 
-	select {
-	case <-m.ctx.Done():
-		return false
-	default:
+	s := &Session{
+		input:        outboundRay.OutboundInput(),
+		output:       outboundRay.OutboundOutput(),
+		transferType: protocol.TransferTypeStream,
 	}
-
-	s := sm.Allocate()
-	if s == nil {
+	if dest, _ := proxy.TargetFromContext(ctx); dest.Network == net.Network_UDP {
+		s.transferType = protocol.TransferTypePacket
+	}
+	// Data from the server is queued only after the server shows that it supports flow control.
+	if !m.sessionManager.Allocate(s, m.inboundRay.InboundInput()) {
 		return false
 	}
-	s.input = outboundRay.OutboundInput()
-	s.output = outboundRay.OutboundOutput()
-	go fetchInput(ctx, s, m.inboundRay.InboundInput())
+	go m.fetchInput(ctx, s)
 	return true
 }
 
@@ -206,12 +258,16 @@ func (m *Client) handleStatusNew(meta *FrameMetadata, reader *buf.BufferedReader
 }
 
 func (m *Client) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	s, found := m.sessionManager.Get(meta.SessionID)
+	if found && meta.Option.Has(OptionFlowControl) {
+		s.window.Grant(meta.WindowUpdate)
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
 
-	if s, found := m.sessionManager.Get(meta.SessionID); found {
-		return buf.Copy(s.NewReader(reader), s.output, buf.IgnoreWriterError())
+	if found {
+		return s.receive(meta, reader)
 	}
 	return drain(reader)
 }
@@ -285,11 +341,18 @@ func (s *Server) Dispatch(ctx context.Context, dest net.Destination) (ray.Inboun
 		return s.dispatcher.Dispatch(ctx, dest)
 	}
 
+	// The access message is of the mux connection, not of any session in it.
+	if msg := log.AccessMessageFromContext(ctx); msg != nil {
+		log.Record(msg)
+		ctx = log.ContextWithAccessMessage(ctx, nil)
+	}
+
 	ray := ray.NewRay(ctx)
 	worker := &ServerWorker{
 		dispatcher:     s.dispatcher,
 		outboundRay:    ray,
 		sessionManager: NewSessionManager(),
+		scheduler:      newScheduler(ray.OutboundOutput(), defaultMaxFrameSize),
 	}
 	go worker.run(ctx)
 	return ray, nil
@@ -299,10 +362,10 @@ type ServerWorker struct {
 	dispatcher     dispatcher.Interface
 	outboundRay    ray.OutboundRay
 	sessionManager *SessionManager
+	scheduler      *scheduler
 }
 
-func handle(ctx context.Context, s *Session, output buf.Writer) {
-	writer := NewResponseWriter(s.ID, output, s.transferType)
+func handle(ctx context.Context, s *Session, writer *Writer) {
 	if err := buf.Copy(s.input, writer); err != nil {
 		newError("session ", s.ID, " ends.").Base(err).WriteToLog()
 	}
@@ -319,6 +382,10 @@ func (w *ServerWorker) handleStatusKeepAlive(meta *FrameMetadata, reader *buf.Bu
 
 func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata, reader *buf.BufferedReader) error {
 	newError("received request for ", meta.Target).WriteToLog()
+	fullCone := meta.Target.Network == net.Network_UDP && meta.Option.Has(OptionAddress)
+	if fullCone {
+		ctx = proxy.ContextWithFullCone(ctx)
+	}
 	inboundRay, err := w.dispatcher.Dispatch(ctx, meta.Target)
 	if err != nil {
 		if meta.Option.Has(OptionData) {
@@ -336,20 +403,40 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 	if meta.Target.Network == net.Network_UDP {
 		s.transferType = protocol.TransferTypePacket
 	}
+	output := w.outboundRay.OutboundOutput()
+	if meta.Option.Has(OptionFlowControl) {
+		// The client supports flow control. An empty window update tells the client that the server does too.
+		s.enableFlowControl(output)
+		s.window.Grant(0)
+		if err := writeWindowUpdate(output, s.ID, 0); err != nil {
+			return newError("failed to write window update").Base(err)
+		}
+	}
+	writer := NewResponseWriter(s.ID, w.scheduler.NewWriter(), s.transferType)
+	writer.window = s.window
+	if fullCone {
+		// Responses from the target itself are sent without address.
+		writer.dest = meta.Target
+		writer.packetAddress = true
+	}
 	w.sessionManager.Add(s)
-	go handle(ctx, s, w.outboundRay.OutboundOutput())
+	go handle(ctx, s, writer)
 	if meta.Option.Has(OptionData) {
-		return buf.Copy(s.NewReader(reader), s.output, buf.IgnoreWriterError())
+		return s.receive(meta, reader)
 	}
 	return nil
 }
 
 func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	s, found := w.sessionManager.Get(meta.SessionID)
+	if found && meta.Option.Has(OptionFlowControl) {
+		s.window.Grant(meta.WindowUpdate)
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
-	if s, found := w.sessionManager.Get(meta.SessionID); found {
-		return buf.Copy(s.NewReader(reader), s.output, buf.IgnoreWriterError())
+	if found {
+		return s.receive(meta, reader)
 	}
 	return drain(reader)
 }
@@ -394,6 +481,7 @@ func (w *ServerWorker) run(ctx context.Context) {
 	reader := buf.NewBufferedReader(input)
 
 	defer w.sessionManager.Close()
+	defer w.scheduler.Close()
 
 	for {
 		select {
@@ -0,0 +1,88 @@
+package mux_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/proxyman"
+	. "v2ray.com/core/app/proxyman/mux"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/ray"
+	. "v2ray.com/ext/assert"
+)
+
+// countingOutbound counts the underlying connections of mux clients.
+type countingOutbound struct {
+	testOutbound
+	count int32
+}
+
+func (o *countingOutbound) Process(ctx context.Context, outboundRay ray.OutboundRay, dialer proxy.Dialer) error {
+	atomic.AddInt32(&o.count, 1)
+	return o.testOutbound.Process(ctx, outboundRay, dialer)
+}
+
+func echoSession(manager *ClientManager) (ray.InboundRay, error) {
+	link := ray.NewRay(context.Background())
+	ctx := proxy.ContextWithTarget(context.Background(), net.TCPDestination(net.DomainAddress("echo.v2ray.com"), 80))
+	if err := manager.Dispatch(ctx, link); err != nil {
+		return nil, err
+	}
+	b := buf.New()
+	b.AppendBytes('a')
+	if err := link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)); err != nil {
+		return nil, err
+	}
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 5)
+	mb.Release()
+	return link, err
+}
+
+func TestMaxSessionsAndPrewarm(t *testing.T) {
+	assert := With(t)
+
+	space := app.NewSpace()
+	assert(space.AddApplication(testDispatcher{}), IsNil)
+	ctx := app.ContextWithSpace(context.Background(), space)
+	server := NewServer(ctx)
+	assert(space.Initialize(), IsNil)
+
+	outbound := &countingOutbound{testOutbound: testOutbound{server: server}}
+	manager := NewClientManager(outbound, nil, &proxyman.MultiplexingConfig{
+		Enabled:     true,
+		Concurrency: 8,
+		MaxSessions: 2,
+		Prewarm:     true,
+	})
+
+	link1, err := echoSession(manager)
+	assert(err, IsNil)
+	assert(atomic.LoadInt32(&outbound.count), Equals, int32(1))
+
+	// The first connection is full after the second session, so a spare one is created.
+	link2, err := echoSession(manager)
+	assert(err, IsNil)
+	time.Sleep(time.Millisecond * 100)
+	assert(atomic.LoadInt32(&outbound.count), Equals, int32(2))
+
+	// The third session takes the spare connection, which can still take another session.
+	link3, err := echoSession(manager)
+	assert(err, IsNil)
+	time.Sleep(time.Millisecond * 100)
+	assert(atomic.LoadInt32(&outbound.count), Equals, int32(2))
+
+	// The spare connection is full after the fourth session.
+	link4, err := echoSession(manager)
+	assert(err, IsNil)
+	time.Sleep(time.Millisecond * 100)
+	assert(atomic.LoadInt32(&outbound.count), Equals, int32(3))
+
+	for _, link := range []ray.InboundRay{link1, link2, link3, link4} {
+		link.InboundInput().Close()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
 	"v2ray.com/core/common/serial"
 )
 
@@ -30,6 +31,7 @@ func ReadMetadata(reader io.Reader) (*FrameMetadata, error) {
 type PacketReader struct {
 	reader io.Reader
 	eof    bool
+	dest   net.Destination
 }
 
 // NewPacketReader creates a new PacketReader.
@@ -61,6 +63,9 @@ func (r *PacketReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
 		b.Release()
 		return nil, err
 	}
+	if r.dest.IsValid() {
+		b.SetDestination(r.dest)
+	}
 	r.eof = true
 	return buf.NewMultiBufferValue(b), nil
 }
@@ -0,0 +1,209 @@
+package mux
+
+import (
+	"io"
+	"sync"
+
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/signal"
+)
+
+const (
+	// defaultMaxFrameSize is the max number of payload bytes in a frame, if not configured.
+	defaultMaxFrameSize = 8 * 1024
+
+	// frameOverhead is the max number of bytes in a frame besides its payload.
+	frameOverhead = 2 + 512 + 2
+
+	// outputBufferFrames is the number of max size frames that may wait in a buffered output. Turns are taken only
+	// when the output has less, so that new frames don't wait behind a long backlog of other sessions.
+	outputBufferFrames = 4
+)
+
+// bufferedOutput is an output that keeps written data until it is read, such as ray.Stream.
+type bufferedOutput interface {
+	// Size returns the number of bytes waiting to be read.
+	Size() uint64
+	// ReadSignal returns a channel that is signaled when data is read.
+	ReadSignal() <-chan bool
+}
+
+// scheduler interleaves the frames of all sessions in a Mux connection into the underlying writer. Sessions with
+// pending frames take turns by deficit round robin, so that a bulk session doesn't delay the others. In each turn, a
+// session may send about one frame of the max size.
+//
+// Window updates of flow control are written to the underlying writer directly, instead of through the scheduler.
+// They are small, and the peer may be blocked until it receives them, so they are not delayed by data frames.
+type scheduler struct {
+	sync.Mutex
+	output  buf.Writer
+	quantum int
+	active  []*frameQueue
+	closed  bool
+	signal  *signal.Notifier
+}
+
+func newScheduler(output buf.Writer, maxFrameSize int) *scheduler {
+	s := &scheduler{
+		output:  output,
+		quantum: maxFrameSize + frameOverhead,
+		signal:  signal.NewNotifier(),
+	}
+	go s.run()
+	return s
+}
+
+// NewWriter creates a buf.Writer for the frames of a session. Each call to WriteMultiBuffer() must contain exactly
+// one frame.
+func (s *scheduler) NewWriter() buf.Writer {
+	return &frameQueue{
+		parent: s,
+		space:  signal.NewNotifier(),
+	}
+}
+
+// Close discards all pending frames, and fails all further writes.
+func (s *scheduler) Close() {
+	s.Lock()
+	s.closed = true
+	for _, q := range s.active {
+		q.release()
+	}
+	s.active = nil
+	s.Unlock()
+
+	s.signal.Signal()
+}
+
+// next takes the frames that the next active session may send in its turn.
+func (s *scheduler) next() ([]buf.MultiBuffer, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return nil, false
+	}
+	if len(s.active) == 0 {
+		return nil, true
+	}
+
+	q := s.active[0]
+	s.active = s.active[1:]
+	q.deficit += s.quantum
+
+	var frames []buf.MultiBuffer
+	for len(q.frames) > 0 && q.frames[0].Len() <= q.deficit {
+		frame := q.frames[0]
+		q.frames = q.frames[1:]
+		q.deficit -= frame.Len()
+		q.size -= frame.Len()
+		frames = append(frames, frame)
+	}
+
+	if len(q.frames) == 0 {
+		q.deficit = 0
+		q.frames = nil
+	} else {
+		s.active = append(s.active, q)
+	}
+	q.space.Signal()
+	return frames, true
+}
+
+// waitForOutput waits until the output has room for more frames, if the output is buffered.
+func (s *scheduler) waitForOutput() {
+	output, ok := s.output.(bufferedOutput)
+	if !ok {
+		return
+	}
+	limit := uint64(s.quantum * outputBufferFrames)
+	for output.Size() >= limit {
+		s.Lock()
+		closed := s.closed
+		s.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case <-output.ReadSignal():
+		case <-s.signal.Wait():
+		}
+	}
+}
+
+func (s *scheduler) run() {
+	for {
+		s.waitForOutput()
+		frames, ok := s.next()
+		if !ok {
+			return
+		}
+		if frames == nil {
+			s.Lock()
+			idle := len(s.active) == 0 && !s.closed
+			s.Unlock()
+			if idle {
+				<-s.signal.Wait()
+			}
+			continue
+		}
+		for i, frame := range frames {
+			if err := s.output.WriteMultiBuffer(frame); err != nil {
+				newError("failed to write frame").Base(err).AtDebug().WriteToLog()
+				for _, f := range frames[i+1:] {
+					f.Release()
+				}
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+// frameQueue holds the pending frames of a session. A session may have about one quantum of pending bytes, before
+// its writes are blocked.
+type frameQueue struct {
+	parent  *scheduler
+	frames  []buf.MultiBuffer
+	size    int
+	deficit int
+	space   *signal.Notifier
+}
+
+// release discards all pending frames. Caller must hold the lock of the scheduler.
+func (q *frameQueue) release() {
+	for _, frame := range q.frames {
+		frame.Release()
+	}
+	q.frames = nil
+	q.size = 0
+	q.space.Signal()
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (q *frameQueue) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	s := q.parent
+	for {
+		s.Lock()
+		if s.closed {
+			s.Unlock()
+			mb.Release()
+			return io.ErrClosedPipe
+		}
+		if q.size < s.quantum {
+			break
+		}
+		s.Unlock()
+		<-q.space.Wait()
+	}
+
+	if len(q.frames) == 0 {
+		s.active = append(s.active, q)
+	}
+	q.frames = append(q.frames, mb)
+	q.size += mb.Len()
+	s.Unlock()
+
+	s.signal.Signal()
+	return nil
+}
@@ -0,0 +1,145 @@
+package mux_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/proxyman"
+	. "v2ray.com/core/app/proxyman/mux"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/ray"
+	. "v2ray.com/ext/assert"
+)
+
+// frameRecorder keeps the session IDs of data frames in the order that they are sent.
+type frameRecorder struct {
+	sync.Mutex
+	pending  []byte
+	sessions []uint16
+}
+
+// record parses the frames in the given bytes, which may end in the middle of a frame.
+func (r *frameRecorder) record(b []byte) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.pending = append(r.pending, b...)
+	for len(r.pending) >= 2 {
+		metaLen := int(serial.BytesToUint16(r.pending))
+		if len(r.pending) < 2+metaLen {
+			return
+		}
+		meta, err := ReadFrameFrom(r.pending[2 : 2+metaLen])
+		if err != nil {
+			panic(err)
+		}
+		frameLen := 2 + metaLen
+		if meta.Option.Has(OptionData) {
+			if len(r.pending) < frameLen+2 {
+				return
+			}
+			frameLen += 2 + int(serial.BytesToUint16(r.pending[frameLen:]))
+			if len(r.pending) < frameLen {
+				return
+			}
+			r.sessions = append(r.sessions, meta.SessionID)
+		}
+		r.pending = r.pending[frameLen:]
+	}
+}
+
+// count returns the number of data frames recorded.
+func (r *frameRecorder) count() int {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.sessions)
+}
+
+// framesBefore returns the number of data frames of other sessions that are recorded after the first given number of
+// frames, and before the first frame of the session.
+func (r *frameRecorder) framesBefore(start int, id uint16) int {
+	r.Lock()
+	defer r.Unlock()
+
+	n := 0
+	for _, s := range r.sessions[start:] {
+		if s == id {
+			return n
+		}
+		n++
+	}
+	return -1
+}
+
+// recordingWriter records all frames written through it.
+type recordingWriter struct {
+	buf.Writer
+	frames *frameRecorder
+}
+
+func (w *recordingWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	for _, b := range mb {
+		w.frames.record(b.Bytes())
+	}
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func TestInterleaveSessions(t *testing.T) {
+	assert := With(t)
+
+	space := app.NewSpace()
+	assert(space.AddApplication(testDispatcher{}), IsNil)
+	ctx := app.ContextWithSpace(context.Background(), space)
+	server := NewServer(ctx)
+	assert(space.Initialize(), IsNil)
+
+	// The link is slow, so that the bulk session alone keeps it busy.
+	frames := &frameRecorder{}
+	manager := NewClientManager(&testOutbound{server: server, rate: 2 * 1024 * 1024, frames: frames}, nil, &proxyman.MultiplexingConfig{
+		Enabled:      true,
+		Concurrency:  8,
+		MaxFrameSize: 1024,
+	})
+
+	// The bulk session is read all the time.
+	bulk := ray.NewRay(context.Background())
+	bulkCtx := proxy.ContextWithTarget(context.Background(), net.TCPDestination(net.DomainAddress("bulk.v2ray.com"), 80))
+	assert(manager.Dispatch(bulkCtx, bulk), IsNil)
+	b := buf.New()
+	b.AppendBytes('a')
+	assert(bulk.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+	go buf.Copy(bulk.InboundOutput(), buf.Discard)
+
+	time.Sleep(time.Millisecond * 100)
+
+	echo := ray.NewRay(context.Background())
+	echoCtx := proxy.ContextWithTarget(context.Background(), net.TCPDestination(net.DomainAddress("echo.v2ray.com"), 80))
+	assert(manager.Dispatch(echoCtx, echo), IsNil)
+	for i := 0; i < 8; i++ {
+		start := frames.count()
+		b := buf.New()
+		b.AppendBytes(byte(i))
+		assert(echo.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+		mb, err := echo.InboundOutput().ReadTimeout(time.Second * 5)
+		assert(err, IsNil)
+		assert(mb.Len(), Equals, 1)
+		mb.Release()
+
+		// The response doesn't wait behind the window of the bulk session, which is about 1000 frames. It only
+		// waits for one turn of the bulk session, and the frames already in the output of the server, or being
+		// sent on the link, which are about 6 frames each.
+		n := frames.framesBefore(start, 2)
+		assert(n >= 0, IsTrue)
+		assert(n <= 16, IsTrue)
+	}
+
+	bulk.InboundInput().Close()
+	echo.InboundInput().Close()
+}
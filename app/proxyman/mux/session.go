@@ -1,13 +1,17 @@
 package mux
 
 import (
+	"io"
 	"sync"
 
 	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/errors"
 	"v2ray.com/core/common/protocol"
 	"v2ray.com/core/transport/ray"
 )
 
+var errConnectionClosed = newError("Mux connection closed")
+
 type SessionManager struct {
 	sync.RWMutex
 	sessions map[uint16]*Session
@@ -36,21 +40,25 @@ func (m *SessionManager) Count() int {
 	return int(m.count)
 }
 
-func (m *SessionManager) Allocate() *Session {
+// Allocate assigns a new ID to the given Session, and adds it to the SessionManager. Flow control of the Session is
+// set up with the given writer, if it is not nil. The Session must be ready for use, as it is visible to other
+// goroutines once added. It returns false if the SessionManager is closed.
+func (m *SessionManager) Allocate(s *Session, flowControl buf.Writer) bool {
 	m.Lock()
 	defer m.Unlock()
 
 	if m.closed {
-		return nil
+		return false
 	}
 
 	m.count++
-	s := &Session{
-		ID:     m.count,
-		parent: m,
+	s.ID = m.count
+	s.parent = m
+	if flowControl != nil {
+		s.enableFlowControl(flowControl)
 	}
 	m.sessions[s.ID] = s
-	return s
+	return true
 }
 
 func (m *SessionManager) Add(s *Session) {
@@ -103,6 +111,8 @@ func (m *SessionManager) CloseIfNoSession() bool {
 	return true
 }
 
+// Close closes the SessionManager when the Mux connection ends. Sessions that are still alive are closed with error,
+// as they end without finishing.
 func (m *SessionManager) Close() {
 	m.Lock()
 	defer m.Unlock()
@@ -114,8 +124,7 @@ func (m *SessionManager) Close() {
 	m.closed = true
 
 	for _, s := range m.sessions {
-		s.input.Close()
-		s.output.Close()
+		s.closeError(errConnectionClosed)
 	}
 
 	m.sessions = nil
@@ -128,11 +137,40 @@ type Session struct {
 	parent       *SessionManager
 	ID           uint16
 	transferType protocol.TransferType
+	window       *sendWindow
+	receiver     *receiver
+}
+
+// enableFlowControl sets up the flow control of this Session. Window updates are written into the given writer, which
+// is the underlying connection rather than the scheduler, so that they are never queued behind data frames.
+func (s *Session) enableFlowControl(writer buf.Writer) {
+	s.window = newSendWindow()
+	s.receiver = newReceiver(s.output, func(n uint32) {
+		if err := writeWindowUpdate(writer, s.ID, n); err != nil {
+			newError("failed to write window update").Base(err).AtDebug().WriteToLog()
+		}
+	})
+}
+
+// closeError closes the Session with error, discarding the data not delivered yet.
+func (s *Session) closeError(err error) {
+	if s.receiver != nil {
+		s.receiver.CloseError(err)
+	} else {
+		ray.CloseWithError(s.output, err)
+	}
+	s.window.Close()
+	s.input.CloseError()
 }
 
 // Close closes all resources associated with this session.
 func (s *Session) Close() {
-	s.output.Close()
+	if s.receiver != nil {
+		s.receiver.Close()
+	} else {
+		s.output.Close()
+	}
+	s.window.Close()
 	s.input.Close()
 	s.parent.Remove(s.ID)
 }
@@ -144,3 +182,42 @@ func (s *Session) NewReader(reader *buf.BufferedReader) buf.Reader {
 	}
 	return NewPacketReader(reader)
 }
+
+// newFrameReader creates a buf.Reader for the data of the given frame. Packets of frames with OptionAddress are
+// marked with the address of the frame.
+func (s *Session) newFrameReader(meta *FrameMetadata, reader *buf.BufferedReader) buf.Reader {
+	if s.transferType == protocol.TransferTypePacket && meta.SessionStatus == SessionStatusKeep && meta.Option.Has(OptionAddress) {
+		return &PacketReader{
+			reader: reader,
+			dest:   meta.Target,
+		}
+	}
+	return s.NewReader(reader)
+}
+
+// receive reads the data of a frame from the reader, and writes it into the output of this Session.
+func (s *Session) receive(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	// Data is queued only if the peer supports flow control, as otherwise the queue is not bounded.
+	if s.receiver == nil || !s.window.Enabled() {
+		return buf.Copy(s.newFrameReader(meta, reader), s.output, buf.IgnoreWriterError())
+	}
+
+	// With flow control, the data is read in full and queued, so that reading frames is never blocked.
+	frameReader := s.newFrameReader(meta, reader)
+	for {
+		mb, err := frameReader.ReadMultiBuffer()
+		if !mb.IsEmpty() {
+			if err := s.receiver.Push(mb); err != nil {
+				// Only this Session ends. The rest of the frame is discarded, as the receiver is closed.
+				newError("session ", s.ID, " ends").Base(err).AtWarning().WriteToLog()
+				s.closeError(err)
+			}
+		}
+		if errors.Cause(err) == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
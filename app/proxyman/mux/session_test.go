@@ -12,11 +12,13 @@ func TestSessionManagerAdd(t *testing.T) {
 
 	m := NewSessionManager()
 
-	s := m.Allocate()
+	s := &Session{}
+	assert(m.Allocate(s, nil), IsTrue)
 	assert(s.ID, Equals, uint16(1))
 	assert(m.Size(), Equals, 1)
 
-	s = m.Allocate()
+	s = &Session{}
+	assert(m.Allocate(s, nil), IsTrue)
 	assert(s.ID, Equals, uint16(2))
 	assert(m.Size(), Equals, 2)
 
@@ -31,9 +33,11 @@ func TestSessionManagerClose(t *testing.T) {
 	assert := With(t)
 
 	m := NewSessionManager()
-	s := m.Allocate()
+	s := &Session{}
+	assert(m.Allocate(s, nil), IsTrue)
 
 	assert(m.CloseIfNoSession(), IsFalse)
 	m.Remove(s.ID)
 	assert(m.CloseIfNoSession(), IsTrue)
+	assert(m.Allocate(&Session{}, nil), IsFalse)
 }
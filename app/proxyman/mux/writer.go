@@ -14,6 +14,10 @@ type Writer struct {
 	id           uint16
 	followup     bool
 	transferType protocol.TransferType
+	window       *sendWindow
+	maxFrameSize int
+	// packetAddress indicates that packets whose destination differs from dest are sent with their own address.
+	packetAddress bool
 }
 
 func NewWriter(id uint16, dest net.Destination, writer buf.Writer, transferType protocol.TransferType) *Writer {
@@ -23,6 +27,7 @@ func NewWriter(id uint16, dest net.Destination, writer buf.Writer, transferType
 		writer:       writer,
 		followup:     false,
 		transferType: transferType,
+		maxFrameSize: defaultMaxFrameSize,
 	}
 }
 
@@ -32,6 +37,7 @@ func NewResponseWriter(id uint16, writer buf.Writer, transferType protocol.Trans
 		writer:       writer,
 		followup:     true,
 		transferType: transferType,
+		maxFrameSize: defaultMaxFrameSize,
 	}
 }
 
@@ -45,6 +51,12 @@ func (w *Writer) getNextFrameMeta() FrameMetadata {
 	} else {
 		w.followup = true
 		meta.SessionStatus = SessionStatusNew
+		if w.window != nil {
+			meta.Option.Set(OptionFlowControl)
+		}
+		if w.packetAddress {
+			meta.Option.Set(OptionAddress)
+		}
 	}
 
 	return meta
@@ -62,6 +74,12 @@ func (w *Writer) writeMetaOnly() error {
 func (w *Writer) writeData(mb buf.MultiBuffer) error {
 	meta := w.getNextFrameMeta()
 	meta.Option.Set(OptionData)
+	if w.packetAddress && meta.SessionStatus == SessionStatusKeep {
+		if dest := mb[0].Destination(); dest.IsValid() && dest != w.dest {
+			meta.Option.Set(OptionAddress)
+			meta.Target = dest
+		}
+	}
 
 	frame := buf.New()
 	if err := frame.Reset(meta.AsSupplier()); err != nil {
@@ -86,12 +104,17 @@ func (w *Writer) WriteMultiBuffer(mb buf.MultiBuffer) error {
 	}
 
 	for !mb.IsEmpty() {
+		size, err := w.window.Wait(w.maxFrameSize)
+		if err != nil {
+			return err
+		}
 		var chunk buf.MultiBuffer
 		if w.transferType == protocol.TransferTypeStream {
-			chunk = mb.SliceBySize(8 * 1024)
+			chunk = mb.SliceBySize(size)
 		} else {
 			chunk = buf.NewMultiBufferValue(mb.SplitFirst())
 		}
+		w.window.Consume(chunk.Len())
 		if err := w.writeData(chunk); err != nil {
 			return err
 		}
@@ -111,3 +134,19 @@ func (w *Writer) Close() {
 
 	w.writer.WriteMultiBuffer(buf.NewMultiBufferValue(frame))
 }
+
+// writeWindowUpdate writes a frame that grants the peer n more bytes to send in the session.
+func writeWindowUpdate(writer buf.Writer, id uint16, n uint32) error {
+	meta := FrameMetadata{
+		SessionID:     id,
+		SessionStatus: SessionStatusKeep,
+		WindowUpdate:  n,
+	}
+	meta.Option.Set(OptionFlowControl)
+
+	frame := buf.New()
+	if err := frame.Reset(meta.AsSupplier()); err != nil {
+		return err
+	}
+	return writer.WriteMultiBuffer(buf.NewMultiBufferValue(frame))
+}
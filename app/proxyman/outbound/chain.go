@@ -0,0 +1,109 @@
+package outbound
+
+import (
+	"context"
+	"strings"
+
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/ray"
+)
+
+type key int
+
+const proxyChainKey key = iota
+
+// contextWithProxyChain returns a context for a handler in a chain. The handler tunnels through the given handlers,
+// instead of following its own proxy settings.
+func contextWithProxyChain(ctx context.Context, chain []string) context.Context {
+	return context.WithValue(ctx, proxyChainKey, chain)
+}
+
+func proxyChainFromContext(ctx context.Context) ([]string, bool) {
+	chain, ok := ctx.Value(proxyChainKey).([]string)
+	return chain, ok
+}
+
+// nextHop returns the tag of the handler that this handler tunnels through, and the context for that handler. The
+// tag is empty if this handler connects directly. chained is true if the tag comes from a chain.
+func (h *Handler) nextHop(ctx context.Context) (tag string, hopCtx context.Context, chained bool) {
+	chain, found := proxyChainFromContext(ctx)
+	if !found {
+		settings := h.senderSettings.GetProxySettings()
+		switch {
+		case settings.HasChain():
+			chain = settings.Chain
+		case settings.HasTag():
+			return settings.Tag, ctx, false
+		default:
+			return "", ctx, false
+		}
+	}
+
+	if len(chain) == 0 {
+		return "", ctx, true
+	}
+	last := len(chain) - 1
+	return chain[last], contextWithProxyChain(ctx, chain[:last]), true
+}
+
+// hopDialer creates connections through another outbound handler, for the transport of the next hop.
+type hopDialer struct {
+	ctx     context.Context
+	handler proxyman.OutboundHandler
+}
+
+// Dial implements internet.SystemDialer.
+func (d *hopDialer) Dial(ctx context.Context, src net.Address, dest net.Destination) (net.Conn, error) {
+	ctx = proxy.ContextWithTarget(d.ctx, dest)
+	stream := ray.NewRay(ctx)
+	go d.handler.Dispatch(ctx, stream)
+	return NewConnection(stream), nil
+}
+
+// checkProxySettings returns an error if the proxy settings of the given handler refer to missing handlers in a
+// chain, or form a cycle.
+func (m *Manager) checkProxySettings(h *Handler) error {
+	path := []string{h.config.Tag}
+	for {
+		settings := h.senderSettings.GetProxySettings()
+		if settings.HasChain() {
+			// Handlers in the chain ignore their own proxy settings, so a cycle can only come from the chain itself.
+			chain := []string{h.config.Tag}
+			for _, tag := range settings.Chain {
+				if containsTag(chain, tag) {
+					return newError("cycle in proxy chain: ", strings.Join(append(chain, tag), " -> "))
+				}
+				if _, found := m.taggedHandler[tag]; !found {
+					return newError("outbound handler in proxy chain not found: ", tag)
+				}
+				chain = append(chain, tag)
+			}
+			return nil
+		}
+		if !settings.HasTag() {
+			return nil
+		}
+
+		tag := settings.Tag
+		if containsTag(path, tag) {
+			return newError("cycle in proxy settings: ", strings.Join(append(path, tag), " -> "))
+		}
+		next, found := m.taggedHandler[tag]
+		if !found {
+			return nil
+		}
+		path = append(path, tag)
+		h = next
+	}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if len(t) > 0 && t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package outbound_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/policy"
+	_ "v2ray.com/core/app/policy/manager"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/transport/internet"
+	_ "v2ray.com/core/transport/internet/tcp"
+	"v2ray.com/core/transport/ray"
+	. "v2ray.com/ext/assert"
+)
+
+func newSpace(handlers ...*proxyman.OutboundHandlerConfig) (app.Space, error) {
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	for _, handler := range handlers {
+		common.Must(om.AddHandler(ctx, handler))
+	}
+	common.Must(space.Initialize())
+	return space, space.Start()
+}
+
+func proxyHandler(tag string, proxySettings *internet.ProxyConfig) *proxyman.OutboundHandlerConfig {
+	return &proxyman.OutboundHandlerConfig{
+		Tag:           tag,
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			ProxySettings: proxySettings,
+		}),
+	}
+}
+
+func TestProxySettingsCycle(t *testing.T) {
+	assert := With(t)
+
+	_, err := newSpace(
+		proxyHandler("exit", &internet.ProxyConfig{Tag: "relay"}),
+		proxyHandler("relay", &internet.ProxyConfig{Tag: "exit"}),
+	)
+	assert(err, IsNotNil)
+
+	_, err = newSpace(
+		proxyHandler("exit", &internet.ProxyConfig{Chain: []string{"relay1", "relay2", "relay1"}}),
+		proxyHandler("relay1", nil),
+		proxyHandler("relay2", nil),
+	)
+	assert(err, IsNotNil)
+
+	_, err = newSpace(
+		proxyHandler("exit", &internet.ProxyConfig{Chain: []string{"relay1", "relay3"}}),
+		proxyHandler("relay1", nil),
+	)
+	assert(err, IsNotNil)
+
+	// Handlers in a chain ignore their own proxy settings.
+	space, err := newSpace(
+		proxyHandler("exit", &internet.ProxyConfig{Chain: []string{"relay1", "relay2"}}),
+		proxyHandler("relay1", nil),
+		proxyHandler("relay2", &internet.ProxyConfig{Tag: "exit"}),
+	)
+	assert(err, IsNil)
+	space.Close()
+}
+
+func TestProxyChain(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	// The first hop sends everything to the echo server, so the unreachable target proves that the chain is used.
+	relay1 := proxyHandler("relay1", nil)
+	relay1.ProxySettings = serial.ToTypedMessage(&freedom.Config{
+		DestinationOverride: &freedom.DestinationOverride{
+			Server: &protocol.ServerEndpoint{
+				Address: net.NewIPOrDomain(dest.Address),
+				Port:    uint32(dest.Port),
+			},
+		},
+	})
+
+	space, err := newSpace(
+		proxyHandler("exit", &internet.ProxyConfig{Chain: []string{"relay1", "relay2"}}),
+		relay1,
+		proxyHandler("relay2", &internet.ProxyConfig{Tag: "exit"}),
+	)
+	assert(err, IsNil)
+	defer space.Close()
+
+	handler := proxyman.OutboundHandlerManagerFromSpace(space).GetHandler("exit")
+	ctx := proxy.ContextWithTarget(context.Background(), net.TCPDestination(net.LocalHostIP, 1))
+	link := ray.NewRay(ctx)
+	go handler.Dispatch(ctx, link)
+
+	b := buf.New()
+	b.Append([]byte("hello"))
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 5)
+	assert(err, IsNil)
+	assert(mb.Len(), Equals, 5)
+	assert(mb[0].String(), Equals, "hello")
+	link.InboundInput().Close()
+}
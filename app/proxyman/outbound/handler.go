@@ -11,6 +11,7 @@ import (
 	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/errors"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/proxyproto"
 	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/internet"
 	"v2ray.com/core/transport/ray"
@@ -22,6 +23,7 @@ type Handler struct {
 	proxy           proxy.Outbound
 	outboundManager proxyman.OutboundHandlerManager
 	mux             *mux.ClientManager
+	sourcePool      *sourcePool
 }
 
 func NewHandler(ctx context.Context, config *proxyman.OutboundHandlerConfig) (*Handler, error) {
@@ -59,11 +61,32 @@ func NewHandler(ctx context.Context, config *proxyman.OutboundHandlerConfig) (*H
 		return nil, err
 	}
 
+	if version := h.senderSettings.GetProxyProtocol(); version > 2 {
+		return nil, newError("invalid PROXY protocol version: ", version).AtWarning()
+	}
+
+	if pool := h.senderSettings.GetViaPool(); len(pool) > 0 {
+		p, err := newSourcePool(pool, h.senderSettings.ViaStrategy)
+		if err != nil {
+			return nil, err
+		}
+		h.sourcePool = p
+		if p.freebind {
+			h.senderSettings = withFreebind(h.senderSettings)
+		}
+	}
+
 	if h.senderSettings != nil && h.senderSettings.MultiplexSettings != nil && h.senderSettings.MultiplexSettings.Enabled {
 		config := h.senderSettings.MultiplexSettings
 		if config.Concurrency < 1 || config.Concurrency > 1024 {
 			return nil, newError("invalid mux concurrency: ", config.Concurrency).AtWarning()
 		}
+		if config.MaxSessions > 65535 {
+			return nil, newError("invalid mux max sessions: ", config.MaxSessions).AtWarning()
+		}
+		if config.MaxFrameSize > 65535 {
+			return nil, newError("invalid mux max frame size: ", config.MaxFrameSize).AtWarning()
+		}
 		h.mux = mux.NewClientManager(proxyHandler, h, config)
 	}
 
@@ -73,54 +96,130 @@ func NewHandler(ctx context.Context, config *proxyman.OutboundHandlerConfig) (*H
 
 // Dispatch implements proxy.Outbound.Dispatch.
 func (h *Handler) Dispatch(ctx context.Context, outboundRay ray.OutboundRay) {
-	if h.mux != nil {
-		err := h.mux.Dispatch(ctx, outboundRay)
-		if err != nil {
-			newError("failed to process outbound traffic").Base(err).WriteToLog()
-			outboundRay.OutboundOutput().CloseError()
+	// Mux connections are shared by all sessions, so they can't follow the proxy chain of a single session.
+	if _, chained := proxyChainFromContext(ctx); h.mux != nil && !chained {
+		if err := h.mux.Dispatch(ctx, outboundRay); err != nil {
+			h.fail(outboundRay, err)
 		}
 	} else {
 		err := h.proxy.Process(ctx, outboundRay, h)
-		// Ensure outbound ray is properly closed.
-		if err != nil && errors.Cause(err) != io.EOF {
-			newError("failed to process outbound traffic").Base(err).WriteToLog()
-			outboundRay.OutboundOutput().CloseError()
+		// Ensure outbound ray is properly closed. The connection is cancelled when it is idle or its inbound ends,
+		// which is not a failure.
+		if cause := errors.Cause(err); err != nil && cause != io.EOF && cause != context.Canceled {
+			h.fail(outboundRay, err)
 		} else {
+			if err != nil {
+				newError("outbound traffic ends").Base(err).AtDebug().WriteToLog()
+			}
 			outboundRay.OutboundOutput().Close()
 		}
 		outboundRay.OutboundInput().CloseError()
 	}
 }
 
+// fail closes the output of the outbound ray with the error that fails the outbound.
+func (h *Handler) fail(outboundRay ray.OutboundRay, err error) {
+	failure := newError("failed to process outbound traffic").Base(err)
+	failure.WriteToLog()
+	ray.CloseWithError(outboundRay.OutboundOutput(), failure)
+}
+
+// FallbackTags implements proxyman.FallbackOutboundHandler.
+func (h *Handler) FallbackTags() []string {
+	return h.config.FallbackTag
+}
+
 // Dial implements proxy.Dialer.Dial().
 func (h *Handler) Dial(ctx context.Context, dest net.Destination) (internet.Connection, error) {
-	if h.senderSettings != nil {
-		if h.senderSettings.ProxySettings.HasTag() {
-			tag := h.senderSettings.ProxySettings.Tag
-			handler := h.outboundManager.GetHandler(tag)
-			if handler != nil {
-				newError("proxying to ", tag, " for dest ", dest).AtDebug().WriteToLog()
-				ctx = proxy.ContextWithTarget(ctx, dest)
-				stream := ray.NewRay(ctx)
-				go handler.Dispatch(ctx, stream)
-				return NewConnection(stream), nil
-			}
-
+	if tag, hopCtx, chained := h.nextHop(ctx); len(tag) > 0 {
+		handler := h.outboundManager.GetHandler(tag)
+		switch {
+		case handler != nil:
+			newError("proxying to ", tag, " for dest ", dest).AtDebug().WriteToLog()
+			// The transport of this handler runs over the connection of the next hop.
+			ctx = internet.ContextWithTransportLayer(ctx, &hopDialer{
+				ctx:     hopCtx,
+				handler: handler,
+			})
+		case chained:
+			return nil, newError("failed to get outbound handler in proxy chain: ", tag).AtWarning()
+		default:
 			newError("failed to get outbound handler with tag: ", tag).AtWarning().WriteToLog()
 		}
+	}
 
-		if h.senderSettings.Via != nil {
-			ctx = internet.ContextWithDialerSource(ctx, h.senderSettings.Via.AsAddress())
+	if h.senderSettings != nil {
+		if h.sourcePool != nil && dest.Address.Family().IsDomain() {
+			// The source and the IPs of the domain must be of the same family.
+			src, ips := h.sourcePool.PickForDomain(ctx, dest)
+			if len(ips) > 0 {
+				ctx = internet.ContextWithDialerIPs(ctx, dest.Address.Domain(), ips)
+			}
+			if src != nil {
+				ctx = internet.ContextWithDialerSource(ctx, src)
+			}
+		} else if src := h.source(ctx, dest); src != nil {
+			ctx = internet.ContextWithDialerSource(ctx, src)
 		}
 
 		if h.senderSettings.StreamSettings != nil {
 			ctx = internet.ContextWithStreamSettings(ctx, h.senderSettings.StreamSettings)
 		}
 	}
+	// The header is always set, so that the header of a previous handler doesn't apply to this one.
+	ctx = internet.ContextWithProxyProtocol(ctx, h.proxyProtocolHeader(ctx))
 
 	return internet.Dial(ctx, dest)
 }
 
+// ListenPacket implements proxy.PacketListener.ListenPacket().
+func (h *Handler) ListenPacket(ctx context.Context) (net.PacketConn, error) {
+	if tag, _, _ := h.nextHop(ctx); len(tag) > 0 {
+		return nil, newError("full-cone UDP is not supported through proxy settings")
+	}
+
+	var src net.Address
+	if h.senderSettings != nil {
+		target, _ := proxy.TargetFromContext(ctx)
+		src = h.source(ctx, target)
+
+		if h.senderSettings.StreamSettings != nil {
+			ctx = internet.ContextWithStreamSettings(ctx, h.senderSettings.StreamSettings)
+		}
+	}
+	return internet.ListenSystemPacket(ctx, src)
+}
+
+// source returns the source address of a connection to dest, or nil for any address.
+func (h *Handler) source(ctx context.Context, dest net.Destination) net.Address {
+	if h.sourcePool != nil {
+		if dest.Address != nil && dest.Address.Family().IsDomain() {
+			src, _ := h.sourcePool.PickForDomain(ctx, dest)
+			return src
+		}
+		return h.sourcePool.Pick(ctx, dest)
+	}
+	if h.senderSettings.Via != nil {
+		return h.senderSettings.Via.AsAddress()
+	}
+	return nil
+}
+
+// proxyProtocolHeader returns the PROXY protocol header with the source of the inbound connection, or nil if the
+// handler sends no PROXY protocol header.
+func (h *Handler) proxyProtocolHeader(ctx context.Context) *proxyproto.Header {
+	if h.senderSettings == nil || h.senderSettings.ProxyProtocol == 0 {
+		return nil
+	}
+	header := &proxyproto.Header{
+		Version: byte(h.senderSettings.ProxyProtocol),
+	}
+	if source, ok := proxy.SourceFromContext(ctx); ok {
+		header.Source = source
+	}
+	return header
+}
+
 var (
 	_ buf.Reader = (*Connection)(nil)
 	_ buf.Writer = (*Connection)(nil)
@@ -0,0 +1,112 @@
+package outbound_test
+
+import (
+	"bufio"
+	"context"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/proxyproto"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/transport/internet"
+	"v2ray.com/core/transport/internet/tls"
+	. "v2ray.com/ext/assert"
+)
+
+func TestProxyProtocolWithTLS(t *testing.T) {
+	assert := With(t)
+
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.LocalHostIP.IP()})
+	assert(err, IsNil)
+	defer listener.Close()
+
+	type received struct {
+		header *proxyproto.Header
+		next   byte
+		err    error
+	}
+	result := make(chan received, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			result <- received{err: err}
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		header, err := proxyproto.ReadHeader(reader)
+		if err != nil {
+			result <- received{err: err}
+			return
+		}
+		next, err := reader.ReadByte()
+		result <- received{header: header, next: next, err: err}
+	}()
+
+	space, err := newSpace(&proxyman.OutboundHandlerConfig{
+		Tag:           "tls",
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			ProxyProtocol: 2,
+			StreamSettings: &internet.StreamConfig{
+				SecurityType: serial.GetMessageType(&tls.Config{}),
+				SecuritySettings: []*serial.TypedMessage{
+					serial.ToTypedMessage(&tls.Config{
+						ServerName:    "v2ray.com",
+						AllowInsecure: true,
+					}),
+				},
+			},
+		}),
+	})
+	assert(err, IsNil)
+	defer space.Close()
+
+	handler := proxyman.OutboundHandlerManagerFromSpace(space).GetHandler("tls")
+	source := net.TCPDestination(net.ParseAddress("1.2.3.4"), 5678)
+	ctx := proxy.ContextWithSource(context.Background(), source)
+	dest := net.DestinationFromAddr(listener.Addr())
+	conn, err := handler.(proxy.Dialer).Dial(ctx, dest)
+	assert(err, IsNil)
+	defer conn.Close()
+
+	// The TLS handshake starts with the first write, and fails as the server doesn't speak TLS.
+	go conn.Write([]byte("hello"))
+
+	select {
+	case r := <-result:
+		assert(r.err, IsNil)
+		assert(r.header.Source, Equals, source)
+		assert(r.header.Destination, Equals, dest)
+		// A TLS handshake record follows the header.
+		assert(r.next, Equals, byte(0x16))
+	case <-time.After(time.Second * 5):
+		t.Fatal("timeout")
+	}
+}
+
+func TestInvalidProxyProtocol(t *testing.T) {
+	assert := With(t)
+
+	ctx := app.ContextWithSpace(context.Background(), app.NewSpace())
+	for _, version := range []uint32{0, 1, 2, 3} {
+		_, err := outbound.NewHandler(ctx, &proxyman.OutboundHandlerConfig{
+			ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+				ProxyProtocol: version,
+			}),
+		})
+		if version <= 2 {
+			assert(err, IsNil)
+		} else {
+			assert(err, IsNotNil)
+		}
+	}
+}
@@ -29,8 +29,21 @@ func (*Manager) Interface() interface{} {
 	return (*proxyman.OutboundHandlerManager)(nil)
 }
 
-// Start implements Application.Start
-func (*Manager) Start() error { return nil }
+// Start implements Application.Start. It fails if the proxy settings of any handler form a cycle.
+func (m *Manager) Start() error {
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, handler := range m.taggedHandler {
+		if err := m.checkProxySettings(handler); err != nil {
+			return err
+		}
+	}
+	if m.defaultHandler != nil {
+		return m.checkProxySettings(m.defaultHandler)
+	}
+	return nil
+}
 
 // Close implements Application.Close
 func (*Manager) Close() {}
@@ -0,0 +1,202 @@
+package outbound
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"sync/atomic"
+
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/common/dice"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/transport/internet"
+)
+
+// sourcePool chooses the source addresses of connections from a pool of IPs and CIDRs.
+type sourcePool struct {
+	nets     []*net.IPNet
+	ipv4     []*net.IPNet
+	ipv6     []*net.IPNet
+	strategy proxyman.SenderConfig_ViaStrategy
+	counter  uint64
+	// freebind is true if the pool has networks, whose addresses are usually not configured on any interface.
+	freebind bool
+}
+
+func newSourcePool(pool []string, strategy proxyman.SenderConfig_ViaStrategy) (*sourcePool, error) {
+	p := &sourcePool{
+		strategy: strategy,
+	}
+	for _, s := range pool {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, newError("invalid IP in via pool: ", s)
+			}
+			if ipv4 := ip.To4(); ipv4 != nil {
+				ip = ipv4
+			}
+			p.nets = append(p.nets, &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(len(ip)*8, len(ip)*8),
+			})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, newError("invalid CIDR in via pool: ", s).Base(err)
+		}
+		p.nets = append(p.nets, ipNet)
+	}
+	if len(p.nets) == 0 {
+		return nil, newError("empty via pool")
+	}
+	for _, ipNet := range p.nets {
+		if ones, bits := ipNet.Mask.Size(); ones < bits {
+			p.freebind = true
+		}
+		if len(ipNet.IP) == net.IPv4len {
+			p.ipv4 = append(p.ipv4, ipNet)
+		} else {
+			p.ipv6 = append(p.ipv6, ipNet)
+		}
+	}
+	return p, nil
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// key returns the number that decides the address for a connection, by the strategy.
+func (p *sourcePool) key(ctx context.Context, dest net.Destination) uint64 {
+	switch p.strategy {
+	case proxyman.SenderConfig_RoundRobin:
+		return atomic.AddUint64(&p.counter, 1) - 1
+	case proxyman.SenderConfig_StickyDestination:
+		if dest.Address != nil {
+			return hashString(dest.Address.String())
+		}
+	case proxyman.SenderConfig_StickyUser:
+		if user := protocol.UserFromContext(ctx); user != nil && len(user.Email) > 0 {
+			return hashString(user.Email)
+		}
+	}
+	return dice.RollUint64()
+}
+
+// Pick returns the source address for a connection to dest in the given context. If dest is an IP, only addresses of
+// its family are picked, and nil is returned when the pool has none, so that the system chooses the source.
+func (p *sourcePool) Pick(ctx context.Context, dest net.Destination) net.Address {
+	nets := p.nets
+	if dest.Address != nil {
+		switch {
+		case dest.Address.Family().IsIPv4():
+			nets = p.ipv4
+		case dest.Address.Family().IsIPv6():
+			nets = p.ipv6
+		}
+	}
+	if len(nets) == 0 {
+		return nil
+	}
+	key := p.key(ctx, dest)
+	n := uint64(len(nets))
+	return net.IPAddress(hostInNet(nets[key%n], key/n))
+}
+
+// PickForDomain returns the source address for a connection to dest, whose address is a domain, and the IPs of the
+// domain to connect to from the source. The IPs are those set by internet.ContextWithDialerIPs(), or resolved
+// otherwise. The family of the first IP is preferred, and the other family is used if the pool has no address of it.
+// The source is nil if no IP is in the families of the pool, and the IPs are nil if the domain can't be resolved.
+func (p *sourcePool) PickForDomain(ctx context.Context, dest net.Destination) (net.Address, []net.Address) {
+	domain := dest.Address.Domain()
+	ips := internet.DialerIPsFromContext(ctx, domain)
+	if len(ips) == 0 {
+		resolved, err := net.LookupIPWithContext(ctx, domain)
+		if err != nil || len(resolved) == 0 {
+			newError("failed to resolve ", domain, " for via pool").Base(err).AtWarning().WriteToLog()
+			return nil, nil
+		}
+		for _, ip := range resolved {
+			ips = append(ips, net.IPAddress(ip))
+		}
+	}
+
+	var ipv4, ipv6 []net.Address
+	for _, ip := range ips {
+		if ip.Family().IsIPv4() {
+			ipv4 = append(ipv4, ip)
+		} else {
+			ipv6 = append(ipv6, ip)
+		}
+	}
+	families := [][]net.Address{ipv4, ipv6}
+	if ips[0].Family().IsIPv6() {
+		families = [][]net.Address{ipv6, ipv4}
+	}
+	for _, family := range families {
+		if len(family) == 0 {
+			continue
+		}
+		target := dest
+		target.Address = family[0]
+		if src := p.Pick(ctx, target); src != nil {
+			return src, family
+		}
+	}
+	return nil, ips
+}
+
+// withFreebind returns a copy of the settings, in which sockets may bind to addresses that are not configured on any
+// interface.
+func withFreebind(settings *proxyman.SenderConfig) *proxyman.SenderConfig {
+	streamSettings := &internet.StreamConfig{}
+	if settings.StreamSettings != nil {
+		*streamSettings = *settings.StreamSettings
+	}
+	socketSettings := &internet.SocketConfig{}
+	if streamSettings.SocketSettings != nil {
+		*socketSettings = *streamSettings.SocketSettings
+	}
+	socketSettings.Freebind = true
+	streamSettings.SocketSettings = socketSettings
+
+	s := *settings
+	s.StreamSettings = streamSettings
+	return &s
+}
+
+// hostInNet returns the IP of the given index in the network. If the network has other addresses, the network
+// address, and the broadcast address of IPv4, are skipped.
+func hostInNet(ipNet *net.IPNet, index uint64) net.IP {
+	ones, bits := ipNet.Mask.Size()
+	ip := make(net.IP, len(ipNet.IP))
+	copy(ip, ipNet.IP)
+
+	hostBits := uint(bits - ones)
+	if hostBits == 0 {
+		return ip
+	}
+	if hostBits > 63 {
+		hostBits = 63
+	}
+	first := uint64(0)
+	count := uint64(1) << hostBits
+	if hostBits >= 2 {
+		first = 1
+		count--
+		if len(ip) == net.IPv4len {
+			count--
+		}
+	}
+
+	offset := first + index%count
+	for i := 0; i < 8 && i < len(ip); i++ {
+		ip[len(ip)-1-i] |= byte(offset >> (8 * uint(i)))
+	}
+	return ip
+}
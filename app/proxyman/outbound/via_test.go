@@ -0,0 +1,137 @@
+package outbound_test
+
+import (
+	"context"
+	"testing"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/proxyman"
+	"v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/transport/internet"
+	. "v2ray.com/ext/assert"
+)
+
+func viaPoolHandler(pool []string, strategy proxyman.SenderConfig_ViaStrategy) *proxyman.OutboundHandlerConfig {
+	return &proxyman.OutboundHandlerConfig{
+		Tag:           "out",
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			ViaPool:     pool,
+			ViaStrategy: strategy,
+		}),
+	}
+}
+
+func dialSource(handler *outbound.Handler, dest net.Destination) string {
+	return dialSourceWithContext(context.Background(), handler, dest)
+}
+
+func dialSourceWithContext(ctx context.Context, handler *outbound.Handler, dest net.Destination) string {
+	conn, err := handler.Dial(ctx, dest)
+	if err != nil {
+		return err.Error()
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.TCPAddr).IP.String()
+}
+
+func TestViaPool(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	ctx := app.ContextWithSpace(context.Background(), app.NewSpace())
+	_, err = outbound.NewHandler(ctx, viaPoolHandler([]string{"127.0.0.x"}, proxyman.SenderConfig_Random))
+	assert(err, IsNotNil)
+
+	space, err := newSpace(viaPoolHandler([]string{"127.0.0.2", "127.0.0.3"}, proxyman.SenderConfig_RoundRobin))
+	assert(err, IsNil)
+	handler := proxyman.OutboundHandlerManagerFromSpace(space).GetHandler("out").(*outbound.Handler)
+	assert(dialSource(handler, dest), Equals, "127.0.0.2")
+	assert(dialSource(handler, dest), Equals, "127.0.0.3")
+	assert(dialSource(handler, dest), Equals, "127.0.0.2")
+	space.Close()
+
+	space, err = newSpace(viaPoolHandler([]string{"127.0.0.0/29"}, proxyman.SenderConfig_StickyDestination))
+	assert(err, IsNil)
+	handler = proxyman.OutboundHandlerManagerFromSpace(space).GetHandler("out").(*outbound.Handler)
+	source := dialSource(handler, dest)
+	assert(source, NotEquals, "127.0.0.0")
+	assert(source, NotEquals, "127.0.0.7")
+	for i := 0; i < 4; i++ {
+		assert(dialSource(handler, dest), Equals, source)
+	}
+	space.Close()
+}
+
+func TestViaPoolMixedFamilies(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	tcpServer6 := tcp.Server{Listen: net.LocalHostIPv6}
+	dest6, err := tcpServer6.Start()
+	assert(err, IsNil)
+	defer tcpServer6.Close()
+
+	space, err := newSpace(viaPoolHandler([]string{"127.0.0.2", "::1", "127.0.0.3"}, proxyman.SenderConfig_RoundRobin))
+	assert(err, IsNil)
+	defer space.Close()
+	handler := proxyman.OutboundHandlerManagerFromSpace(space).GetHandler("out").(*outbound.Handler)
+	for i := 0; i < 4; i++ {
+		assert(dialSource(handler, dest), NotEquals, "::1")
+		assert(dialSource(handler, dest6), Equals, "::1")
+	}
+
+	space4, err := newSpace(viaPoolHandler([]string{"127.0.0.2"}, proxyman.SenderConfig_Random))
+	assert(err, IsNil)
+	defer space4.Close()
+	handler = proxyman.OutboundHandlerManagerFromSpace(space4).GetHandler("out").(*outbound.Handler)
+	// Without an IPv6 address in the pool, the system chooses the source.
+	assert(dialSource(handler, dest6), Equals, "::1")
+}
+
+func TestViaPoolDomain(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	tcpServer6 := tcp.Server{Listen: net.LocalHostIPv6, Port: dest.Port}
+	_, err = tcpServer6.Start()
+	assert(err, IsNil)
+	defer tcpServer6.Close()
+
+	domain := net.TCPDestination(net.DomainAddress("v2ray.test"), dest.Port)
+	ipv4First := internet.ContextWithDialerIPs(context.Background(), "v2ray.test", []net.Address{net.LocalHostIP, net.LocalHostIPv6})
+	ipv6First := internet.ContextWithDialerIPs(context.Background(), "v2ray.test", []net.Address{net.LocalHostIPv6, net.LocalHostIP})
+
+	space, err := newSpace(viaPoolHandler([]string{"127.0.0.2", "::1"}, proxyman.SenderConfig_RoundRobin))
+	assert(err, IsNil)
+	defer space.Close()
+	handler := proxyman.OutboundHandlerManagerFromSpace(space).GetHandler("out").(*outbound.Handler)
+	// The source is of the family of the first IP of the domain, and only IPs of the family are dialed.
+	for i := 0; i < 4; i++ {
+		assert(dialSourceWithContext(ipv4First, handler, domain), Equals, "127.0.0.2")
+		assert(dialSourceWithContext(ipv6First, handler, domain), Equals, "::1")
+	}
+
+	space4, err := newSpace(viaPoolHandler([]string{"127.0.0.2"}, proxyman.SenderConfig_Random))
+	assert(err, IsNil)
+	defer space4.Close()
+	handler = proxyman.OutboundHandlerManagerFromSpace(space4).GetHandler("out").(*outbound.Handler)
+	// The other family is used if the pool has no address of the first one.
+	assert(dialSourceWithContext(ipv6First, handler, domain), Equals, "127.0.0.2")
+}
@@ -5,6 +5,7 @@ package proxyman
 
 import (
 	"context"
+	"strings"
 
 	"v2ray.com/core/app"
 	"v2ray.com/core/common/net"
@@ -25,6 +26,14 @@ type InboundHandler interface {
 	GetRandomInboundProxy() (proxy.Inbound, net.Port, int)
 }
 
+// ExternalInboundHandler is an InboundHandler whose ports are opened and closed by external controllers.
+type ExternalInboundHandler interface {
+	InboundHandler
+
+	// SetPorts sets the ports to listen on. Ports not in the list are closed.
+	SetPorts(ports []net.Port) error
+}
+
 type OutboundHandlerManager interface {
 	GetHandler(tag string) OutboundHandler
 	GetDefaultHandler() OutboundHandler
@@ -35,6 +44,14 @@ type OutboundHandler interface {
 	Dispatch(ctx context.Context, outboundRay ray.OutboundRay)
 }
 
+// FallbackOutboundHandler is an OutboundHandler with other handlers to try, if it fails before any response.
+type FallbackOutboundHandler interface {
+	OutboundHandler
+
+	// FallbackTags returns the tags of the handlers to try in order.
+	FallbackTags() []string
+}
+
 func InboundHandlerManagerFromSpace(space app.Space) InboundHandlerManager {
 	app := space.GetApplication((*InboundHandlerManager)(nil))
 	if app == nil {
@@ -57,13 +74,50 @@ const (
 	protocolsKey key = iota
 )
 
-func ContextWithProtocolSniffers(ctx context.Context, list []KnownProtocols) context.Context {
-	return context.WithValue(ctx, protocolsKey, list)
+// DomainOverride is the settings of overriding destinations with sniffed domains.
+type DomainOverride struct {
+	// Protocols to be sniffed.
+	Protocols []KnownProtocols
+	// RouteOnly indicates that sniffed domains are only used for routing.
+	RouteOnly bool
+	// Excluded domains are never used to override destinations.
+	Excluded []string
+}
+
+// NewDomainOverride creates a DomainOverride from the given ReceiverConfig. It returns nil if no protocol is sniffed.
+func NewDomainOverride(config *ReceiverConfig) *DomainOverride {
+	if len(config.DomainOverride) == 0 {
+		return nil
+	}
+	excluded := make([]string, 0, len(config.DomainOverrideExcluded))
+	for _, domain := range config.DomainOverrideExcluded {
+		excluded = append(excluded, strings.TrimSuffix(strings.ToLower(domain), "."))
+	}
+	return &DomainOverride{
+		Protocols: config.DomainOverride,
+		RouteOnly: config.DomainOverrideRouteOnly,
+		Excluded:  excluded,
+	}
+}
+
+// IsExcluded returns true if the given domain is, or is a subdomain of, any of the excluded domains.
+func (o *DomainOverride) IsExcluded(domain string) bool {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	for _, excluded := range o.Excluded {
+		if domain == excluded || strings.HasSuffix(domain, "."+excluded) {
+			return true
+		}
+	}
+	return false
+}
+
+func ContextWithDomainOverride(ctx context.Context, override *DomainOverride) context.Context {
+	return context.WithValue(ctx, protocolsKey, override)
 }
 
-func ProtocoSniffersFromContext(ctx context.Context) []KnownProtocols {
-	if list, ok := ctx.Value(protocolsKey).([]KnownProtocols); ok {
-		return list
+func DomainOverrideFromContext(ctx context.Context) *DomainOverride {
+	if override, ok := ctx.Value(protocolsKey).(*DomainOverride); ok {
+		return override
 	}
 	return nil
 }
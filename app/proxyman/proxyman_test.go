@@ -0,0 +1,26 @@
+package proxyman_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/app/proxyman"
+	. "v2ray.com/ext/assert"
+)
+
+func TestDomainOverride(t *testing.T) {
+	assert := With(t)
+
+	assert(NewDomainOverride(&ReceiverConfig{}), IsNil)
+
+	override := NewDomainOverride(&ReceiverConfig{
+		DomainOverride:          []KnownProtocols{KnownProtocols_TLS},
+		DomainOverrideRouteOnly: true,
+		DomainOverrideExcluded:  []string{"Apple.com", "v2ray.com."},
+	})
+	assert(override.RouteOnly, IsTrue)
+	assert(override.IsExcluded("apple.com"), IsTrue)
+	assert(override.IsExcluded("www.apple.com"), IsTrue)
+	assert(override.IsExcluded("WWW.V2RAY.COM."), IsTrue)
+	assert(override.IsExcluded("pineapple.com"), IsFalse)
+	assert(override.IsExcluded("google.com"), IsFalse)
+}
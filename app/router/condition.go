@@ -426,3 +426,33 @@ func (v *InboundTagMatcher) Apply(ctx context.Context) bool {
 	}
 	return false
 }
+
+type ProtocolMatcher struct {
+	protocols []string
+}
+
+func NewProtocolMatcher(protocols []string) *ProtocolMatcher {
+	pCopy := make([]string, 0, len(protocols))
+	for _, p := range protocols {
+		if len(p) > 0 {
+			pCopy = append(pCopy, strings.ToLower(p))
+		}
+	}
+	return &ProtocolMatcher{
+		protocols: pCopy,
+	}
+}
+
+func (m *ProtocolMatcher) Apply(ctx context.Context) bool {
+	protocol, ok := proxy.SniffedProtocolFromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	for _, p := range m.protocols {
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
@@ -177,6 +177,25 @@ func TestRoutingRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			rule: &RoutingRule{
+				Protocol: []string{"BitTorrent"},
+			},
+			test: []ruleTest{
+				{
+					input:  proxy.ContextWithSniffedProtocol(context.Background(), "bittorrent"),
+					output: true,
+				},
+				{
+					input:  proxy.ContextWithSniffedProtocol(context.Background(), "quic"),
+					output: false,
+				},
+				{
+					input:  context.Background(),
+					output: false,
+				},
+			},
+		},
 	}
 
 	for _, test := range cases {
@@ -9,6 +9,8 @@ import (
 type Rule struct {
 	Tag       string
 	Condition Condition
+	// Fallback is the tags of outbound handlers to try in order, if the outbound handler of Tag fails to connect.
+	Fallback []string
 }
 
 func (r *Rule) Apply(ctx context.Context) bool {
@@ -91,6 +93,10 @@ func (rr *RoutingRule) BuildCondition() (Condition, error) {
 		conds.Add(NewInboundTagMatcher(rr.InboundTag))
 	}
 
+	if len(rr.Protocol) > 0 {
+		conds.Add(NewProtocolMatcher(rr.Protocol))
+	}
+
 	if conds.Len() == 0 {
 		return nil, newError("this rule has no effective fields").AtWarning()
 	}
@@ -219,6 +219,10 @@ type RoutingRule struct {
 	SourceCidr  []*CIDR                             `protobuf:"bytes,6,rep,name=source_cidr,json=sourceCidr" json:"source_cidr,omitempty"`
 	UserEmail   []string                            `protobuf:"bytes,7,rep,name=user_email,json=userEmail" json:"user_email,omitempty"`
 	InboundTag  []string                            `protobuf:"bytes,8,rep,name=inbound_tag,json=inboundTag" json:"inbound_tag,omitempty"`
+	// Protocols detected by sniffing, such as "http", "tls", "quic" and "bittorrent".
+	Protocol []string `protobuf:"bytes,9,rep,name=protocol" json:"protocol,omitempty"`
+	// Tags of outbound handlers to try in order, if the outbound handler of this rule fails to connect.
+	FallbackTag []string `protobuf:"bytes,10,rep,name=fallback_tag,json=fallbackTag" json:"fallback_tag,omitempty"`
 }
 
 func (m *RoutingRule) Reset()                    { *m = RoutingRule{} }
@@ -282,6 +286,20 @@ func (m *RoutingRule) GetInboundTag() []string {
 	return nil
 }
 
+func (m *RoutingRule) GetProtocol() []string {
+	if m != nil {
+		return m.Protocol
+	}
+	return nil
+}
+
+func (m *RoutingRule) GetFallbackTag() []string {
+	if m != nil {
+		return m.FallbackTag
+	}
+	return nil
+}
+
 type Config struct {
 	DomainStrategy Config_DomainStrategy `protobuf:"varint,1,opt,name=domain_strategy,json=domainStrategy,enum=v2ray.core.app.router.Config_DomainStrategy" json:"domain_strategy,omitempty"`
 	Rule           []*RoutingRule        `protobuf:"bytes,2,rep,name=rule" json:"rule,omitempty"`
@@ -322,45 +340,47 @@ func init() {
 func init() { proto.RegisterFile("v2ray.com/core/app/router/config.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 640 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0xcd, 0x6e, 0xd4, 0x3a,
-	0x14, 0xc7, 0x6f, 0xe6, 0xab, 0x9d, 0x93, 0xb9, 0x73, 0x23, 0xeb, 0x16, 0x0d, 0x85, 0xc2, 0x10,
-	0x21, 0x98, 0x05, 0x4a, 0xa4, 0xe1, 0x63, 0x05, 0xaa, 0xca, 0xb4, 0xaa, 0x22, 0x41, 0x19, 0xb9,
-	0x2d, 0x0b, 0x58, 0x44, 0x6e, 0xe2, 0x86, 0x88, 0x89, 0x6d, 0x39, 0x4e, 0xe9, 0xec, 0x78, 0x01,
-	0x5e, 0x84, 0xa7, 0xe2, 0x51, 0x90, 0xed, 0x0c, 0xb4, 0xa8, 0x81, 0x8a, 0x9d, 0xed, 0xfc, 0xfe,
-	0xe7, 0xfc, 0x73, 0x7c, 0x8e, 0xe1, 0xc1, 0xd9, 0x54, 0x92, 0x65, 0x90, 0xf0, 0x22, 0x4c, 0xb8,
-	0xa4, 0x21, 0x11, 0x22, 0x94, 0xbc, 0x52, 0x54, 0x86, 0x09, 0x67, 0xa7, 0x79, 0x16, 0x08, 0xc9,
-	0x15, 0x47, 0x1b, 0x2b, 0x4e, 0xd2, 0x80, 0x08, 0x11, 0x58, 0x66, 0xf3, 0xfe, 0x2f, 0xf2, 0x84,
-	0x17, 0x05, 0x67, 0x21, 0xa3, 0x2a, 0x14, 0x5c, 0x2a, 0x2b, 0xde, 0x7c, 0xd8, 0x4c, 0x31, 0xaa,
-	0x3e, 0x71, 0xf9, 0xd1, 0x82, 0xfe, 0x67, 0x07, 0x7a, 0xbb, 0xbc, 0x20, 0x39, 0x43, 0xcf, 0xa0,
-	0xa3, 0x96, 0x82, 0x8e, 0x9c, 0xb1, 0x33, 0x19, 0x4e, 0xfd, 0xe0, 0xca, 0xfc, 0x81, 0x85, 0x83,
-	0xa3, 0xa5, 0xa0, 0xd8, 0xf0, 0xe8, 0x7f, 0xe8, 0x9e, 0x91, 0x45, 0x45, 0x47, 0xad, 0xb1, 0x33,
-	0xe9, 0x63, 0xbb, 0xf1, 0x27, 0xd0, 0xd1, 0x0c, 0xea, 0x43, 0x77, 0xbe, 0x20, 0x39, 0xf3, 0xfe,
-	0xd1, 0x4b, 0x4c, 0x33, 0x7a, 0xee, 0x39, 0x08, 0x56, 0x59, 0xbd, 0x96, 0x1f, 0x40, 0x67, 0x16,
-	0xed, 0x62, 0x34, 0x84, 0x56, 0x2e, 0x4c, 0xf6, 0x01, 0x6e, 0xe5, 0x02, 0xdd, 0x80, 0x9e, 0x90,
-	0xf4, 0x34, 0x3f, 0x37, 0x81, 0xff, 0xc5, 0xf5, 0xce, 0x7f, 0x0f, 0xdd, 0x7d, 0xca, 0xa3, 0x39,
-	0xba, 0x07, 0x83, 0x84, 0x57, 0x4c, 0xc9, 0x65, 0x9c, 0xf0, 0xd4, 0x1a, 0xef, 0x63, 0xb7, 0x3e,
-	0x9b, 0xf1, 0x94, 0xa2, 0x10, 0x3a, 0x49, 0x9e, 0xca, 0x51, 0x6b, 0xdc, 0x9e, 0xb8, 0xd3, 0x5b,
-	0x0d, 0xff, 0xa4, 0xd3, 0x63, 0x03, 0xfa, 0xdb, 0xd0, 0x37, 0xc1, 0x5f, 0xe5, 0xa5, 0x42, 0x53,
-	0xe8, 0x52, 0x1d, 0x6a, 0xe4, 0x18, 0xf9, 0xed, 0x06, 0xb9, 0x11, 0x60, 0x8b, 0xfa, 0x09, 0xac,
-	0xed, 0x53, 0x7e, 0x98, 0x2b, 0x7a, 0x1d, 0x7f, 0x4f, 0xa1, 0x97, 0x9a, 0x3a, 0xd4, 0x0e, 0xb7,
-	0x7e, 0x5b, 0x75, 0x5c, 0xc3, 0xfe, 0x0c, 0xdc, 0x3a, 0x89, 0xf1, 0xf9, 0xe4, 0xb2, 0xcf, 0x3b,
-	0xcd, 0x3e, 0xb5, 0x64, 0xe5, 0xf4, 0x4b, 0x1b, 0x5c, 0xcc, 0x2b, 0x95, 0xb3, 0x0c, 0x57, 0x0b,
-	0x8a, 0x3c, 0x68, 0x2b, 0x92, 0xd5, 0x2e, 0xf5, 0xf2, 0x2f, 0xdd, 0xfd, 0x28, 0x7a, 0xfb, 0x9a,
-	0x45, 0x47, 0xdb, 0x00, 0xba, 0x77, 0x63, 0x49, 0x58, 0x46, 0x47, 0x9d, 0xb1, 0x33, 0x71, 0xa7,
-	0xe3, 0x8b, 0x32, 0xdb, 0xbe, 0x01, 0xa3, 0x2a, 0x98, 0x73, 0xa9, 0xb0, 0xe6, 0x70, 0x5f, 0xac,
-	0x96, 0x68, 0x0f, 0x06, 0x75, 0x5b, 0xc7, 0x8b, 0xbc, 0x54, 0xa3, 0xae, 0x09, 0xe1, 0x37, 0x84,
-	0x38, 0xb0, 0xa8, 0x2e, 0x1d, 0x76, 0xd9, 0xcf, 0x0d, 0x7a, 0x0e, 0x6e, 0xc9, 0x2b, 0x99, 0xd0,
-	0xd8, 0xf8, 0xef, 0xfd, 0xd9, 0x3f, 0x58, 0x7e, 0xa6, 0xff, 0x62, 0x0b, 0xa0, 0x2a, 0xa9, 0x8c,
-	0x69, 0x41, 0xf2, 0xc5, 0x68, 0x6d, 0xdc, 0x9e, 0xf4, 0x71, 0x5f, 0x9f, 0xec, 0xe9, 0x03, 0x74,
-	0x17, 0xdc, 0x9c, 0x9d, 0xf0, 0x8a, 0xa5, 0xb1, 0x2e, 0xf3, 0xba, 0xf9, 0x0e, 0xf5, 0xd1, 0x11,
-	0xc9, 0xfc, 0x6f, 0x0e, 0xf4, 0x66, 0xe6, 0x05, 0x40, 0xc7, 0xf0, 0x9f, 0xad, 0x65, 0x5c, 0x2a,
-	0x49, 0x14, 0xcd, 0x96, 0xf5, 0x54, 0x3e, 0x6a, 0x32, 0x63, 0x5f, 0x0e, 0x7b, 0x11, 0x87, 0xb5,
-	0x06, 0x0f, 0xd3, 0x4b, 0x7b, 0x3d, 0xe1, 0xb2, 0x5a, 0xd0, 0xfa, 0x36, 0x9b, 0x26, 0xfc, 0x42,
-	0x4f, 0x60, 0xc3, 0xfb, 0xfb, 0x30, 0xbc, 0x1c, 0x19, 0xad, 0x43, 0x67, 0xa7, 0x8c, 0x4a, 0x3b,
-	0xd4, 0xc7, 0x25, 0x8d, 0x84, 0xe7, 0x20, 0x0f, 0x06, 0x91, 0x88, 0x4e, 0x0f, 0x38, 0x7b, 0x4d,
-	0x54, 0xf2, 0xc1, 0x6b, 0xa1, 0x21, 0x40, 0x24, 0xde, 0xb0, 0x5d, 0x5a, 0x10, 0x96, 0x7a, 0xed,
-	0x97, 0x2f, 0xe0, 0x66, 0xc2, 0x8b, 0xab, 0xf3, 0xce, 0x9d, 0x77, 0x3d, 0xbb, 0xfa, 0xda, 0xda,
-	0x78, 0x3b, 0xc5, 0x64, 0x19, 0xcc, 0x34, 0xb1, 0x23, 0x84, 0xb1, 0x44, 0xe5, 0x49, 0xcf, 0xbc,
-	0x59, 0x8f, 0xbf, 0x07, 0x00, 0x00, 0xff, 0xff, 0x53, 0x7c, 0xa8, 0x94, 0x43, 0x05, 0x00, 0x00,
+	// 666 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xdd, 0x6e, 0xd4, 0x3a,
+	0x10, 0x3e, 0xd9, 0xbf, 0x36, 0x93, 0x3d, 0x7b, 0x22, 0xeb, 0x14, 0x85, 0x42, 0x61, 0x89, 0x10,
+	0xec, 0x05, 0xca, 0x4a, 0xcb, 0xcf, 0x15, 0xa8, 0x2a, 0xdb, 0xaa, 0x5a, 0x09, 0xca, 0xca, 0x6d,
+	0xb9, 0x80, 0x8b, 0xc8, 0x4d, 0xbc, 0x21, 0x6a, 0x62, 0x5b, 0x8e, 0x53, 0xba, 0x77, 0x3c, 0x0f,
+	0xcf, 0xc2, 0x43, 0xf0, 0x28, 0xc8, 0x4e, 0xb6, 0xb4, 0xa8, 0x81, 0x8a, 0xbb, 0x99, 0xf1, 0xf7,
+	0xcd, 0x7c, 0x1e, 0x8f, 0x07, 0x1e, 0x9d, 0x4d, 0x24, 0x59, 0x06, 0x11, 0xcf, 0xc7, 0x11, 0x97,
+	0x74, 0x4c, 0x84, 0x18, 0x4b, 0x5e, 0x2a, 0x2a, 0xc7, 0x11, 0x67, 0x8b, 0x34, 0x09, 0x84, 0xe4,
+	0x8a, 0xa3, 0x8d, 0x15, 0x4e, 0xd2, 0x80, 0x08, 0x11, 0x54, 0x98, 0xcd, 0x87, 0xbf, 0xd0, 0x23,
+	0x9e, 0xe7, 0x9c, 0x8d, 0x19, 0x55, 0x63, 0xc1, 0xa5, 0xaa, 0xc8, 0x9b, 0x8f, 0x9b, 0x51, 0x8c,
+	0xaa, 0xcf, 0x5c, 0x9e, 0x56, 0x40, 0xff, 0x8b, 0x05, 0xbd, 0x5d, 0x9e, 0x93, 0x94, 0xa1, 0x17,
+	0xd0, 0x51, 0x4b, 0x41, 0x3d, 0x6b, 0x68, 0x8d, 0x06, 0x13, 0x3f, 0xb8, 0xb6, 0x7e, 0x50, 0x81,
+	0x83, 0xa3, 0xa5, 0xa0, 0xd8, 0xe0, 0xd1, 0xff, 0xd0, 0x3d, 0x23, 0x59, 0x49, 0xbd, 0xd6, 0xd0,
+	0x1a, 0xd9, 0xb8, 0x72, 0xfc, 0x11, 0x74, 0x34, 0x06, 0xd9, 0xd0, 0x9d, 0x67, 0x24, 0x65, 0xee,
+	0x3f, 0xda, 0xc4, 0x34, 0xa1, 0xe7, 0xae, 0x85, 0x60, 0x55, 0xd5, 0x6d, 0xf9, 0x01, 0x74, 0xa6,
+	0xb3, 0x5d, 0x8c, 0x06, 0xd0, 0x4a, 0x85, 0xa9, 0xde, 0xc7, 0xad, 0x54, 0xa0, 0x5b, 0xd0, 0x13,
+	0x92, 0x2e, 0xd2, 0x73, 0x93, 0xf8, 0x5f, 0x5c, 0x7b, 0xfe, 0x47, 0xe8, 0xee, 0x53, 0x3e, 0x9b,
+	0xa3, 0x07, 0xd0, 0x8f, 0x78, 0xc9, 0x94, 0x5c, 0x86, 0x11, 0x8f, 0x2b, 0xe1, 0x36, 0x76, 0xea,
+	0xd8, 0x94, 0xc7, 0x14, 0x8d, 0xa1, 0x13, 0xa5, 0xb1, 0xf4, 0x5a, 0xc3, 0xf6, 0xc8, 0x99, 0xdc,
+	0x69, 0xb8, 0x93, 0x2e, 0x8f, 0x0d, 0xd0, 0xdf, 0x06, 0xdb, 0x24, 0x7f, 0x93, 0x16, 0x0a, 0x4d,
+	0xa0, 0x4b, 0x75, 0x2a, 0xcf, 0x32, 0xf4, 0xbb, 0x0d, 0x74, 0x43, 0xc0, 0x15, 0xd4, 0x8f, 0x60,
+	0x6d, 0x9f, 0xf2, 0xc3, 0x54, 0xd1, 0x9b, 0xe8, 0x7b, 0x0e, 0xbd, 0xd8, 0xf4, 0xa1, 0x56, 0xb8,
+	0xf5, 0xdb, 0xae, 0xe3, 0x1a, 0xec, 0x4f, 0xc1, 0xa9, 0x8b, 0x18, 0x9d, 0xcf, 0xae, 0xea, 0xbc,
+	0xd7, 0xac, 0x53, 0x53, 0x56, 0x4a, 0xbf, 0xb5, 0xc1, 0xc1, 0xbc, 0x54, 0x29, 0x4b, 0x70, 0x99,
+	0x51, 0xe4, 0x42, 0x5b, 0x91, 0xa4, 0x56, 0xa9, 0xcd, 0xbf, 0x54, 0x77, 0xd1, 0xf4, 0xf6, 0x0d,
+	0x9b, 0x8e, 0xb6, 0x01, 0xf4, 0xec, 0x86, 0x92, 0xb0, 0x84, 0x7a, 0x9d, 0xa1, 0x35, 0x72, 0x26,
+	0xc3, 0xcb, 0xb4, 0x6a, 0x7c, 0x03, 0x46, 0x55, 0x30, 0xe7, 0x52, 0x61, 0x8d, 0xc3, 0xb6, 0x58,
+	0x99, 0x68, 0x0f, 0xfa, 0xf5, 0x58, 0x87, 0x59, 0x5a, 0x28, 0xaf, 0x6b, 0x52, 0xf8, 0x0d, 0x29,
+	0x0e, 0x2a, 0xa8, 0x6e, 0x1d, 0x76, 0xd8, 0x4f, 0x07, 0xbd, 0x04, 0xa7, 0xe0, 0xa5, 0x8c, 0x68,
+	0x68, 0xf4, 0xf7, 0xfe, 0xac, 0x1f, 0x2a, 0xfc, 0x54, 0xdf, 0x62, 0x0b, 0xa0, 0x2c, 0xa8, 0x0c,
+	0x69, 0x4e, 0xd2, 0xcc, 0x5b, 0x1b, 0xb6, 0x47, 0x36, 0xb6, 0x75, 0x64, 0x4f, 0x07, 0xd0, 0x7d,
+	0x70, 0x52, 0x76, 0xc2, 0x4b, 0x16, 0x87, 0xba, 0xcd, 0xeb, 0xe6, 0x1c, 0xea, 0xd0, 0x11, 0x49,
+	0xd0, 0x26, 0xac, 0x9b, 0x3f, 0x19, 0xf1, 0xcc, 0xb3, 0xcd, 0xe9, 0x85, 0xaf, 0x47, 0x69, 0x41,
+	0xb2, 0xec, 0x84, 0x44, 0xa7, 0x86, 0x0d, 0xe6, 0xdc, 0x59, 0xc5, 0x8e, 0x48, 0xe2, 0x7f, 0xb7,
+	0xa0, 0x37, 0x35, 0x0b, 0x04, 0x1d, 0xc3, 0x7f, 0xd5, 0x53, 0x84, 0x85, 0x92, 0x44, 0xd1, 0x64,
+	0x59, 0x7f, 0xea, 0x27, 0x4d, 0x77, 0x31, 0xbc, 0xfa, 0x1d, 0x0f, 0x6b, 0x0e, 0x1e, 0xc4, 0x57,
+	0x7c, 0xbd, 0x20, 0x64, 0x99, 0xd1, 0x7a, 0x18, 0x9a, 0x16, 0xc4, 0xa5, 0x91, 0xc2, 0x06, 0xef,
+	0xef, 0xc3, 0xe0, 0x6a, 0x66, 0xb4, 0x0e, 0x9d, 0x9d, 0x62, 0x56, 0x54, 0x3b, 0xe1, 0xb8, 0xa0,
+	0x33, 0xe1, 0x5a, 0xc8, 0x85, 0xfe, 0x4c, 0xcc, 0x16, 0x07, 0x9c, 0xbd, 0x25, 0x2a, 0xfa, 0xe4,
+	0xb6, 0xd0, 0x00, 0x60, 0x26, 0xde, 0xb1, 0x5d, 0x9a, 0x13, 0x16, 0xbb, 0xed, 0xd7, 0xaf, 0xe0,
+	0x76, 0xc4, 0xf3, 0xeb, 0xeb, 0xce, 0xad, 0x0f, 0xbd, 0xca, 0xfa, 0xda, 0xda, 0x78, 0x3f, 0xc1,
+	0x64, 0x19, 0x4c, 0x35, 0x62, 0x47, 0x08, 0x23, 0x89, 0xca, 0x93, 0x9e, 0x69, 0xe7, 0xd3, 0x1f,
+	0x03, 0x00, 0xce, 0x6b, 0xe0, 0xf2, 0x82, 0x05, 0x00, 0x00,
 }
@@ -33,6 +33,7 @@ func NewRouter(ctx context.Context, config *Config) (*Router, error) {
 	space.On(app.SpaceInitializing, func(interface{}) error {
 		for idx, rule := range config.Rule {
 			r.rules[idx].Tag = rule.Tag
+			r.rules[idx].Fallback = rule.FallbackTag
 			cond, err := rule.BuildCondition()
 			if err != nil {
 				return err
@@ -45,6 +46,7 @@ func NewRouter(ctx context.Context, config *Config) (*Router, error) {
 }
 
 type ipResolver struct {
+	ctx      context.Context
 	ip       []net.Address
 	domain   string
 	resolved bool
@@ -57,7 +59,7 @@ func (r *ipResolver) Resolve() []net.Address {
 
 	newError("looking for IP for domain: ", r.domain).WriteToLog()
 	r.resolved = true
-	ips, err := net.LookupIP(r.domain)
+	ips, err := net.LookupIPWithContext(r.ctx, r.domain)
 	if err != nil {
 		newError("failed to get IP address").Base(err).WriteToLog()
 	}
@@ -71,8 +73,20 @@ func (r *ipResolver) Resolve() []net.Address {
 	return r.ip
 }
 
+// TakeDetour returns the outbound tag of the first rule that applies to the connection in the given context.
 func (r *Router) TakeDetour(ctx context.Context) (string, error) {
-	resolver := &ipResolver{}
+	rule, err := r.PickRule(ctx)
+	if err != nil {
+		return "", err
+	}
+	return rule.Tag, nil
+}
+
+// PickRule returns the first rule that applies to the connection in the given context.
+func (r *Router) PickRule(ctx context.Context) (*Rule, error) {
+	resolver := &ipResolver{
+		ctx: ctx,
+	}
 	if r.domainStrategy == Config_IpOnDemand {
 		if dest, ok := proxy.TargetFromContext(ctx); ok && dest.Address.Family().IsDomain() {
 			resolver.domain = dest.Address.Domain()
@@ -80,15 +94,15 @@ func (r *Router) TakeDetour(ctx context.Context) (string, error) {
 		}
 	}
 
-	for _, rule := range r.rules {
-		if rule.Apply(ctx) {
-			return rule.Tag, nil
+	for idx := range r.rules {
+		if r.rules[idx].Apply(ctx) {
+			return &r.rules[idx], nil
 		}
 	}
 
 	dest, ok := proxy.TargetFromContext(ctx)
 	if !ok {
-		return "", ErrNoRuleApplicable
+		return nil, ErrNoRuleApplicable
 	}
 
 	if r.domainStrategy == Config_IpIfNonMatch && dest.Address.Family().IsDomain() {
@@ -96,15 +110,15 @@ func (r *Router) TakeDetour(ctx context.Context) (string, error) {
 		ips := resolver.Resolve()
 		if len(ips) > 0 {
 			ctx = proxy.ContextWithResolveIPs(ctx, resolver)
-			for _, rule := range r.rules {
-				if rule.Apply(ctx) {
-					return rule.Tag, nil
+			for idx := range r.rules {
+				if r.rules[idx].Apply(ctx) {
+					return &r.rules[idx], nil
 				}
 			}
 		}
 	}
 
-	return "", ErrNoRuleApplicable
+	return nil, ErrNoRuleApplicable
 }
 
 func (*Router) Interface() interface{} {
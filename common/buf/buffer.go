@@ -3,6 +3,8 @@ package buf
 
 import (
 	"io"
+
+	"v2ray.com/core/common/net"
 )
 
 // Supplier is a writer that writes contents into the given buffer.
@@ -17,6 +19,8 @@ type Buffer struct {
 
 	start int
 	end   int
+
+	dest net.Destination
 }
 
 // Release recycles the buffer into an internal buffer pool.
@@ -31,6 +35,7 @@ func (b *Buffer) Release() {
 	b.pool = nil
 	b.start = 0
 	b.end = 0
+	b.dest = net.Destination{}
 }
 
 // Clear clears the content of the buffer, results an empty buffer with
@@ -69,6 +74,17 @@ func (b *Buffer) SetByte(index int, value byte) {
 	b.v[b.start+index] = value
 }
 
+// SetDestination sets the address that the content of this Buffer is sent to, or received from. It is used for
+// packets that don't share the destination of their connection.
+func (b *Buffer) SetDestination(dest net.Destination) {
+	b.dest = dest
+}
+
+// Destination returns the address set by SetDestination(). The returned Destination is invalid if it was not set.
+func (b *Buffer) Destination() net.Destination {
+	return b.dest
+}
+
 // Bytes returns the content bytes of this Buffer.
 func (b *Buffer) Bytes() []byte {
 	return b.v[b.start:b.end]
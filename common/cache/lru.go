@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+type lruEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// Lru is a fixed size cache that evicts the least recently used entry when it is full.
+// It is safe for concurrent use.
+type Lru struct {
+	sync.Mutex
+	capacity int
+	list     *list.List
+	elements map[interface{}]*list.Element
+	onEvict  func(key, value interface{})
+}
+
+// NewLru creates a new Lru with the given capacity. Capacity must be positive.
+func NewLru(capacity int) *Lru {
+	if capacity <= 0 {
+		panic("Cache: capacity must be positive.")
+	}
+	return &Lru{
+		capacity: capacity,
+		list:     list.New(),
+		elements: make(map[interface{}]*list.Element),
+	}
+}
+
+// OnEvict sets a callback that is called whenever an entry is removed from the cache,
+// either by eviction or by Remove(). The callback is called with the cache locked.
+func (l *Lru) OnEvict(f func(key, value interface{})) {
+	l.Lock()
+	l.onEvict = f
+	l.Unlock()
+}
+
+// Get returns the value of the given key, and marks the key as recently used.
+func (l *Lru) Get(key interface{}) (interface{}, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if e, found := l.elements[key]; found {
+		l.list.MoveToFront(e)
+		return e.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// Peek returns the value of the given key, without changing its position in the cache.
+func (l *Lru) Peek(key interface{}) (interface{}, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if e, found := l.elements[key]; found {
+		return e.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// Put adds or updates the value of the given key. The least recently used entry is evicted if the cache is full.
+func (l *Lru) Put(key, value interface{}) {
+	l.Lock()
+	defer l.Unlock()
+
+	if e, found := l.elements[key]; found {
+		e.Value.(*lruEntry).value = value
+		l.list.MoveToFront(e)
+		return
+	}
+
+	l.elements[key] = l.list.PushFront(&lruEntry{
+		key:   key,
+		value: value,
+	})
+	for l.list.Len() > l.capacity {
+		l.removeElement(l.list.Back())
+	}
+}
+
+// Remove removes the given key from the cache.
+func (l *Lru) Remove(key interface{}) {
+	l.Lock()
+	defer l.Unlock()
+
+	if e, found := l.elements[key]; found {
+		l.removeElement(e)
+	}
+}
+
+// RemoveOldest removes the least recently used entry, and returns its key and value.
+func (l *Lru) RemoveOldest() (interface{}, interface{}, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	e := l.list.Back()
+	if e == nil {
+		return nil, nil, false
+	}
+	entry := e.Value.(*lruEntry)
+	l.removeElement(e)
+	return entry.key, entry.value, true
+}
+
+// Len returns the number of entries in the cache.
+func (l *Lru) Len() int {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.list.Len()
+}
+
+// Capacity returns the max number of entries in the cache.
+func (l *Lru) Capacity() int {
+	return l.capacity
+}
+
+// Keys returns all keys in the cache, from the most recently used to the least.
+func (l *Lru) Keys() []interface{} {
+	l.Lock()
+	defer l.Unlock()
+
+	keys := make([]interface{}, 0, l.list.Len())
+	for e := l.list.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*lruEntry).key)
+	}
+	return keys
+}
+
+func (l *Lru) removeElement(e *list.Element) {
+	entry := e.Value.(*lruEntry)
+	l.list.Remove(e)
+	delete(l.elements, entry.key)
+	if l.onEvict != nil {
+		l.onEvict(entry.key, entry.value)
+	}
+}
@@ -0,0 +1,56 @@
+package cache_test
+
+import (
+	"testing"
+
+	. "v2ray.com/core/common/cache"
+	. "v2ray.com/ext/assert"
+)
+
+func TestLruEviction(t *testing.T) {
+	assert := With(t)
+
+	lru := NewLru(2)
+	evicted := make([]interface{}, 0, 2)
+	lru.OnEvict(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+
+	lru.Put("a", 1)
+	lru.Put("b", 2)
+	v, found := lru.Get("a")
+	assert(found, IsTrue)
+	assert(v, Equals, 1)
+
+	lru.Put("c", 3)
+	assert(lru.Len(), Equals, 2)
+	assert(len(evicted), Equals, 1)
+	assert(evicted[0], Equals, "b")
+
+	_, found = lru.Get("b")
+	assert(found, IsFalse)
+}
+
+func TestLruUpdate(t *testing.T) {
+	assert := With(t)
+
+	lru := NewLru(2)
+	lru.Put("a", 1)
+	lru.Put("b", 2)
+	lru.Put("a", 3)
+	lru.Put("c", 4)
+
+	v, found := lru.Peek("a")
+	assert(found, IsTrue)
+	assert(v, Equals, 3)
+
+	_, found = lru.Peek("b")
+	assert(found, IsFalse)
+
+	key, _, ok := lru.RemoveOldest()
+	assert(ok, IsTrue)
+	assert(key, Equals, "a")
+
+	lru.Remove("c")
+	assert(lru.Len(), Equals, 0)
+}
@@ -20,6 +20,11 @@ func RollUint16() uint16 {
 	return uint16(rand.Intn(65536))
 }
 
+// RollUint64 returns a random uint64 value.
+func RollUint64() uint64 {
+	return rand.Uint64()
+}
+
 func init() {
 	rand.Seed(time.Now().Unix())
 }
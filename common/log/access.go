@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"strings"
 
 	"v2ray.com/core/common/serial"
@@ -18,8 +19,32 @@ type AccessMessage struct {
 	To     interface{}
 	Status AccessStatus
 	Reason interface{}
+	// Sniffed is the result of protocol sniffing on the connection, or nil if the connection is not sniffed.
+	Sniffed interface{}
 }
 
 func (m *AccessMessage) String() string {
-	return strings.Join([]string{serial.ToString(m.From), string(m.Status), serial.ToString(m.To), serial.ToString(m.Reason)}, " ")
+	fields := []string{serial.ToString(m.From), string(m.Status), serial.ToString(m.To), serial.ToString(m.Reason)}
+	if m.Sniffed != nil {
+		fields = append(fields, "[sniffed: "+serial.ToString(m.Sniffed)+"]")
+	}
+	return strings.Join(fields, " ")
+}
+
+type accessKey int
+
+const accessMessageKey accessKey = 0
+
+// ContextWithAccessMessage returns a context with the access message of a connection that is about to be dispatched.
+// The dispatcher records the message once it knows the result of sniffing. A nil message means that there is none.
+func ContextWithAccessMessage(ctx context.Context, msg *AccessMessage) context.Context {
+	return context.WithValue(ctx, accessMessageKey, msg)
+}
+
+// AccessMessageFromContext returns the access message set by ContextWithAccessMessage(), or nil if not set.
+func AccessMessageFromContext(ctx context.Context) *AccessMessage {
+	if msg, ok := ctx.Value(accessMessageKey).(*AccessMessage); ok {
+		return msg
+	}
+	return nil
 }
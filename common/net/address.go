@@ -55,6 +55,11 @@ func (af AddressFamily) IsIPv6() bool {
 	return af == AddressFamilyIPv6
 }
 
+// IsIP returns true if current AddressFamily is IPv6 or IPv4.
+func (af AddressFamily) IsIP() bool {
+	return af == AddressFamilyIPv4 || af == AddressFamilyIPv6
+}
+
 // IsDomain returns true if current AddressFamily is Domain.
 func (af AddressFamily) IsDomain() bool {
 	return af == AddressFamilyDomain
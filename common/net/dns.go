@@ -1,6 +1,7 @@
 package net
 
 import (
+	"context"
 	"net"
 	"sync/atomic"
 	"unsafe"
@@ -11,6 +12,13 @@ type IPResolver interface {
 	LookupIP(host string) ([]net.IP, error)
 }
 
+// ContextIPResolver is an IPResolver that takes the context of the request into account, such as
+// the source of the connection.
+type ContextIPResolver interface {
+	IPResolver
+	LookupIPWithContext(ctx context.Context, host string) ([]net.IP, error)
+}
+
 type systemIPResolver int
 
 func (s systemIPResolver) LookupIP(host string) ([]net.IP, error) {
@@ -35,6 +43,16 @@ func LookupIP(host string) ([]net.IP, error) {
 	return (*r).LookupIP(host)
 }
 
+// LookupIPWithContext resolves the given host with the registered IPResolver. The context is passed to
+// the resolver if it is a ContextIPResolver.
+func LookupIPWithContext(ctx context.Context, host string) ([]net.IP, error) {
+	r := (*IPResolver)(atomic.LoadPointer(&ipResolver))
+	if cr, ok := (*r).(ContextIPResolver); ok {
+		return cr.LookupIPWithContext(ctx, host)
+	}
+	return (*r).LookupIP(host)
+}
+
 func RegisterIPResolver(resolver IPResolver) {
 	atomic.StorePointer(&ipResolver, unsafe.Pointer(&resolver))
 }
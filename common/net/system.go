@@ -10,10 +10,12 @@ var DialUnix = net.DialUnix
 var Listen = net.Listen
 var ListenTCP = net.ListenTCP
 var ListenUDP = net.ListenUDP
+var ListenPacket = net.ListenPacket
 
 var FileConn = net.FileConn
 
 var ParseIP = net.ParseIP
+var ParseCIDR = net.ParseCIDR
 
 var SplitHostPort = net.SplitHostPort
 
@@ -21,6 +23,7 @@ var CIDRMask = net.CIDRMask
 
 type Addr = net.Addr
 type Conn = net.Conn
+type PacketConn = net.PacketConn
 
 type TCPAddr = net.TCPAddr
 type TCPConn = net.TCPConn
@@ -0,0 +1,7 @@
+// +build go1.11
+
+package net
+
+import "net"
+
+type ListenConfig = net.ListenConfig
@@ -0,0 +1,80 @@
+package proxyproto
+
+import (
+	"bufio"
+	"sync"
+	"time"
+
+	"v2ray.com/core/common/net"
+)
+
+// HeaderTimeout is the time limit for reading the PROXY protocol header of a connection.
+const HeaderTimeout = time.Second * 10
+
+// Conn is a server side connection which starts with a PROXY protocol header. The header is read on the first call
+// of Read or RemoteAddr, so that accepting connections is not blocked by slow clients.
+type Conn struct {
+	net.Conn
+	once   sync.Once
+	reader *bufio.Reader
+	header *Header
+	err    error
+}
+
+// NewConn creates a new Conn on the given connection.
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		Conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+func (c *Conn) readHeader() {
+	c.Conn.SetReadDeadline(time.Now().Add(HeaderTimeout))
+	c.header, c.err = ReadHeader(c.reader)
+	c.Conn.SetReadDeadline(time.Time{})
+	if c.err != nil {
+		newError("failed to read PROXY protocol header from ", c.Conn.RemoteAddr()).Base(c.err).AtWarning().WriteToLog()
+	}
+}
+
+// Header returns the PROXY protocol header of the connection.
+func (c *Conn) Header() (*Header, error) {
+	c.once.Do(c.readHeader)
+	return c.header, c.err
+}
+
+// Read implements net.Conn.Read().
+func (c *Conn) Read(b []byte) (int, error) {
+	if _, err := c.Header(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr implements net.Conn.RemoteAddr(). It returns the source address in the PROXY protocol header, or the
+// address of the peer if the header carries no address.
+func (c *Conn) RemoteAddr() net.Addr {
+	header, err := c.Header()
+	if err != nil || !header.Source.IsValid() {
+		return c.Conn.RemoteAddr()
+	}
+	return &net.TCPAddr{
+		IP:   header.Source.Address.IP(),
+		Port: int(header.Source.Port),
+	}
+}
+
+// Listener is a listener whose connections start with PROXY protocol headers.
+type Listener struct {
+	net.Listener
+}
+
+// Accept implements net.Listener.Accept().
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(conn), nil
+}
@@ -0,0 +1,5 @@
+package proxyproto
+
+import "v2ray.com/core/common/errors"
+
+func newError(values ...interface{}) *errors.Error { return errors.New(values...).Path("Protocol", "PROXY") }
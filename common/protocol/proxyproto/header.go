@@ -0,0 +1,255 @@
+// Package proxyproto implements the PROXY protocol of HAProxy, version 1 and 2.
+package proxyproto
+
+//go:generate go run $GOPATH/src/v2ray.com/core/common/errors/errorgen/main.go -pkg proxyproto -path Protocol,PROXY
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+
+	"v2ray.com/core/common/net"
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const (
+	// v1MaxLength is the maximum length of version 1 headers, including the CRLF.
+	v1MaxLength = 107
+
+	v2CommandLocal = 0x0
+	v2CommandProxy = 0x1
+
+	v2FamilyIPv4 = 0x1
+	v2FamilyIPv6 = 0x2
+
+	v2TransportStream = 0x1
+	v2TransportDgram  = 0x2
+)
+
+// Header is a PROXY protocol header.
+type Header struct {
+	Version byte
+	// Source and Destination are invalid if the header carries no address, such as the UNKNOWN protocol in version 1
+	// or the LOCAL command in version 2.
+	Source      net.Destination
+	Destination net.Destination
+}
+
+// ReadHeader reads a PROXY protocol header of either version from the reader.
+func ReadHeader(reader *bufio.Reader) (*Header, error) {
+	b, err := reader.Peek(1)
+	if err != nil {
+		return nil, newError("failed to read PROXY protocol header").Base(err)
+	}
+	switch b[0] {
+	case v1Prefix[0]:
+		return readV1Header(reader)
+	case v2Signature[0]:
+		return readV2Header(reader)
+	default:
+		return nil, newError("not a PROXY protocol header")
+	}
+}
+
+func readV1Header(reader *bufio.Reader) (*Header, error) {
+	var line []byte
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= v1MaxLength {
+			return nil, newError("PROXY protocol v1 header too long")
+		}
+		c, err := reader.ReadByte()
+		if err != nil {
+			return nil, newError("failed to read PROXY protocol v1 header").Base(err)
+		}
+		line = append(line, c)
+	}
+	if !bytes.HasPrefix(line, v1Prefix) {
+		return nil, newError("invalid PROXY protocol v1 header")
+	}
+
+	fields := strings.Split(string(line[len(v1Prefix):len(line)-2]), " ")
+	header := &Header{Version: 1}
+	switch fields[0] {
+	case "UNKNOWN":
+		return header, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, newError("unknown protocol in PROXY protocol v1 header: ", fields[0])
+	}
+	if len(fields) != 5 {
+		return nil, newError("invalid PROXY protocol v1 header")
+	}
+
+	source, err := parseV1Destination(fields[1], fields[3])
+	if err != nil {
+		return nil, err
+	}
+	destination, err := parseV1Destination(fields[2], fields[4])
+	if err != nil {
+		return nil, err
+	}
+	header.Source = source
+	header.Destination = destination
+	return header, nil
+}
+
+func parseV1Destination(ip string, port string) (net.Destination, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return net.Destination{}, newError("invalid IP in PROXY protocol v1 header: ", ip)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return net.Destination{}, newError("invalid port in PROXY protocol v1 header: ", port).Base(err)
+	}
+	return net.TCPDestination(net.IPAddress(addr), net.Port(p)), nil
+}
+
+func readV2Header(reader *bufio.Reader) (*Header, error) {
+	var fixed [16]byte
+	if _, err := io.ReadFull(reader, fixed[:]); err != nil {
+		return nil, newError("failed to read PROXY protocol v2 header").Base(err)
+	}
+	if !bytes.Equal(fixed[:12], v2Signature) {
+		return nil, newError("invalid PROXY protocol v2 signature")
+	}
+	if fixed[12]>>4 != 2 {
+		return nil, newError("unknown PROXY protocol version: ", fixed[12]>>4)
+	}
+	command := fixed[12] & 0x0f
+	family := fixed[13] >> 4
+	transport := fixed[13] & 0x0f
+
+	payload := make([]byte, binary.BigEndian.Uint16(fixed[14:16]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, newError("failed to read PROXY protocol v2 addresses").Base(err)
+	}
+
+	header := &Header{Version: 2}
+	switch command {
+	case v2CommandLocal:
+		return header, nil
+	case v2CommandProxy:
+	default:
+		return nil, newError("unknown PROXY protocol v2 command: ", command)
+	}
+
+	var ipLen int
+	switch family {
+	case v2FamilyIPv4:
+		ipLen = net.IPv4len
+	case v2FamilyIPv6:
+		ipLen = net.IPv6len
+	default:
+		// Unix sockets and unspecified families carry no usable address.
+		return header, nil
+	}
+	if len(payload) < ipLen*2+4 {
+		return nil, newError("PROXY protocol v2 addresses too short")
+	}
+
+	network := net.Network_TCP
+	if transport == v2TransportDgram {
+		network = net.Network_UDP
+	}
+	header.Source = net.Destination{
+		Network: network,
+		Address: net.IPAddress(payload[:ipLen]),
+		Port:    net.PortFromBytes(payload[ipLen*2 : ipLen*2+2]),
+	}
+	header.Destination = net.Destination{
+		Network: network,
+		Address: net.IPAddress(payload[ipLen : ipLen*2]),
+		Port:    net.PortFromBytes(payload[ipLen*2+2 : ipLen*2+4]),
+	}
+	return header, nil
+}
+
+// WriteHeader writes the header into the writer, in the version of the header. If the source or destination of the
+// header is not an IP destination, a header without address is written.
+func WriteHeader(writer io.Writer, header *Header) error {
+	var b []byte
+	switch header.Version {
+	case 1:
+		b = encodeV1Header(header)
+	case 2:
+		b = encodeV2Header(header)
+	default:
+		return newError("unknown PROXY protocol version: ", header.Version)
+	}
+	_, err := writer.Write(b)
+	return err
+}
+
+// headerIPs returns the source and destination IPs of the header in the same length, or nil if the header has
+// no IP addresses.
+func headerIPs(header *Header) (net.IP, net.IP) {
+	if !header.Source.IsValid() || !header.Destination.IsValid() ||
+		!header.Source.Address.Family().IsIP() || !header.Destination.Address.Family().IsIP() {
+		return nil, nil
+	}
+	source := header.Source.Address.IP()
+	destination := header.Destination.Address.IP()
+	if len(source) != len(destination) {
+		source = source.To16()
+		destination = destination.To16()
+	}
+	return source, destination
+}
+
+func encodeV1Header(header *Header) []byte {
+	source, destination := headerIPs(header)
+	if source == nil {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+	protocol := "TCP4"
+	if len(source) == net.IPv6len {
+		protocol = "TCP6"
+	}
+	return []byte("PROXY " + protocol + " " + v1IPString(source) + " " + v1IPString(destination) + " " +
+		header.Source.Port.String() + " " + header.Destination.Port.String() + "\r\n")
+}
+
+// v1IPString formats the IP. IPv4-mapped IPv6 addresses are kept in the IPv6 form, which net.IP.String() doesn't.
+func v1IPString(ip net.IP) string {
+	if len(ip) == net.IPv6len && ip.To4() != nil {
+		return "::ffff:" + ip.To4().String()
+	}
+	return ip.String()
+}
+
+func encodeV2Header(header *Header) []byte {
+	b := make([]byte, 16, 16+net.IPv6len*2+4)
+	copy(b, v2Signature)
+
+	source, destination := headerIPs(header)
+	if source == nil {
+		b[12] = 0x20 | v2CommandLocal
+		return b
+	}
+	b[12] = 0x20 | v2CommandProxy
+
+	family := byte(v2FamilyIPv4)
+	if len(source) == net.IPv6len {
+		family = v2FamilyIPv6
+	}
+	transport := byte(v2TransportStream)
+	if header.Source.Network == net.Network_UDP {
+		transport = v2TransportDgram
+	}
+	b[13] = family<<4 | transport
+
+	b = append(b, source...)
+	b = append(b, destination...)
+	b = append(b, header.Source.Port.Bytes(nil)...)
+	b = append(b, header.Destination.Port.Bytes(nil)...)
+	binary.BigEndian.PutUint16(b[14:16], uint16(len(b)-16))
+	return b
+}
@@ -0,0 +1,152 @@
+package proxyproto_test
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"v2ray.com/core/common/net"
+	. "v2ray.com/core/common/protocol/proxyproto"
+	. "v2ray.com/ext/assert"
+)
+
+func TestReadV1Header(t *testing.T) {
+	assert := With(t)
+
+	reader := bufio.NewReader(bytes.NewReader([]byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\nGET /")))
+	header, err := ReadHeader(reader)
+	assert(err, IsNil)
+	assert(header.Version, Equals, byte(1))
+	assert(header.Source, Equals, net.TCPDestination(net.ParseAddress("192.168.0.1"), 56324))
+	assert(header.Destination, Equals, net.TCPDestination(net.ParseAddress("192.168.0.11"), 443))
+
+	rest, err := ioutil.ReadAll(reader)
+	assert(err, IsNil)
+	assert(string(rest), Equals, "GET /")
+
+	header, err = ReadHeader(bufio.NewReader(bytes.NewReader([]byte("PROXY TCP6 2001:db8::1 2001:db8::2 1 2\r\n"))))
+	assert(err, IsNil)
+	assert(header.Source, Equals, net.TCPDestination(net.ParseAddress("2001:db8::1"), 1))
+
+	header, err = ReadHeader(bufio.NewReader(bytes.NewReader([]byte("PROXY UNKNOWN\r\n"))))
+	assert(err, IsNil)
+	assert(header.Source.IsValid(), IsFalse)
+}
+
+func TestReadInvalidV1Header(t *testing.T) {
+	assert := With(t)
+
+	for _, input := range []string{
+		"PROXY TCP4 192.168.0.1 192.168.0.11 56324\r\n",
+		"PROXY TCP4 example.com 192.168.0.11 56324 443\r\n",
+		"PROXY TCP4 192.168.0.1 192.168.0.11 65536 443\r\n",
+		"PROXY TCP4 192.168.0.1 192.168.0.11 56324 443",
+		"PROXY UDP4 192.168.0.1 192.168.0.11 56324 443\r\n",
+		"PROXY TCP4 " + string(bytes.Repeat([]byte{'1'}, 100)) + "\r\n",
+		"GET / HTTP/1.1\r\n",
+	} {
+		_, err := ReadHeader(bufio.NewReader(bytes.NewReader([]byte(input))))
+		assert(err, IsNotNil)
+	}
+}
+
+func TestReadV2Header(t *testing.T) {
+	assert := With(t)
+
+	input := []byte("\r\n\r\n\x00\r\nQUIT\n")
+	input = append(input, 0x21, 0x11, 0x00, 0x0c)
+	input = append(input, 10, 0, 0, 1, 10, 0, 0, 2, 0x1f, 0x90, 0x01, 0xbb)
+	input = append(input, "data"...)
+
+	reader := bufio.NewReader(bytes.NewReader(input))
+	header, err := ReadHeader(reader)
+	assert(err, IsNil)
+	assert(header.Version, Equals, byte(2))
+	assert(header.Source, Equals, net.TCPDestination(net.ParseAddress("10.0.0.1"), 8080))
+	assert(header.Destination, Equals, net.TCPDestination(net.ParseAddress("10.0.0.2"), 443))
+
+	rest, err := ioutil.ReadAll(reader)
+	assert(err, IsNil)
+	assert(string(rest), Equals, "data")
+}
+
+func TestReadV2LocalHeader(t *testing.T) {
+	assert := With(t)
+
+	input := []byte("\r\n\r\n\x00\r\nQUIT\n")
+	input = append(input, 0x20, 0x00, 0x00, 0x00)
+
+	header, err := ReadHeader(bufio.NewReader(bytes.NewReader(input)))
+	assert(err, IsNil)
+	assert(header.Source.IsValid(), IsFalse)
+}
+
+func TestConnRemoteAddr(t *testing.T) {
+	assert := With(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert(err, IsNil)
+	defer listener.Close()
+
+	go func() {
+		conn, err := net.DialTCP("tcp", nil, listener.Addr().(*net.TCPAddr))
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("PROXY TCP4 1.2.3.4 5.6.7.8 1234 80\r\ntest"))
+		conn.Close()
+	}()
+
+	l := &Listener{Listener: listener}
+	conn, err := l.Accept()
+	assert(err, IsNil)
+	defer conn.Close()
+
+	assert(conn.RemoteAddr().String(), Equals, "1.2.3.4:1234")
+	b, err := ioutil.ReadAll(conn)
+	assert(err, IsNil)
+	assert(string(b), Equals, "test")
+}
+
+func TestWriteHeader(t *testing.T) {
+	assert := With(t)
+
+	source := net.TCPDestination(net.ParseAddress("1.2.3.4"), 1234)
+	for _, destination := range []net.Destination{
+		net.TCPDestination(net.ParseAddress("5.6.7.8"), 80),
+		net.TCPDestination(net.ParseAddress("2001:db8::1"), 443),
+	} {
+		for _, version := range []byte{1, 2} {
+			b := new(bytes.Buffer)
+			assert(WriteHeader(b, &Header{
+				Version:     version,
+				Source:      source,
+				Destination: destination,
+			}), IsNil)
+
+			header, err := ReadHeader(bufio.NewReader(b))
+			assert(err, IsNil)
+			assert(header.Version, Equals, version)
+			assert(header.Source, Equals, source)
+			assert(header.Destination, Equals, destination)
+		}
+	}
+}
+
+func TestWriteHeaderWithoutAddress(t *testing.T) {
+	assert := With(t)
+
+	b := new(bytes.Buffer)
+	assert(WriteHeader(b, &Header{Version: 1}), IsNil)
+	assert(b.String(), Equals, "PROXY UNKNOWN\r\n")
+
+	b.Reset()
+	assert(WriteHeader(b, &Header{Version: 2}), IsNil)
+	header, err := ReadHeader(bufio.NewReader(b))
+	assert(err, IsNil)
+	assert(header.Version, Equals, byte(2))
+	assert(header.Source.IsValid(), IsFalse)
+
+	assert(WriteHeader(b, &Header{Version: 3}), IsNotNil)
+}
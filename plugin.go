@@ -12,7 +12,8 @@ const GetMetadataFuncName = "GetPluginMetadata"
 // GetMetadataFunc is the type of the function in the plugin to return PluginMetadata.
 type GetMetadataFunc func() PluginMetadata
 
-// LoadPlugins loads all possible plugins in the 'plugin' directory.
+// LoadPlugins loads all possible plugins in the 'plugin' directory. Plugins may register creators of their own configs
+// in init(), such as dispatcher interceptors, so that the configs can be used in the V2Ray config.
 func LoadPlugins() error {
 	return loadPluginsInternal()
 }
@@ -15,6 +15,8 @@ const (
 	inboundEntryPointKey
 	inboundTagKey
 	resolvedIPsKey
+	sniffedProtocolKey
+	fullConeKey
 )
 
 // ContextWithSource creates a new context with given source.
@@ -64,6 +66,29 @@ func InboundTagFromContext(ctx context.Context) (string, bool) {
 	return v, ok
 }
 
+// ContextWithSniffedProtocol creates a new context with the name of the protocol detected by sniffing.
+func ContextWithSniffedProtocol(ctx context.Context, protocol string) context.Context {
+	return context.WithValue(ctx, sniffedProtocolKey, protocol)
+}
+
+// SniffedProtocolFromContext returns the name of the protocol detected by sniffing.
+func SniffedProtocolFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(sniffedProtocolKey).(string)
+	return v, ok
+}
+
+// ContextWithFullCone creates a new context that requests a full-cone UDP connection, where each packet is sent to
+// the destination of its buffer, and packets from any peer are accepted.
+func ContextWithFullCone(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fullConeKey, true)
+}
+
+// FullConeFromContext returns true if a full-cone UDP connection is requested in the given context.
+func FullConeFromContext(ctx context.Context) bool {
+	v, ok := ctx.Value(fullConeKey).(bool)
+	return ok && v
+}
+
 type IPResolver interface {
 	Resolve() []net.Address
 }
@@ -0,0 +1,191 @@
+// Package dns is an outbound handler that answers DNS queries from clients. A and AAAA queries are answered with
+// fake IPs if fake DNS is enabled in the DNS app, or with the IPs resolved by the DNS app otherwise. Other queries are
+// forwarded to their destination.
+//
+// Only client queries should be routed to this handler, for example by inbound tag. Queries of the DNS app itself
+// would come back to it otherwise.
+package dns
+
+//go:generate go run $GOPATH/src/v2ray.com/core/common/errors/errorgen/main.go -pkg dns -path Proxy,DNS
+
+import (
+	"context"
+
+	dnsmsg "github.com/miekg/dns"
+	"v2ray.com/core/app"
+	appdns "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/proxy"
+	"v2ray.com/core/transport/internet"
+	"v2ray.com/core/transport/ray"
+)
+
+// answerTTL is the TTL of answers in seconds.
+const answerTTL = 60
+
+// Handler is an outbound handler that answers DNS queries.
+type Handler struct {
+	server *appdns.Server
+	policy policy.Policy
+}
+
+// New creates a new DNS handler.
+func New(ctx context.Context, config *Config) (*Handler, error) {
+	space := app.SpaceFromContext(ctx)
+	if space == nil {
+		return nil, newError("no space in context")
+	}
+	h := &Handler{}
+	space.On(app.SpaceInitializing, func(interface{}) error {
+		h.server = appdns.FromSpace(space)
+		if h.server == nil {
+			return newError("DNS app is not found in the space")
+		}
+		pm := policy.FromSpace(space)
+		if pm == nil {
+			return newError("Policy not found in space.")
+		}
+		h.policy = pm.GetPolicy(0)
+		return nil
+	})
+	return h, nil
+}
+
+// answer returns the response to the given query, or nil if the query should be forwarded.
+func (h *Handler) answer(ctx context.Context, query []byte) []byte {
+	msg := new(dnsmsg.Msg)
+	if err := msg.Unpack(query); err != nil {
+		newError("failed to parse DNS query").Base(err).AtDebug().WriteToLog()
+		return nil
+	}
+	if len(msg.Question) != 1 {
+		return nil
+	}
+	q := msg.Question[0]
+	if q.Qclass != dnsmsg.ClassINET || (q.Qtype != dnsmsg.TypeA && q.Qtype != dnsmsg.TypeAAAA) {
+		return nil
+	}
+
+	reply := new(dnsmsg.Msg)
+	reply.SetReply(msg)
+	reply.RecursionAvailable = true
+
+	var ips []net.IP
+	if pool := h.server.FakeDNS(); pool != nil {
+		// The pool has addresses of one family. Queries of the other family get an empty answer, so that clients
+		// connect to the fake IPs.
+		if (q.Qtype == dnsmsg.TypeA) == pool.IPFamily().IsIPv4() {
+			ips = []net.IP{pool.GetFakeIPForDomain(q.Name).IP()}
+		}
+	} else {
+		resolved, err := h.server.LookupIPWithContext(ctx, q.Name)
+		if err != nil {
+			newError("failed to resolve ", q.Name).Base(err).WriteToLog()
+			reply.Rcode = dnsmsg.RcodeServerFailure
+		}
+		ips = resolved
+	}
+
+	for _, ip := range ips {
+		header := dnsmsg.RR_Header{
+			Name:   q.Name,
+			Rrtype: q.Qtype,
+			Class:  dnsmsg.ClassINET,
+			Ttl:    answerTTL,
+		}
+		ipv4 := ip.To4()
+		switch {
+		case q.Qtype == dnsmsg.TypeA && ipv4 != nil:
+			reply.Answer = append(reply.Answer, &dnsmsg.A{Hdr: header, A: ipv4})
+		case q.Qtype == dnsmsg.TypeAAAA && ipv4 == nil:
+			reply.Answer = append(reply.Answer, &dnsmsg.AAAA{Hdr: header, AAAA: ip})
+		}
+	}
+
+	response, err := reply.Pack()
+	if err != nil {
+		newError("failed to build DNS response").Base(err).WriteToLog()
+		return nil
+	}
+	newError("answered ", q.Name, " with ", len(reply.Answer), " records").AtDebug().WriteToLog()
+	return response
+}
+
+// Process implements proxy.Outbound.
+func (h *Handler) Process(ctx context.Context, outboundRay ray.OutboundRay, dialer proxy.Dialer) error {
+	destination, _ := proxy.TargetFromContext(ctx)
+	if destination.Network != net.Network_UDP {
+		return newError("unsupported network: ", destination.Network)
+	}
+
+	input := outboundRay.OutboundInput()
+	output := outboundRay.OutboundOutput()
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, h.policy.Timeout.ConnectionIdle.Duration())
+
+	// conn is the connection to the destination, for queries that are forwarded.
+	var conn internet.Connection
+	forward := func(b *buf.Buffer) error {
+		if conn == nil {
+			c, err := dialer.Dial(ctx, destination)
+			if err != nil {
+				return newError("failed to open connection to ", destination).Base(err)
+			}
+			conn = c
+			go func() {
+				if err := buf.Copy(buf.NewReader(c), output, buf.UpdateActivity(timer)); err != nil {
+					newError("failed to forward DNS responses").Base(err).AtDebug().WriteToLog()
+				}
+			}()
+		}
+		return buf.NewSequentialWriter(conn).WriteMultiBuffer(buf.NewMultiBufferValue(b))
+	}
+
+	requestDone := signal.ExecuteAsync(func() error {
+		defer func() {
+			if conn != nil {
+				conn.Close()
+			}
+		}()
+
+		for {
+			mb, err := input.ReadMultiBuffer()
+			if err != nil {
+				return err
+			}
+			timer.Update()
+			for i, b := range mb {
+				var err error
+				if response := h.answer(ctx, b.Bytes()); response != nil {
+					b.Release()
+					reply := buf.New()
+					reply.Append(response)
+					err = output.WriteMultiBuffer(buf.NewMultiBufferValue(reply))
+				} else {
+					err = forward(b)
+				}
+				if err != nil {
+					rest := mb[i+1:]
+					rest.Release()
+					return err
+				}
+			}
+		}
+	})
+
+	if err := signal.ErrorOrFinish1(ctx, requestDone); err != nil {
+		return newError("connection ends").Base(err)
+	}
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return New(ctx, config.(*Config))
+	}))
+}
@@ -0,0 +1,132 @@
+package dns_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dnsmsg "github.com/miekg/dns"
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	_ "v2ray.com/core/app/dispatcher/impl"
+	appdns "v2ray.com/core/app/dns"
+	"v2ray.com/core/app/policy"
+	_ "v2ray.com/core/app/policy/manager"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/app/router"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy"
+	. "v2ray.com/core/proxy/dns"
+	"v2ray.com/core/proxy/freedom"
+	"v2ray.com/core/testing/servers/tcp"
+	_ "v2ray.com/core/transport/internet/tcp"
+	. "v2ray.com/ext/assert"
+)
+
+// query sends a DNS query from a client to the DNS outbound, and returns the response, or nil if there is none.
+func query(d dispatcher.Interface, name string, qtype uint16) *dnsmsg.Msg {
+	msg := new(dnsmsg.Msg)
+	msg.SetQuestion(name, qtype)
+	rawQuery, err := msg.Pack()
+	common.Must(err)
+
+	link, err := d.Dispatch(proxy.ContextWithInboundTag(context.Background(), "dns-in"), net.UDPDestination(net.ParseAddress("8.8.8.8"), 53))
+	common.Must(err)
+	defer link.InboundInput().Close()
+	b := buf.New()
+	b.Append(rawQuery)
+	common.Must(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)))
+
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 5)
+	if err != nil {
+		return nil
+	}
+	rawResponse := make([]byte, mb.Len())
+	mb.Copy(rawResponse)
+	mb.Release()
+
+	response := new(dnsmsg.Msg)
+	if err := response.Unpack(rawResponse); err != nil || response.Id != msg.Id {
+		return nil
+	}
+	return response
+}
+
+func TestFakeDNSEndToEnd(t *testing.T) {
+	assert := With(t)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &policy.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &appdns.Config{
+		FakeDns: &appdns.FakeDnsConfig{
+			IpPool: "198.18.0.0/15",
+		},
+	}))
+	common.Must(app.AddApplicationToSpace(ctx, &router.Config{
+		Rule: []*router.RoutingRule{
+			{
+				Tag:        "dns",
+				InboundTag: []string{"dns-in"},
+			},
+		},
+	}))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		Tag:           "dns",
+		ProxySettings: serial.ToTypedMessage(&Config{}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	d := dispatcher.FromSpace(space)
+
+	// IPv6 queries get no fake IPs from an IPv4 pool.
+	response := query(d, "v2ray.com.", dnsmsg.TypeAAAA)
+	assert(response, IsNotNil)
+	assert(response.Rcode, Equals, dnsmsg.RcodeSuccess)
+	assert(len(response.Answer), Equals, 0)
+
+	// A client asks for the IP of the echo server, which gets the first address of the pool.
+	response = query(d, "localhost.", dnsmsg.TypeA)
+	assert(response, IsNotNil)
+	assert(len(response.Answer), Equals, 1)
+	assert(response.Answer[0].(*dnsmsg.A).A.String(), Equals, "198.18.0.1")
+	fakeIP := net.IPAddress(response.Answer[0].(*dnsmsg.A).A)
+	assert(appdns.FromSpace(space).FakeDNS().IsIPInIPPool(fakeIP), IsTrue)
+
+	// The client then connects to the fake IP, which is restored to the domain by the dispatcher.
+	link, err := d.Dispatch(context.Background(), net.TCPDestination(fakeIP, dest.Port))
+	assert(err, IsNil)
+	b := buf.New()
+	b.Append([]byte("hello"))
+	assert(link.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(b)), IsNil)
+
+	mb, err := link.InboundOutput().ReadTimeout(time.Second * 10)
+	assert(err, IsNil)
+	echo := make([]byte, mb.Len())
+	mb.Copy(echo)
+	mb.Release()
+	assert(string(echo), Equals, "hello")
+	link.InboundInput().Close()
+}
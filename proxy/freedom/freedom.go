@@ -51,23 +51,21 @@ func New(ctx context.Context, config *Config) (*Handler, error) {
 	return f, nil
 }
 
-func (h *Handler) resolveIP(ctx context.Context, domain string) net.Address {
+// resolveIPs returns all IPs of the given domain, from the router if it has resolved the domain already.
+func (h *Handler) resolveIPs(ctx context.Context, domain string) []net.Address {
 	if resolver, ok := proxy.ResolvedIPsFromContext(ctx); ok {
-		ips := resolver.Resolve()
-		if len(ips) == 0 {
-			return nil
-		}
-		return ips[dice.Roll(len(ips))]
+		return resolver.Resolve()
 	}
 
-	ips, err := net.LookupIP(domain)
+	ips, err := net.LookupIPWithContext(ctx, domain)
 	if err != nil {
 		newError("failed to get IP address for domain ", domain).Base(err).WriteToLog()
 	}
-	if len(ips) == 0 {
-		return nil
+	addrs := make([]net.Address, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddress(ip)
 	}
-	return net.IPAddress(ips[dice.Roll(len(ips))])
+	return addrs
 }
 
 // Process implements proxy.Outbound.
@@ -87,17 +85,32 @@ func (h *Handler) Process(ctx context.Context, outboundRay ray.OutboundRay, dial
 	output := outboundRay.OutboundOutput()
 
 	if h.domainStrategy == Config_USE_IP && destination.Address.Family().IsDomain() {
-		ip := h.resolveIP(ctx, destination.Address.Domain())
-		if ip != nil {
+		ips := h.resolveIPs(ctx, destination.Address.Domain())
+		switch {
+		case len(ips) > 1 && destination.Network == net.Network_TCP:
+			// All IPs are tried by the system dialer, so that unreachable ones don't stall the connection.
+			newError("dialing ", destination, " with IPs ", ips).WriteToLog()
+			ctx = internet.ContextWithDialerIPs(ctx, destination.Address.Domain(), ips)
+		case len(ips) > 0:
 			destination = net.Destination{
 				Network: destination.Network,
-				Address: ip,
+				Address: ips[dice.Roll(len(ips))],
 				Port:    destination.Port,
 			}
 			newError("changing destination to ", destination).WriteToLog()
 		}
 	}
 
+	if destination.Network == net.Network_UDP && h.destOverride == nil && proxy.FullConeFromContext(ctx) {
+		if listener, ok := dialer.(proxy.PacketListener); ok {
+			conn, err := listener.ListenPacket(ctx)
+			if err == nil {
+				return h.processFullCone(ctx, conn, destination, outboundRay)
+			}
+			newError("failed to open full-cone UDP socket").Base(err).WriteToLog()
+		}
+	}
+
 	var conn internet.Connection
 	err := retry.ExponentialBackoff(5, 100).On(func() error {
 		rawConn, err := dialer.Dial(ctx, destination)
@@ -0,0 +1,130 @@
+package freedom
+
+import (
+	"context"
+
+	"v2ray.com/core/common/buf"
+	"v2ray.com/core/common/dice"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/signal"
+	"v2ray.com/core/transport/ray"
+)
+
+// packetWriter sends each packet to the destination of its buffer, or the default destination if the buffer has
+// none.
+type packetWriter struct {
+	ctx      context.Context
+	conn     net.PacketConn
+	dest     net.Destination
+	resolved map[string]net.Address
+}
+
+func (w *packetWriter) resolve(dest net.Destination) *net.UDPAddr {
+	address := dest.Address
+	if address.Family().IsDomain() {
+		domain := address.Domain()
+		ip, found := w.resolved[domain]
+		if !found {
+			ips, err := net.LookupIPWithContext(w.ctx, domain)
+			if err != nil || len(ips) == 0 {
+				newError("failed to get IP address for domain ", domain).Base(err).WriteToLog()
+				return nil
+			}
+			ip = net.IPAddress(ips[dice.Roll(len(ips))])
+			w.resolved[domain] = ip
+		}
+		address = ip
+	}
+	return &net.UDPAddr{
+		IP:   address.IP(),
+		Port: int(dest.Port),
+	}
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *packetWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer mb.Release()
+
+	for _, b := range mb {
+		dest := b.Destination()
+		if !dest.IsValid() {
+			dest = w.dest
+		}
+		addr := w.resolve(dest)
+		if addr == nil {
+			continue
+		}
+		if _, err := w.conn.WriteTo(b.Bytes(), addr); err != nil {
+			newError("failed to send packet to ", dest).Base(err).AtDebug().WriteToLog()
+		}
+	}
+	return nil
+}
+
+// packetReader reads packets from any peer, with the source address as the destination of each buffer.
+type packetReader struct {
+	conn net.PacketConn
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (r *packetReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	b := buf.New()
+	var addr net.Addr
+	err := b.Reset(func(p []byte) (int, error) {
+		n, from, err := r.conn.ReadFrom(p)
+		addr = from
+		return n, err
+	})
+	if err != nil {
+		b.Release()
+		return nil, err
+	}
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		b.SetDestination(net.UDPDestination(net.IPAddress(udpAddr.IP), net.Port(udpAddr.Port)))
+	}
+	return buf.NewMultiBufferValue(b), nil
+}
+
+// processFullCone relays packets between the given ray and an unconnected UDP socket, so that packets may be sent to
+// and received from any peer.
+func (h *Handler) processFullCone(ctx context.Context, conn net.PacketConn, destination net.Destination, outboundRay ray.OutboundRay) error {
+	defer conn.Close()
+
+	input := outboundRay.OutboundInput()
+	output := outboundRay.OutboundOutput()
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, h.policy.Timeout.ConnectionIdle.Duration())
+
+	requestDone := signal.ExecuteAsync(func() error {
+		writer := &packetWriter{
+			ctx:      ctx,
+			conn:     conn,
+			dest:     destination,
+			resolved: make(map[string]net.Address),
+		}
+		if err := buf.Copy(input, writer, buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to process request").Base(err)
+		}
+		timer.SetTimeout(h.policy.Timeout.DownlinkOnly.Duration())
+		return nil
+	})
+
+	responseDone := signal.ExecuteAsync(func() error {
+		defer output.Close()
+
+		if err := buf.Copy(&packetReader{conn: conn}, output, buf.UpdateActivity(timer)); err != nil {
+			return newError("failed to process response").Base(err)
+		}
+		timer.SetTimeout(h.policy.Timeout.UplinkOnly.Duration())
+		return nil
+	})
+
+	if err := signal.ErrorOrFinish2(ctx, requestDone, responseDone); err != nil {
+		input.CloseError()
+		output.CloseError()
+		return newError("connection ends").Base(err)
+	}
+
+	return nil
+}
@@ -18,15 +18,18 @@ import (
 	"v2ray.com/core/common/errors"
 	"v2ray.com/core/common/log"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
 	http_proto "v2ray.com/core/common/protocol/http"
 	"v2ray.com/core/common/signal"
+	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/internet"
 )
 
 // Server is a HTTP proxy server.
 type Server struct {
-	config *ServerConfig
-	policy policy.Policy
+	config        *ServerConfig
+	policy        policy.Policy
+	policyManager policy.Manager
 }
 
 // NewServer creates a new HTTP inbound handler.
@@ -43,6 +46,7 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 		if pm == nil {
 			return newError("Policy not found in space.")
 		}
+		s.policyManager = pm
 		s.policy = pm.GetPolicy(config.UserLevel)
 		if config.Timeout > 0 && config.UserLevel == 0 {
 			s.policy.Timeout.ConnectionIdle.Value = config.Timeout
@@ -119,13 +123,18 @@ Start:
 		return trace
 	}
 
+	user := &protocol.User{
+		Level: s.config.UserLevel,
+	}
 	if len(s.config.Accounts) > 0 {
-		user, pass, ok := parseBasicAuth(request.Header.Get("Proxy-Authorization"))
-		if !ok || !s.config.HasAccount(user, pass) {
+		username, pass, ok := parseBasicAuth(request.Header.Get("Proxy-Authorization"))
+		if !ok || !s.config.HasAccount(username, pass) {
 			_, err := conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\n\r\n"))
 			return err
 		}
+		user.Email = username
 	}
+	ctx = protocol.ContextWithUser(ctx, user)
 
 	newError("request to Method [", request.Method, "] Host [", request.Host, "] with URL [", request.URL, "]").WriteToLog()
 	conn.SetReadDeadline(time.Time{})
@@ -142,19 +151,32 @@ Start:
 	if err != nil {
 		return newError("malformed proxy host: ", host).AtWarning().Base(err)
 	}
-	log.Record(&log.AccessMessage{
+	source, _ := proxy.SourceFromContext(ctx)
+	release, err := s.policyManager.AcquireSession(user, source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   conn.RemoteAddr(),
+			To:     request.URL,
+			Status: log.AccessRejected,
+			Reason: err,
+		})
+		return newError("session of ", user.Email, " rejected").Base(err)
+	}
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   conn.RemoteAddr(),
 		To:     request.URL,
 		Status: log.AccessAccepted,
 	})
 
 	if strings.ToUpper(request.Method) == "CONNECT" {
+		defer release()
 		return s.handleConnect(ctx, request, reader, conn, dest, dispatcher)
 	}
 
 	keepAlive := (strings.TrimSpace(strings.ToLower(request.Header.Get("Proxy-Connection"))) == "keep-alive")
 
 	err = s.handlePlainHTTP(ctx, request, conn, dest, dispatcher)
+	release()
 	if err == errWaitAnother {
 		if keepAlive {
 			goto Start
@@ -36,3 +36,9 @@ type Dialer interface {
 	// Dial dials a system connection to the given destination.
 	Dial(ctx context.Context, destination net.Destination) (internet.Connection, error)
 }
+
+// PacketListener is implemented by Dialers that can open unconnected UDP sockets for full-cone UDP connections.
+type PacketListener interface {
+	// ListenPacket opens a UDP socket that may send packets to any destination.
+	ListenPacket(ctx context.Context) (net.PacketConn, error)
+}
@@ -81,6 +81,19 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn internet
 }
 
 func (s *Server) handlerUDPPayload(ctx context.Context, conn internet.Connection, dispatcher dispatcher.Interface) error {
+	source, _ := proxy.SourceFromContext(ctx)
+	release, err := s.policyManager.AcquireSession(s.user, source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   source,
+			To:     "",
+			Status: log.AccessRejected,
+			Reason: err,
+		})
+		return newError("session of ", s.user.Email, " rejected").Base(err)
+	}
+	defer release()
+
 	udpServer := udp.NewDispatcher(dispatcher)
 
 	reader := buf.NewReader(conn)
@@ -167,7 +180,20 @@ func (s *Server) handleConnection(ctx context.Context, conn internet.Connection,
 	bufferedReader.SetBuffered(false)
 
 	dest := request.Destination()
-	log.Record(&log.AccessMessage{
+	source, _ := proxy.SourceFromContext(ctx)
+	release, err := s.policyManager.AcquireSession(request.User, source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   conn.RemoteAddr(),
+			To:     dest,
+			Status: log.AccessRejected,
+			Reason: err,
+		})
+		return newError("session of ", request.User.Email, " rejected").Base(err)
+	}
+	defer release()
+
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   conn.RemoteAddr(),
 		To:     dest,
 		Status: log.AccessAccepted,
@@ -116,6 +116,10 @@ func (s *ServerSession) Handshake(reader io.Reader, writer io.Writer) (*protocol
 			if err := writeSocks5AuthenticationResponse(writer, 0x01, 0x00); err != nil {
 				return nil, newError("failed to write auth response").Base(err)
 			}
+			request.User = &protocol.User{
+				Level: s.config.UserLevel,
+				Email: username,
+			}
 		}
 		if err := buffer.Reset(buf.ReadFullFrom(reader, 4)); err != nil {
 			return nil, newError("failed to read request").Base(err)
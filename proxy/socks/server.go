@@ -3,6 +3,7 @@ package socks
 import (
 	"context"
 	"io"
+	"sync"
 	"time"
 
 	"v2ray.com/core/app"
@@ -16,13 +17,26 @@ import (
 	"v2ray.com/core/common/signal"
 	"v2ray.com/core/proxy"
 	"v2ray.com/core/transport/internet"
-	"v2ray.com/core/transport/internet/udp"
+	"v2ray.com/core/transport/ray"
 )
 
 // Server is a SOCKS 5 proxy server
 type Server struct {
-	config *ServerConfig
-	policy policy.Policy
+	config        *ServerConfig
+	policy        policy.Policy
+	policyManager policy.Manager
+
+	access       sync.Mutex
+	associations map[string][]*udpAssociation
+}
+
+// udpAssociation is a UDP associate request in progress. Its UDP packets are relayed for its user.
+type udpAssociation struct {
+	user *protocol.User
+	// port is the port that the client declares to send UDP packets from, or 0 if unknown.
+	port net.Port
+	// source is the address of the UDP packets of the association, which is set by the first packet.
+	source net.Destination
 }
 
 // NewServer creates a new Server object.
@@ -32,13 +46,15 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 		return nil, newError("no space in context").AtWarning()
 	}
 	s := &Server{
-		config: config,
+		config:       config,
+		associations: make(map[string][]*udpAssociation),
 	}
 	space.On(app.SpaceInitializing, func(interface{}) error {
 		pm := policy.FromSpace(space)
 		if pm == nil {
 			return newError("Policy not found in space.")
 		}
+		s.policyManager = pm
 		s.policy = pm.GetPolicy(config.UserLevel)
 		if config.Timeout > 0 && config.UserLevel == 0 {
 			s.policy.Timeout.ConnectionIdle.Value = config.Timeout
@@ -96,11 +112,21 @@ func (s *Server) processTCP(ctx context.Context, conn internet.Connection, dispa
 	}
 	conn.SetReadDeadline(time.Time{})
 
+	user := s.user(request)
+	source, _ := proxy.SourceFromContext(ctx)
+	ctx = protocol.ContextWithUser(ctx, user)
+
 	if request.Command == protocol.RequestCommandTCP {
 		dest := request.Destination()
+		release, err := s.acquireSession(user, source, dest)
+		if err != nil {
+			return err
+		}
+		defer release()
+
 		newError("TCP Connect request to ", dest).WriteToLog()
 		if source, ok := proxy.SourceFromContext(ctx); ok {
-			log.Record(&log.AccessMessage{
+			ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 				From:   source,
 				To:     dest,
 				Status: log.AccessAccepted,
@@ -112,12 +138,131 @@ func (s *Server) processTCP(ctx context.Context, conn internet.Connection, dispa
 	}
 
 	if request.Command == protocol.RequestCommandUDP {
+		// The session of the user is acquired by the UDP relay of the association, so that it counts once.
+		if source.Address != nil {
+			defer s.associate(source.Address, request.Port, user)()
+		}
 		return s.handleUDP(conn)
 	}
 
 	return nil
 }
 
+// acquireSession registers a session of the user from the source, and records the rejection in the access log if the
+// policy of the user doesn't allow it.
+func (s *Server) acquireSession(user *protocol.User, source net.Destination, dest interface{}) (func(), error) {
+	release, err := s.policyManager.AcquireSession(user, source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   source,
+			To:     dest,
+			Status: log.AccessRejected,
+			Reason: err,
+		})
+		return nil, newError("session of ", user.Email, " rejected").Base(err)
+	}
+	return release, nil
+}
+
+// user returns the user of the request. Users without authentication have the user level in the config only.
+func (s *Server) user(request *protocol.RequestHeader) *protocol.User {
+	if request.User != nil {
+		return request.User
+	}
+	return &protocol.User{
+		Level: s.config.UserLevel,
+	}
+}
+
+// associate registers a UDP associate request of the user from the client IP, until the returned function is called.
+// The port is the one that the client declares to send UDP packets from, or 0 if unknown.
+func (s *Server) associate(client net.Address, port net.Port, user *protocol.User) func() {
+	key := client.String()
+	a := &udpAssociation{
+		user: user,
+		port: port,
+	}
+
+	s.access.Lock()
+	s.associations[key] = append(s.associations[key], a)
+	s.access.Unlock()
+
+	return func() {
+		s.access.Lock()
+		defer s.access.Unlock()
+
+		list := s.associations[key]
+		for i, other := range list {
+			if other == a {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(s.associations, key)
+		} else {
+			s.associations[key] = list
+		}
+	}
+}
+
+// udpUser returns the user of the UDP packets from the source. The packets belong to the association that they are
+// already bound to, or else the one that declares their port. Otherwise, they are bound to the only association from
+// the IP with an unknown port. If there are more, clients behind the same NAT can't be told apart, and the packets
+// are rejected rather than relayed for any of them. Without password authentication, packets of no association are
+// relayed for the user level in the config.
+func (s *Server) udpUser(source net.Destination) (*protocol.User, error) {
+	if source.Address != nil {
+		s.access.Lock()
+		a, err := s.bindAssociation(source)
+		s.access.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			return a.user, nil
+		}
+	}
+	if s.config.AuthType == AuthType_PASSWORD {
+		return nil, newError("no UDP associate request from ", source)
+	}
+	return &protocol.User{
+		Level: s.config.UserLevel,
+	}, nil
+}
+
+// bindAssociation returns the association of the UDP packets from the source, or nil if there is none. Caller must
+// hold the lock.
+func (s *Server) bindAssociation(source net.Destination) (*udpAssociation, error) {
+	list := s.associations[source.Address.String()]
+	for _, a := range list {
+		if a.source == source {
+			return a, nil
+		}
+	}
+	for _, a := range list {
+		if !a.source.IsValid() && a.port != 0 && a.port == source.Port {
+			a.source = source
+			return a, nil
+		}
+	}
+	var unknown []*udpAssociation
+	for _, a := range list {
+		if !a.source.IsValid() && a.port == 0 {
+			unknown = append(unknown, a)
+		}
+	}
+	switch len(unknown) {
+	case 0:
+		return nil, nil
+	case 1:
+		unknown[0].source = source
+		return unknown[0], nil
+	default:
+		return nil, newError("ambiguous UDP associate requests from ", source.Address, " without ports")
+	}
+}
+
 func (*Server) handleUDP(c net.Conn) error {
 	// The TCP connection closes after this method returns. We need to wait until
 	// the client closes it.
@@ -167,11 +312,35 @@ func (v *Server) transport(ctx context.Context, reader io.Reader, writer io.Writ
 }
 
 func (v *Server) handleUDPPayload(ctx context.Context, conn internet.Connection, dispatcher dispatcher.Interface) error {
-	udpServer := udp.NewDispatcher(dispatcher)
-
-	if source, ok := proxy.SourceFromContext(ctx); ok {
-		newError("client UDP connection from ", source).WriteToLog()
+	source, _ := proxy.SourceFromContext(ctx)
+	user, err := v.udpUser(source)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   source,
+			To:     "",
+			Status: log.AccessRejected,
+			Reason: err,
+		})
+		return err
 	}
+	release, err := v.acquireSession(user, source, "")
+	if err != nil {
+		return err
+	}
+	defer release()
+	// All packets of the client go through one full-cone connection, so that peers see the same source for every
+	// destination, and may send packets back to the client from any address.
+	ctx = protocol.ContextWithUser(ctx, user)
+	ctx = proxy.ContextWithFullCone(ctx)
+
+	newError("client UDP connection from ", source).WriteToLog()
+
+	var inboundRay ray.InboundRay
+	defer func() {
+		if inboundRay != nil {
+			inboundRay.InboundInput().Close()
+		}
+	}()
 
 	reader := buf.NewReader(conn)
 	for {
@@ -192,33 +361,81 @@ func (v *Server) handleUDPPayload(ctx context.Context, conn internet.Connection,
 				continue
 			}
 
-			newError("send packet to ", request.Destination(), " with ", len(data), " bytes").AtDebug().WriteToLog()
+			destination := request.Destination()
+			newError("send packet to ", destination, " with ", len(data), " bytes").AtDebug().WriteToLog()
 			if source, ok := proxy.SourceFromContext(ctx); ok {
 				log.Record(&log.AccessMessage{
 					From:   source,
-					To:     request.Destination,
+					To:     destination,
 					Status: log.AccessAccepted,
 					Reason: "",
 				})
 			}
 
+			if inboundRay == nil {
+				// The connection is routed by the destination of the first packet.
+				inboundRay, err = dispatcher.Dispatch(ctx, destination)
+				if err != nil {
+					mpayload.Release()
+					return newError("failed to dispatch UDP packets to ", destination).Base(err)
+				}
+				go func(output ray.InputStream, dest net.Destination) {
+					writer := &udpResponseWriter{
+						conn: conn,
+						dest: dest,
+					}
+					if err := buf.Copy(output, writer); err != nil {
+						newError("failed to write UDP response").Base(err).WriteToLog()
+						output.CloseError()
+					}
+				}(inboundRay.InboundOutput(), destination)
+			}
+
 			dataBuf := buf.New()
 			dataBuf.Append(data)
-			udpServer.Dispatch(ctx, request.Destination(), dataBuf, func(payload *buf.Buffer) {
-				defer payload.Release()
+			dataBuf.SetDestination(destination)
+			if err := inboundRay.InboundInput().WriteMultiBuffer(buf.NewMultiBufferValue(dataBuf)); err != nil {
+				mpayload.Release()
+				return newError("failed to send UDP packet to ", destination).Base(err)
+			}
+		}
+		mpayload.Release()
+	}
+}
 
-				newError("writing back UDP response with ", payload.Len(), " bytes").AtDebug().WriteToLog()
+// udpResponseWriter writes packets back to the client of a UDP relay, with the address of the peer that each packet
+// comes from, or the default destination if the packet has none.
+type udpResponseWriter struct {
+	conn net.Conn
+	dest net.Destination
+}
 
-				udpMessage, err := EncodeUDPPacket(request, payload.Bytes())
-				defer udpMessage.Release()
-				if err != nil {
-					newError("failed to write UDP response").AtWarning().Base(err).WriteToLog()
-				}
+// WriteMultiBuffer implements buf.Writer.
+func (w *udpResponseWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer mb.Release()
 
-				conn.Write(udpMessage.Bytes())
-			})
+	for _, b := range mb {
+		dest := b.Destination()
+		if !dest.IsValid() {
+			dest = w.dest
+		}
+		newError("writing back UDP response from ", dest, " with ", b.Len(), " bytes").AtDebug().WriteToLog()
+		packet, err := EncodeUDPPacket(&protocol.RequestHeader{
+			Command: protocol.RequestCommandUDP,
+			Address: dest.Address,
+			Port:    dest.Port,
+		}, b.Bytes())
+		if err != nil {
+			newError("failed to write UDP response").AtWarning().Base(err).WriteToLog()
+			continue
+		}
+		_, err = w.conn.Write(packet.Bytes())
+		packet.Release()
+		if err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
 func init() {
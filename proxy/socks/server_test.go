@@ -0,0 +1,435 @@
+package socks_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"v2ray.com/core/app"
+	"v2ray.com/core/app/dispatcher"
+	_ "v2ray.com/core/app/dispatcher/impl"
+	"v2ray.com/core/app/policy"
+	_ "v2ray.com/core/app/policy/manager"
+	"v2ray.com/core/app/proxyman"
+	_ "v2ray.com/core/app/proxyman/inbound"
+	_ "v2ray.com/core/app/proxyman/outbound"
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/log"
+	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol"
+	"v2ray.com/core/common/serial"
+	"v2ray.com/core/proxy/freedom"
+	. "v2ray.com/core/proxy/socks"
+	"v2ray.com/core/testing/servers/tcp"
+	"v2ray.com/core/testing/servers/udp"
+	_ "v2ray.com/core/transport/internet/tcp"
+	_ "v2ray.com/core/transport/internet/udp"
+	. "v2ray.com/ext/assert"
+)
+
+// accessLogger keeps the access log messages.
+type accessLogger struct {
+	sync.Mutex
+	messages []*log.AccessMessage
+}
+
+func (l *accessLogger) Handle(msg log.Message) {
+	if msg, ok := msg.(*log.AccessMessage); ok {
+		l.Lock()
+		l.messages = append(l.messages, msg)
+		l.Unlock()
+	}
+}
+
+// waitRejected waits for a rejected access whose reason contains the given text.
+func (l *accessLogger) waitRejected(reason string) *log.AccessMessage {
+	for i := 0; i < 50; i++ {
+		l.Lock()
+		for _, msg := range l.messages {
+			if msg.Status == log.AccessRejected && strings.Contains(serial.ToString(msg.Reason), reason) {
+				l.Unlock()
+				return msg
+			}
+		}
+		l.Unlock()
+		time.Sleep(time.Millisecond * 20)
+	}
+	return nil
+}
+
+func startSocksServer(port net.Port, policyConfig *policy.Config) app.Space {
+	ctx := context.Background()
+	space := app.NewSpace()
+	ctx = app.ContextWithSpace(ctx, space)
+	common.Must(app.AddApplicationToSpace(ctx, &dispatcher.Config{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.InboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, &proxyman.OutboundConfig{}))
+	common.Must(app.AddApplicationToSpace(ctx, policyConfig))
+
+	om := proxyman.OutboundHandlerManagerFromSpace(space)
+	common.Must(om.AddHandler(ctx, &proxyman.OutboundHandlerConfig{
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	}))
+
+	im := proxyman.InboundHandlerManagerFromSpace(space)
+	common.Must(im.AddHandler(ctx, &proxyman.InboundHandlerConfig{
+		ReceiverSettings: serial.ToTypedMessage(&proxyman.ReceiverConfig{
+			Listen:    net.NewIPOrDomain(net.LocalHostIP),
+			PortRange: net.SinglePortRange(port),
+		}),
+		ProxySettings: serial.ToTypedMessage(&ServerConfig{
+			AuthType:   AuthType_PASSWORD,
+			Accounts:   map[string]string{"v2ray": "v2ray"},
+			Address:    net.NewIPOrDomain(net.LocalHostIP),
+			UdpEnabled: true,
+		}),
+	}))
+
+	common.Must(space.Initialize())
+	common.Must(space.Start())
+	return space
+}
+
+func socksUser() *protocol.User {
+	return &protocol.User{
+		Account: serial.ToTypedMessage(&Account{
+			Username: "v2ray",
+			Password: "v2ray",
+		}),
+	}
+}
+
+// socksHandshake sends a request of the given command to the SOCKS server through a new TCP connection.
+func socksHandshake(port net.Port, command protocol.RequestCommand, dest net.Destination) (net.Conn, error) {
+	conn, err := net.DialTCP("tcp", nil, &net.TCPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ClientHandshake(&protocol.RequestHeader{
+		Version: 5,
+		Command: command,
+		Address: dest.Address,
+		Port:    dest.Port,
+		User:    socksUser(),
+	}, conn, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sendUDP sends the payload to dest through the SOCKS server, and returns the response, or nil if there is none.
+func sendUDP(conn net.Conn, dest net.Destination, payload []byte) []byte {
+	packet, err := EncodeUDPPacket(&protocol.RequestHeader{
+		Command: protocol.RequestCommandUDP,
+		Address: dest.Address,
+		Port:    dest.Port,
+	}, payload)
+	common.Must(err)
+	defer packet.Release()
+	if _, err := conn.Write(packet.Bytes()); err != nil {
+		return nil
+	}
+
+	b := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(b)
+	if err != nil {
+		return nil
+	}
+	_, data, err := DecodeUDPPacket(b[:n])
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func TestUDPAssociate(t *testing.T) {
+	assert := With(t)
+
+	logger := &accessLogger{}
+	log.RegisterHandler(logger)
+
+	udpServer := udp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := udpServer.Start()
+	assert(err, IsNil)
+	defer udpServer.Close()
+
+	port := tcp.PickPort()
+	space := startSocksServer(port, &policy.Config{})
+	defer space.Close()
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	assert(err, IsNil)
+	defer conn.Close()
+
+	// Clients must authenticate with a UDP associate request first.
+	assert(sendUDP(conn, dest, []byte("ping")), IsNil)
+	assert(logger.waitRejected("no UDP associate request"), IsNotNil)
+	time.Sleep(time.Millisecond * 100)
+
+	associate, err := socksHandshake(port, protocol.RequestCommandUDP, net.UDPDestination(net.AnyIP, 0))
+	assert(err, IsNil)
+	defer associate.Close()
+
+	assert(string(sendUDP(conn, dest, []byte("ping"))), Equals, "ping")
+}
+
+func TestUDPSessionLimit(t *testing.T) {
+	assert := With(t)
+
+	logger := &accessLogger{}
+	log.RegisterHandler(logger)
+
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	tcpDest, err := tcpServer.Start()
+	assert(err, IsNil)
+	defer tcpServer.Close()
+
+	udpServer := udp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	udpDest, err := udpServer.Start()
+	assert(err, IsNil)
+	defer udpServer.Close()
+
+	port := tcp.PickPort()
+	space := startSocksServer(port, &policy.Config{
+		Level: map[uint32]*policy.Policy{
+			0: {
+				Session: &policy.Policy_Session{
+					MaxConnections: 1,
+				},
+			},
+		},
+	})
+	defer space.Close()
+
+	session, err := socksHandshake(port, protocol.RequestCommandTCP, tcpDest)
+	assert(err, IsNil)
+	_, err = session.Write([]byte("ping"))
+	assert(err, IsNil)
+	b := make([]byte, 4)
+	_, err = io.ReadFull(session, b)
+	assert(err, IsNil)
+
+	associate, err := socksHandshake(port, protocol.RequestCommandUDP, net.UDPDestination(net.AnyIP, 0))
+	assert(err, IsNil)
+	defer associate.Close()
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	assert(err, IsNil)
+	defer conn.Close()
+
+	// The TCP session takes the only session of the user, so the UDP relay is rejected.
+	assert(sendUDP(conn, udpDest, []byte("ping")), IsNil)
+	msg := logger.waitRejected("too many connections of user v2ray")
+	assert(msg, IsNotNil)
+	assert(msg.From.(net.Destination).Address, Equals, net.LocalHostIP)
+	session.Close()
+}
+
+// receiveUDP reads a packet from the SOCKS server, and returns the address in its header with the payload.
+func receiveUDP(conn net.Conn) (net.Destination, []byte, error) {
+	b := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	n, err := conn.Read(b)
+	if err != nil {
+		return net.Destination{}, nil, err
+	}
+	request, data, err := DecodeUDPPacket(b[:n])
+	if err != nil {
+		return net.Destination{}, nil, err
+	}
+	return request.Destination(), data, nil
+}
+
+// listenPeer listens on a UDP port of localhost, and returns its address.
+func listenPeer() (*net.UDPConn, net.Destination, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: 0,
+	})
+	if err != nil {
+		return nil, net.Destination{}, err
+	}
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	return conn, net.UDPDestination(net.IPAddress(addr.IP), net.Port(addr.Port)), nil
+}
+
+// readPeer reads a packet sent to the peer, and returns the payload with the address it comes from.
+func readPeer(conn *net.UDPConn) (string, *net.UDPAddr, error) {
+	b := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	n, addr, err := conn.ReadFromUDP(b)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b[:n]), addr, nil
+}
+
+func TestUDPFullCone(t *testing.T) {
+	assert := With(t)
+
+	peer1, dest1, err := listenPeer()
+	assert(err, IsNil)
+	defer peer1.Close()
+
+	peer2, dest2, err := listenPeer()
+	assert(err, IsNil)
+	defer peer2.Close()
+
+	port := tcp.PickPort()
+	space := startSocksServer(port, &policy.Config{})
+	defer space.Close()
+
+	associate, err := socksHandshake(port, protocol.RequestCommandUDP, net.UDPDestination(net.AnyIP, 0))
+	assert(err, IsNil)
+	defer associate.Close()
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	assert(err, IsNil)
+	defer conn.Close()
+
+	request, err := EncodeUDPPacket(&protocol.RequestHeader{
+		Command: protocol.RequestCommandUDP,
+		Address: dest1.Address,
+		Port:    dest1.Port,
+	}, []byte("ping"))
+	assert(err, IsNil)
+	_, err = conn.Write(request.Bytes())
+	assert(err, IsNil)
+	request.Release()
+
+	payload, relay, err := readPeer(peer1)
+	assert(err, IsNil)
+	assert(payload, Equals, "ping")
+
+	// The second peer has never been sent anything, but its packet still reaches the client, with its own address.
+	_, err = peer2.WriteToUDP([]byte("pong"), relay)
+	assert(err, IsNil)
+	from, data, err := receiveUDP(conn)
+	assert(err, IsNil)
+	assert(from, Equals, dest2)
+	assert(string(data), Equals, "pong")
+
+	// Packets to the second peer come from the same address as those to the first one.
+	request, err = EncodeUDPPacket(&protocol.RequestHeader{
+		Command: protocol.RequestCommandUDP,
+		Address: dest2.Address,
+		Port:    dest2.Port,
+	}, []byte("ping"))
+	assert(err, IsNil)
+	_, err = conn.Write(request.Bytes())
+	assert(err, IsNil)
+	request.Release()
+
+	payload, addr, err := readPeer(peer2)
+	assert(err, IsNil)
+	assert(payload, Equals, "ping")
+	assert(addr.String(), Equals, relay.String())
+
+	_, err = peer1.WriteToUDP([]byte("pong"), relay)
+	assert(err, IsNil)
+	from, data, err = receiveUDP(conn)
+	assert(err, IsNil)
+	assert(from, Equals, dest1)
+	assert(string(data), Equals, "pong")
+}
+
+// dialUDP connects to the UDP port of the SOCKS server, and returns the connection with its local port.
+func dialUDP(port net.Port) (*net.UDPConn, net.Port, error) {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: int(port),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return conn, net.Port(conn.LocalAddr().(*net.UDPAddr).Port), nil
+}
+
+func TestUDPAssociateSharedAddress(t *testing.T) {
+	assert := With(t)
+
+	logger := &accessLogger{}
+	log.RegisterHandler(logger)
+
+	udpServer := udp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := udpServer.Start()
+	assert(err, IsNil)
+	defer udpServer.Close()
+
+	port := tcp.PickPort()
+	space := startSocksServer(port, &policy.Config{})
+	defer space.Close()
+
+	conn1, port1, err := dialUDP(port)
+	assert(err, IsNil)
+	defer conn1.Close()
+	conn2, port2, err := dialUDP(port)
+	assert(err, IsNil)
+	defer conn2.Close()
+	conn3, _, err := dialUDP(port)
+	assert(err, IsNil)
+	defer conn3.Close()
+
+	// Clients that declare their ports are told apart, even from the same IP.
+	associate1, err := socksHandshake(port, protocol.RequestCommandUDP, net.UDPDestination(net.LocalHostIP, port1))
+	assert(err, IsNil)
+	defer associate1.Close()
+	associate2, err := socksHandshake(port, protocol.RequestCommandUDP, net.UDPDestination(net.LocalHostIP, port2))
+	assert(err, IsNil)
+	defer associate2.Close()
+
+	assert(string(sendUDP(conn1, dest, []byte("ping"))), Equals, "ping")
+	assert(string(sendUDP(conn2, dest, []byte("ping"))), Equals, "ping")
+
+	// Packets from other ports don't belong to either of them.
+	assert(sendUDP(conn3, dest, []byte("ping")), IsNil)
+	assert(logger.waitRejected("no UDP associate request"), IsNotNil)
+
+	// Two clients without ports can't be told apart.
+	associate3, err := socksHandshake(port, protocol.RequestCommandUDP, net.UDPDestination(net.AnyIP, 0))
+	assert(err, IsNil)
+	defer associate3.Close()
+	associate4, err := socksHandshake(port, protocol.RequestCommandUDP, net.UDPDestination(net.AnyIP, 0))
+	assert(err, IsNil)
+
+	assert(sendUDP(conn3, dest, []byte("ping")), IsNil)
+	assert(logger.waitRejected("ambiguous UDP associate requests"), IsNotNil)
+
+	associate4.Close()
+	time.Sleep(time.Millisecond * 100)
+
+	assert(string(sendUDP(conn3, dest, []byte("ping"))), Equals, "ping")
+}
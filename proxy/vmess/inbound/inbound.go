@@ -21,6 +21,7 @@ import (
 	"v2ray.com/core/common/serial"
 	"v2ray.com/core/common/signal"
 	"v2ray.com/core/common/uuid"
+	"v2ray.com/core/proxy"
 	"v2ray.com/core/proxy/vmess"
 	"v2ray.com/core/proxy/vmess/encoding"
 	"v2ray.com/core/transport/internet"
@@ -208,7 +209,20 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection i
 		request.Port = net.Port(0)
 	}
 
-	log.Record(&log.AccessMessage{
+	source, _ := proxy.SourceFromContext(ctx)
+	release, err := h.policyManager.AcquireSession(request.User, source.Address)
+	if err != nil {
+		log.Record(&log.AccessMessage{
+			From:   connection.RemoteAddr(),
+			To:     request.Destination(),
+			Status: log.AccessRejected,
+			Reason: err,
+		})
+		return newError("session of ", request.User.Email, " rejected").Base(err)
+	}
+	defer release()
+
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
 		From:   connection.RemoteAddr(),
 		To:     request.Destination(),
 		Status: log.AccessAccepted,
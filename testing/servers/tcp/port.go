@@ -0,0 +1,15 @@
+package tcp
+
+import (
+	"v2ray.com/core/common"
+	"v2ray.com/core/common/net"
+)
+
+func PickPort() net.Port {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return net.Port(addr.Port)
+}
@@ -92,3 +92,8 @@ func ApplyGlobalTransportSettings(settings []*TransportConfig) error {
 func (c *ProxyConfig) HasTag() bool {
 	return c != nil && len(c.Tag) > 0
 }
+
+// HasChain returns true if the config has a chain of outbound handlers.
+func (c *ProxyConfig) HasChain() bool {
+	return c != nil && len(c.Chain) > 0
+}
@@ -43,6 +43,31 @@ func (x TransportProtocol) String() string {
 }
 func (TransportProtocol) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+type SocketConfig_State int32
+
+const (
+	// Leave the option as the system default.
+	SocketConfig_AsIs    SocketConfig_State = 0
+	SocketConfig_Enable  SocketConfig_State = 1
+	SocketConfig_Disable SocketConfig_State = 2
+)
+
+var SocketConfig_State_name = map[int32]string{
+	0: "AsIs",
+	1: "Enable",
+	2: "Disable",
+}
+var SocketConfig_State_value = map[string]int32{
+	"AsIs":    0,
+	"Enable":  1,
+	"Disable": 2,
+}
+
+func (x SocketConfig_State) String() string {
+	return proto.EnumName(SocketConfig_State_name, int32(x))
+}
+func (SocketConfig_State) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{3, 0} }
+
 type TransportConfig struct {
 	// Type of network that this settings supports.
 	Protocol TransportProtocol `protobuf:"varint,1,opt,name=protocol,enum=v2ray.core.transport.internet.TransportProtocol" json:"protocol,omitempty"`
@@ -76,6 +101,8 @@ type StreamConfig struct {
 	// Type of security. Must be a message name of the settings proto.
 	SecurityType     string                                   `protobuf:"bytes,3,opt,name=security_type,json=securityType" json:"security_type,omitempty"`
 	SecuritySettings []*v2ray_core_common_serial.TypedMessage `protobuf:"bytes,4,rep,name=security_settings,json=securitySettings" json:"security_settings,omitempty"`
+	// Options of the underlying sockets, for both dialing and listening.
+	SocketSettings *SocketConfig `protobuf:"bytes,5,opt,name=socket_settings,json=socketSettings" json:"socket_settings,omitempty"`
 }
 
 func (m *StreamConfig) Reset()                    { *m = StreamConfig{} }
@@ -111,8 +138,19 @@ func (m *StreamConfig) GetSecuritySettings() []*v2ray_core_common_serial.TypedMe
 	return nil
 }
 
+func (m *StreamConfig) GetSocketSettings() *SocketConfig {
+	if m != nil {
+		return m.SocketSettings
+	}
+	return nil
+}
+
 type ProxyConfig struct {
 	Tag string `protobuf:"bytes,1,opt,name=tag" json:"tag,omitempty"`
+	// Tags of outbound handlers to tunnel through, in order. The first handler connects directly, and each of the others
+	// connects through the previous one, with its own transport and security settings. The proxy settings of the
+	// handlers in the chain are ignored. It takes precedence over tag.
+	Chain []string `protobuf:"bytes,2,rep,name=chain" json:"chain,omitempty"`
 }
 
 func (m *ProxyConfig) Reset()                    { *m = ProxyConfig{} }
@@ -127,39 +165,134 @@ func (m *ProxyConfig) GetTag() string {
 	return ""
 }
 
+func (m *ProxyConfig) GetChain() []string {
+	if m != nil {
+		return m.Chain
+	}
+	return nil
+}
+
+// SocketConfig is the options of sockets. Options that are not supported by the platform are ignored.
+type SocketConfig struct {
+	// Mark of the packets sent by the socket, for policy routing. Linux only. 0 for no mark.
+	Mark uint32 `protobuf:"varint,1,opt,name=mark" json:"mark,omitempty"`
+	// TCP Fast Open, on the client side for dialing, and the server side for listening.
+	Tfo SocketConfig_State `protobuf:"varint,2,opt,name=tfo,enum=v2ray.core.transport.internet.SocketConfig_State" json:"tfo,omitempty"`
+	// Name of the network interface to bind the socket to. Linux only.
+	BindToDevice string `protobuf:"bytes,3,opt,name=bind_to_device,json=bindToDevice" json:"bind_to_device,omitempty"`
+	// Interval in seconds between TCP keepalive probes. System default if unset.
+	TcpKeepAliveInterval uint32 `protobuf:"varint,4,opt,name=tcp_keep_alive_interval,json=tcpKeepAliveInterval" json:"tcp_keep_alive_interval,omitempty"`
+	// TCP_NODELAY, which is enabled by default.
+	TcpNoDelay SocketConfig_State `protobuf:"varint,5,opt,name=tcp_no_delay,json=tcpNoDelay,enum=v2ray.core.transport.internet.SocketConfig_State" json:"tcp_no_delay,omitempty"`
+	// Name of the TCP congestion control algorithm, such as "bbr". Linux only.
+	TcpCongestion string `protobuf:"bytes,6,opt,name=tcp_congestion,json=tcpCongestion" json:"tcp_congestion,omitempty"`
+	// Allows binding to addresses that are not configured on any interface, with IP_FREEBIND. Linux only. It is
+	// enabled for outbounds whose via_pool has networks, so that any address in them can be the source.
+	Freebind bool `protobuf:"varint,7,opt,name=freebind" json:"freebind,omitempty"`
+}
+
+func (m *SocketConfig) Reset()                    { *m = SocketConfig{} }
+func (m *SocketConfig) String() string            { return proto.CompactTextString(m) }
+func (*SocketConfig) ProtoMessage()               {}
+func (*SocketConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *SocketConfig) GetMark() uint32 {
+	if m != nil {
+		return m.Mark
+	}
+	return 0
+}
+
+func (m *SocketConfig) GetTfo() SocketConfig_State {
+	if m != nil {
+		return m.Tfo
+	}
+	return SocketConfig_AsIs
+}
+
+func (m *SocketConfig) GetBindToDevice() string {
+	if m != nil {
+		return m.BindToDevice
+	}
+	return ""
+}
+
+func (m *SocketConfig) GetTcpKeepAliveInterval() uint32 {
+	if m != nil {
+		return m.TcpKeepAliveInterval
+	}
+	return 0
+}
+
+func (m *SocketConfig) GetTcpNoDelay() SocketConfig_State {
+	if m != nil {
+		return m.TcpNoDelay
+	}
+	return SocketConfig_AsIs
+}
+
+func (m *SocketConfig) GetTcpCongestion() string {
+	if m != nil {
+		return m.TcpCongestion
+	}
+	return ""
+}
+
+func (m *SocketConfig) GetFreebind() bool {
+	if m != nil {
+		return m.Freebind
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*TransportConfig)(nil), "v2ray.core.transport.internet.TransportConfig")
 	proto.RegisterType((*StreamConfig)(nil), "v2ray.core.transport.internet.StreamConfig")
 	proto.RegisterType((*ProxyConfig)(nil), "v2ray.core.transport.internet.ProxyConfig")
+	proto.RegisterType((*SocketConfig)(nil), "v2ray.core.transport.internet.SocketConfig")
 	proto.RegisterEnum("v2ray.core.transport.internet.TransportProtocol", TransportProtocol_name, TransportProtocol_value)
+	proto.RegisterEnum("v2ray.core.transport.internet.SocketConfig_State", SocketConfig_State_name, SocketConfig_State_value)
 }
 
 func init() { proto.RegisterFile("v2ray.com/core/transport/internet/config.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 374 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x91, 0x4d, 0x4b, 0xeb, 0x40,
-	0x14, 0x86, 0x6f, 0x92, 0x72, 0x6f, 0x7a, 0xda, 0x5e, 0xd3, 0x59, 0x15, 0xa1, 0x58, 0x2b, 0x48,
-	0x70, 0x31, 0x29, 0x71, 0xef, 0xa2, 0x71, 0x23, 0x5a, 0x0c, 0x49, 0x55, 0x10, 0xa4, 0xa4, 0xe3,
-	0x18, 0x82, 0x4d, 0xa6, 0x4c, 0x46, 0x31, 0xbf, 0xc7, 0x9d, 0x7b, 0xff, 0x9f, 0xe4, 0x63, 0x86,
-	0xa2, 0x50, 0xba, 0x71, 0x37, 0x64, 0xde, 0xf3, 0x9c, 0x27, 0xef, 0x00, 0x7e, 0x75, 0x79, 0x54,
-	0x60, 0xc2, 0x52, 0x87, 0x30, 0x4e, 0x1d, 0xc1, 0xa3, 0x2c, 0x5f, 0x33, 0x2e, 0x9c, 0x24, 0x13,
-	0x94, 0x67, 0x54, 0x38, 0x84, 0x65, 0x4f, 0x49, 0x8c, 0xd7, 0x9c, 0x09, 0x86, 0x86, 0x32, 0xcf,
-	0x29, 0x56, 0x59, 0x2c, 0xb3, 0xfb, 0x93, 0x6f, 0x38, 0xc2, 0xd2, 0x94, 0x65, 0x4e, 0x4e, 0x79,
-	0x12, 0xad, 0x1c, 0x51, 0xac, 0xe9, 0xe3, 0x22, 0xa5, 0x79, 0x1e, 0xc5, 0xb4, 0x06, 0x8e, 0xdf,
-	0x35, 0xd8, 0x9b, 0x4b, 0x90, 0x57, 0xad, 0x42, 0x57, 0x60, 0x56, 0x97, 0x84, 0xad, 0x06, 0xda,
-	0x48, 0xb3, 0xff, 0xbb, 0x13, 0xbc, 0x75, 0x2f, 0x56, 0x04, 0xbf, 0x99, 0x0b, 0x14, 0x01, 0x4d,
-	0xc1, 0xcc, 0xa9, 0x10, 0x49, 0x16, 0xe7, 0x03, 0x7d, 0xa4, 0xd9, 0x1d, 0xf7, 0x78, 0x93, 0x56,
-	0x2b, 0xe2, 0x5a, 0x11, 0xcf, 0x4b, 0xc5, 0x59, 0x6d, 0x18, 0xa8, 0xb9, 0xf1, 0xa7, 0x0e, 0xdd,
-	0x50, 0x70, 0x1a, 0xa5, 0xbf, 0xa2, 0xf8, 0x00, 0x48, 0x4d, 0x2c, 0x36, 0x64, 0x0d, 0xbb, 0xe3,
-	0xe2, 0x5d, 0xb9, 0xb5, 0x59, 0xd0, 0x57, 0x99, 0xb0, 0x01, 0xa1, 0x23, 0xe8, 0xe5, 0x94, 0xbc,
-	0xf0, 0x44, 0x14, 0x8b, 0xf2, 0x0d, 0x06, 0xc6, 0x48, 0xb3, 0xdb, 0x41, 0x57, 0x7e, 0x2c, 0x7f,
-	0x1a, 0x85, 0xd0, 0x57, 0x21, 0xa5, 0xd0, 0xaa, 0x14, 0x76, 0xed, 0xcb, 0x92, 0x00, 0xb9, 0x79,
-	0x7c, 0x00, 0x1d, 0x9f, 0xb3, 0xb7, 0xa2, 0x69, 0xcd, 0x02, 0x43, 0x44, 0x71, 0x55, 0x58, 0x3b,
-	0x28, 0x8f, 0x27, 0x67, 0xd0, 0xff, 0x51, 0x0c, 0xfa, 0x07, 0xc6, 0xdc, 0xf3, 0xad, 0x3f, 0xe5,
-	0xe1, 0xe6, 0xdc, 0xb7, 0x34, 0x64, 0x42, 0x6b, 0x76, 0xe9, 0xf9, 0x96, 0x8e, 0x7a, 0xd0, 0xbe,
-	0xa3, 0xcb, 0x90, 0x91, 0x67, 0x2a, 0x2c, 0x63, 0x7a, 0x0d, 0x87, 0x84, 0xa5, 0xdb, 0x2b, 0xf2,
-	0xb5, 0x7b, 0x53, 0x9e, 0x3f, 0xf4, 0xe1, 0xad, 0x1b, 0x44, 0x05, 0xf6, 0xca, 0xac, 0x5a, 0x8d,
-	0x2f, 0x9a, 0xfb, 0xe5, 0xdf, 0xea, 0x51, 0x4e, 0xbf, 0x02, 0x00, 0x00, 0xff, 0xff, 0xbf, 0x4a,
-	0x68, 0x51, 0x19, 0x03, 0x00, 0x00,
+	// 590 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x93, 0x5d, 0x6b, 0x13, 0x4d,
+	0x14, 0xc7, 0xbb, 0xd9, 0xa4, 0x4d, 0x4e, 0x9a, 0x74, 0x3b, 0x14, 0x9e, 0xa5, 0x50, 0xc8, 0x13,
+	0x5f, 0x08, 0x15, 0x26, 0x35, 0xd2, 0x5b, 0xa1, 0x4d, 0xbc, 0x28, 0xb5, 0x1a, 0x36, 0x51, 0x41,
+	0x90, 0x65, 0x32, 0x3d, 0x8d, 0x4b, 0xb3, 0x3b, 0xcb, 0xcc, 0x18, 0xcc, 0x37, 0x10, 0xfc, 0x18,
+	0xde, 0xf9, 0x29, 0x65, 0x66, 0xb3, 0x43, 0x50, 0xa8, 0x56, 0xf0, 0x6e, 0xe6, 0xbc, 0xfc, 0xce,
+	0xff, 0x3f, 0x67, 0x17, 0xe8, 0x72, 0x20, 0xd9, 0x8a, 0x72, 0x91, 0xf6, 0xb9, 0x90, 0xd8, 0xd7,
+	0x92, 0x65, 0x2a, 0x17, 0x52, 0xf7, 0x93, 0x4c, 0xa3, 0xcc, 0x50, 0xf7, 0xb9, 0xc8, 0x6e, 0x92,
+	0x39, 0xcd, 0xa5, 0xd0, 0x82, 0x1c, 0x95, 0xf5, 0x12, 0xa9, 0xab, 0xa5, 0x65, 0xed, 0xe1, 0xc9,
+	0x4f, 0x38, 0x2e, 0xd2, 0x54, 0x64, 0x7d, 0x85, 0x32, 0x61, 0x8b, 0xbe, 0x5e, 0xe5, 0x78, 0x1d,
+	0xa7, 0xa8, 0x14, 0x9b, 0x63, 0x01, 0xec, 0x7e, 0xf3, 0x60, 0x6f, 0x5a, 0x82, 0x86, 0x76, 0x14,
+	0x79, 0x09, 0x75, 0x9b, 0xe4, 0x62, 0x11, 0x7a, 0x1d, 0xaf, 0xd7, 0x1e, 0x9c, 0xd0, 0x3b, 0xe7,
+	0x52, 0x47, 0x18, 0xaf, 0xfb, 0x22, 0x47, 0x20, 0xe7, 0x50, 0x57, 0xa8, 0x75, 0x92, 0xcd, 0x55,
+	0x58, 0xe9, 0x78, 0xbd, 0xe6, 0xe0, 0xf1, 0x26, 0xad, 0x90, 0x48, 0x0b, 0x89, 0x74, 0x6a, 0x24,
+	0x5e, 0x15, 0x0a, 0x23, 0xd7, 0xd7, 0xfd, 0xea, 0xc3, 0xee, 0x44, 0x4b, 0x64, 0xe9, 0x3f, 0x91,
+	0xf8, 0x01, 0x88, 0xeb, 0x88, 0x37, 0xc4, 0xfa, 0xbd, 0xe6, 0x80, 0xfe, 0x29, 0xb7, 0x50, 0x16,
+	0xed, 0xbb, 0x9a, 0xc9, 0x1a, 0x44, 0x1e, 0x40, 0x4b, 0x21, 0xff, 0x24, 0x13, 0xbd, 0x8a, 0xcd,
+	0x0e, 0x42, 0xbf, 0xe3, 0xf5, 0x1a, 0xd1, 0x6e, 0x19, 0x34, 0xa6, 0xc9, 0x04, 0xf6, 0x5d, 0x91,
+	0x93, 0x50, 0xed, 0xf8, 0xf7, 0x78, 0xaf, 0xa0, 0x04, 0xb8, 0xc9, 0x53, 0xd8, 0x53, 0x82, 0xdf,
+	0xe2, 0x86, 0xab, 0x9a, 0x5d, 0xc1, 0x93, 0xdf, 0xb8, 0x9a, 0xd8, 0xae, 0xb5, 0xa5, 0x76, 0xc1,
+	0x28, 0xa9, 0xdd, 0x53, 0x68, 0x8e, 0xa5, 0xf8, 0xbc, 0x5a, 0xef, 0x22, 0x00, 0x5f, 0xb3, 0xb9,
+	0x5d, 0x43, 0x23, 0x32, 0x47, 0x72, 0x00, 0x35, 0xfe, 0x91, 0x25, 0x99, 0x7d, 0xc2, 0x46, 0x54,
+	0x5c, 0xba, 0x5f, 0xcc, 0x12, 0x37, 0xb8, 0x84, 0x40, 0x35, 0x65, 0xf2, 0xd6, 0x76, 0xb6, 0x22,
+	0x7b, 0x26, 0x43, 0xf0, 0xf5, 0x8d, 0xb0, 0x1f, 0x4a, 0x7b, 0xf0, 0xf4, 0x1e, 0x2a, 0xe9, 0x44,
+	0x33, 0x8d, 0x91, 0xe9, 0x26, 0x0f, 0xa1, 0x3d, 0x4b, 0xb2, 0xeb, 0x58, 0x8b, 0xf8, 0x1a, 0x97,
+	0x09, 0x77, 0x2f, 0x6e, 0xa2, 0x53, 0x31, 0xb2, 0x31, 0x72, 0x0a, 0xff, 0x69, 0x9e, 0xc7, 0xb7,
+	0x88, 0x79, 0xcc, 0x16, 0xc9, 0x12, 0x63, 0x0b, 0x5e, 0xb2, 0x45, 0x58, 0xb5, 0x8a, 0x0e, 0x34,
+	0xcf, 0x2f, 0x11, 0xf3, 0x33, 0x93, 0xbc, 0x58, 0xe7, 0xc8, 0x04, 0x76, 0x4d, 0x5b, 0x66, 0xd8,
+	0x0b, 0xb6, 0x0a, 0x6b, 0x7f, 0x2b, 0x15, 0x34, 0xcf, 0x5f, 0x89, 0x91, 0x81, 0x90, 0x47, 0xd0,
+	0x36, 0x50, 0x2e, 0xb2, 0x39, 0x2a, 0x9d, 0x88, 0x2c, 0xdc, 0xb6, 0x8a, 0x5b, 0x9a, 0xe7, 0x43,
+	0x17, 0x24, 0x87, 0x50, 0xbf, 0x91, 0x88, 0xc6, 0x46, 0xb8, 0xd3, 0xf1, 0x7a, 0xf5, 0xc8, 0xdd,
+	0xbb, 0xc7, 0x50, 0xb3, 0x5c, 0x52, 0x87, 0xea, 0x99, 0xba, 0x50, 0xc1, 0x16, 0x01, 0xd8, 0x7e,
+	0x91, 0xb1, 0xd9, 0x02, 0x03, 0x8f, 0x34, 0x61, 0x67, 0x94, 0x28, 0x7b, 0xa9, 0x1c, 0x3f, 0x87,
+	0xfd, 0x5f, 0xfe, 0x07, 0xb2, 0x03, 0xfe, 0x74, 0x38, 0x0e, 0xb6, 0xcc, 0xe1, 0xcd, 0x68, 0x1c,
+	0x78, 0x86, 0x74, 0x75, 0x39, 0x1c, 0x07, 0x15, 0xd2, 0x82, 0xc6, 0x3b, 0x9c, 0x15, 0x26, 0x02,
+	0xff, 0xfc, 0x35, 0xfc, 0xcf, 0x45, 0x7a, 0xb7, 0xe5, 0xb1, 0xf7, 0xbe, 0x5e, 0x9e, 0xbf, 0x57,
+	0x8e, 0xde, 0x0e, 0x22, 0xb6, 0xa2, 0x43, 0x53, 0xeb, 0x46, 0xd3, 0x8b, 0x75, 0x7e, 0xb6, 0x6d,
+	0xff, 0xc5, 0x67, 0x3f, 0x06, 0x00, 0xa4, 0xec, 0xab, 0x5c, 0x10, 0x05, 0x00, 0x00,
 }
@@ -2,8 +2,10 @@ package internet
 
 import (
 	"context"
+	"os"
 
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/proxyproto"
 )
 
 type key int
@@ -13,6 +15,10 @@ const (
 	dialerSrcKey
 	transportSettingsKey
 	securitySettingsKey
+	unixSocketModeKey
+	transportLayerKey
+	dialerIPsKey
+	proxyProtocolKey
 )
 
 func ContextWithStreamSettings(ctx context.Context, streamSettings *StreamConfig) context.Context {
@@ -53,3 +59,63 @@ func ContextWithSecuritySettings(ctx context.Context, securitySettings interface
 func SecuritySettingsFromContext(ctx context.Context) interface{} {
 	return ctx.Value(securitySettingsKey)
 }
+
+func ContextWithUnixSocketMode(ctx context.Context, mode os.FileMode) context.Context {
+	return context.WithValue(ctx, unixSocketModeKey, mode)
+}
+
+// UnixSocketModeFromContext returns the permission bits of Unix domain socket files, or 0 if not set.
+func UnixSocketModeFromContext(ctx context.Context) os.FileMode {
+	if mode, ok := ctx.Value(unixSocketModeKey).(os.FileMode); ok {
+		return mode
+	}
+	return 0
+}
+
+// ContextWithTransportLayer returns a context in which transports create their underlying connections with the given
+// dialer, instead of the system dialer. It allows transports to run over the connections of other outbounds.
+func ContextWithTransportLayer(ctx context.Context, dialer SystemDialer) context.Context {
+	return context.WithValue(ctx, transportLayerKey, dialer)
+}
+
+// TransportLayerFromContext returns the dialer set by ContextWithTransportLayer(), or nil if not set.
+func TransportLayerFromContext(ctx context.Context) SystemDialer {
+	if dialer, ok := ctx.Value(transportLayerKey).(SystemDialer); ok {
+		return dialer
+	}
+	return nil
+}
+
+type dialerIPs struct {
+	domain string
+	ips    []net.Address
+}
+
+// ContextWithDialerIPs returns a context in which the system dialer connects to the given domain through the given
+// IPs, instead of resolving the domain itself. The IPs are tried in parallel with Happy Eyeballs. Other domains, such
+// as the one of a proxy that the connection goes through, are still resolved as usual.
+func ContextWithDialerIPs(ctx context.Context, domain string, ips []net.Address) context.Context {
+	return context.WithValue(ctx, dialerIPsKey, &dialerIPs{domain: domain, ips: ips})
+}
+
+// DialerIPsFromContext returns the IPs set by ContextWithDialerIPs() for the given domain, or nil if not set.
+func DialerIPsFromContext(ctx context.Context, domain string) []net.Address {
+	if v, ok := ctx.Value(dialerIPsKey).(*dialerIPs); ok && v.domain == domain {
+		return v.ips
+	}
+	return nil
+}
+
+// ContextWithProxyProtocol returns a context in which the system dialer writes the given PROXY protocol header to TCP
+// connections, before any transport or security layer runs over them. A nil header writes nothing.
+func ContextWithProxyProtocol(ctx context.Context, header *proxyproto.Header) context.Context {
+	return context.WithValue(ctx, proxyProtocolKey, header)
+}
+
+// ProxyProtocolFromContext returns the header set by ContextWithProxyProtocol(), or nil if not set.
+func ProxyProtocolFromContext(ctx context.Context) *proxyproto.Header {
+	if header, ok := ctx.Value(proxyProtocolKey).(*proxyproto.Header); ok {
+		return header
+	}
+	return nil
+}
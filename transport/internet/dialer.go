@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/proxyproto"
 )
 
 type Dialer func(ctx context.Context, dest net.Destination) (Connection, error)
@@ -50,7 +51,37 @@ func Dial(ctx context.Context, dest net.Destination) (Connection, error) {
 	return udpDialer(ctx, dest)
 }
 
-// DialSystem calls system dialer to create a network connection.
+// DialSystem calls system dialer to create a network connection, or the dialer of the transport layer in the context.
 func DialSystem(ctx context.Context, src net.Address, dest net.Destination) (net.Conn, error) {
-	return effectiveSystemDialer.Dial(ctx, src, dest)
+	var conn net.Conn
+	var err error
+	if dialer := TransportLayerFromContext(ctx); dialer != nil {
+		conn, err = dialer.Dial(ctx, src, dest)
+	} else {
+		conn, err = effectiveSystemDialer.Dial(ctx, src, dest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if header := ProxyProtocolFromContext(ctx); header != nil && dest.Network == net.Network_TCP {
+		if err := writeProxyProtocolHeader(conn, header); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// writeProxyProtocolHeader writes the PROXY protocol header to the connection. The destination in the header is the
+// remote address of the connection, if the header has a source.
+func writeProxyProtocolHeader(conn net.Conn, header *proxyproto.Header) error {
+	h := *header
+	if h.Source.IsValid() {
+		h.Destination = net.DestinationFromAddr(conn.RemoteAddr())
+	}
+	if err := proxyproto.WriteHeader(conn, &h); err != nil {
+		return newError("failed to write PROXY protocol header").Base(err)
+	}
+	return nil
 }
@@ -3,6 +3,7 @@ package internet_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"v2ray.com/core/common/net"
 	"v2ray.com/core/testing/servers/tcp"
@@ -23,3 +24,52 @@ func TestDialWithLocalAddr(t *testing.T) {
 	assert(conn.RemoteAddr().String(), Equals, "127.0.0.1:"+dest.Port.String())
 	conn.Close()
 }
+
+func TestDialHappyEyeballs(t *testing.T) {
+	assert := With(t)
+
+	server := &tcp.Server{}
+	dest, err := server.Start()
+	assert(err, IsNil)
+	defer server.Close()
+
+	deadServer := &tcp.Server{}
+	dead, err := deadServer.Start()
+	assert(err, IsNil)
+	deadServer.Close()
+
+	ctx := ContextWithDialerIPs(context.Background(), "v2ray.com", []net.Address{
+		net.ParseAddress("127.0.0.2"),
+		net.ParseAddress("127.0.0.3"),
+		net.LocalHostIP,
+	})
+	start := time.Now()
+	conn, err := DialSystem(ctx, nil, net.TCPDestination(net.DomainAddress("v2ray.com"), dest.Port))
+	assert(err, IsNil)
+	assert(conn.RemoteAddr().String(), Equals, "127.0.0.1:"+dest.Port.String())
+	// Failed attempts don't wait for the attempt delay.
+	assert(time.Since(start) < time.Millisecond*250, IsTrue)
+	conn.Close()
+
+	_, err = DialSystem(ctx, nil, net.TCPDestination(net.DomainAddress("v2ray.com"), dead.Port))
+	assert(err, IsNotNil)
+}
+
+func TestDialerIPsOnlyForTheirDomain(t *testing.T) {
+	assert := With(t)
+
+	server := &tcp.Server{}
+	dest, err := server.Start()
+	assert(err, IsNil)
+	defer server.Close()
+
+	ctx := ContextWithDialerIPs(context.Background(), "v2ray.com", []net.Address{net.ParseAddress("127.0.0.2")})
+	assert(len(DialerIPsFromContext(ctx, "v2ray.com")), Equals, 1)
+	assert(len(DialerIPsFromContext(ctx, "localhost")), Equals, 0)
+
+	// A next hop to another domain resolves it as usual, instead of going to the IPs of the original destination.
+	conn, err := DialSystem(ctx, nil, net.TCPDestination(net.DomainAddress("localhost"), dest.Port))
+	assert(err, IsNil)
+	assert(conn.RemoteAddr().String(), Equals, "127.0.0.1:"+dest.Port.String())
+	conn.Close()
+}
@@ -0,0 +1,104 @@
+package internet
+
+import (
+	"context"
+	"time"
+
+	"v2ray.com/core/common/net"
+)
+
+// happyEyeballsDelay is the time to wait for a connection attempt, before starting the next one in parallel. It is
+// the Connection Attempt Delay recommended by RFC 8305.
+const happyEyeballsDelay = time.Millisecond * 250
+
+// sortForHappyEyeballs interleaves IPv6 and IPv4 addresses, starting with IPv6, as in RFC 8305 section 4. The
+// relative order of addresses in each family is kept.
+func sortForHappyEyeballs(ips []net.Address) []net.Address {
+	var ipv6, ipv4 []net.Address
+	for _, ip := range ips {
+		if ip.Family().IsIPv6() {
+			ipv6 = append(ipv6, ip)
+		} else {
+			ipv4 = append(ipv4, ip)
+		}
+	}
+
+	sorted := make([]net.Address, 0, len(ips))
+	for len(ipv6) > 0 || len(ipv4) > 0 {
+		if len(ipv6) > 0 {
+			sorted = append(sorted, ipv6[0])
+			ipv6 = ipv6[1:]
+		}
+		if len(ipv4) > 0 {
+			sorted = append(sorted, ipv4[0])
+			ipv4 = ipv4[1:]
+		}
+	}
+	return sorted
+}
+
+type dialResult struct {
+	address net.Address
+	conn    net.Conn
+	err     error
+}
+
+// dialHappyEyeballs dials the port of dest on the given IPs. A new attempt starts when the previous one fails, or
+// doesn't finish in happyEyeballsDelay. The first established connection is returned, and the other attempts are
+// canceled.
+func dialHappyEyeballs(ctx context.Context, dialer *net.Dialer, dest net.Destination, ips []net.Address) (net.Conn, error) {
+	ips = sortForHappyEyeballs(ips)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, len(ips))
+	next := 0
+	pending := 0
+	var delay <-chan time.Time
+	startAttempt := func() {
+		target := dest
+		target.Address = ips[next]
+		next++
+		pending++
+		if next < len(ips) {
+			delay = time.After(happyEyeballsDelay)
+		} else {
+			delay = nil
+		}
+		go func() {
+			conn, err := dialer.DialContext(ctx, target.Network.SystemString(), target.NetAddr())
+			results <- dialResult{address: target.Address, conn: conn, err: err}
+		}()
+	}
+
+	var lastErr error
+	startAttempt()
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				go closeLateConnections(results, pending)
+				return r.conn, nil
+			}
+			lastErr = r.err
+			newError("failed to dial ", dest.Address, " on ", r.address).Base(r.err).AtDebug().WriteToLog()
+			if next < len(ips) {
+				startAttempt()
+			}
+		case <-delay:
+			startAttempt()
+		}
+	}
+	return nil, lastErr
+}
+
+// closeLateConnections closes the connections of attempts that finish after another one is chosen.
+func closeLateConnections(results <-chan dialResult, pending int) {
+	for i := 0; i < pending; i++ {
+		if r := <-results; r.conn != nil {
+			r.conn.Close()
+		}
+	}
+}
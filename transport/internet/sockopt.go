@@ -0,0 +1,65 @@
+package internet
+
+import (
+	"syscall"
+	"time"
+
+	"v2ray.com/core/common/net"
+)
+
+type socketOptionsFunc func(fd uintptr, network string, config *SocketConfig) error
+
+// getControlFunc returns a function to set the options on sockets before they are connected or bound, or nil if
+// there is no option.
+func getControlFunc(config *SocketConfig, apply socketOptionsFunc) func(network, address string, c syscall.RawConn) error {
+	if config == nil {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		var err error
+		if cerr := c.Control(func(fd uintptr) {
+			err = apply(fd, network, config)
+		}); cerr != nil {
+			return cerr
+		}
+		return err
+	}
+}
+
+// getKeepAlive returns the keepalive period of TCP connections, or 0 for the default.
+func (c *SocketConfig) getKeepAlive() time.Duration {
+	return time.Duration(c.GetTcpKeepAliveInterval()) * time.Second
+}
+
+// applyConnOptions sets the options that are overridden by Go when a TCP connection is established.
+func applyConnOptions(conn net.Conn, config *SocketConfig) {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	if keepAlive := config.getKeepAlive(); keepAlive > 0 {
+		tcpConn.SetKeepAlive(true)
+		tcpConn.SetKeepAlivePeriod(keepAlive)
+	}
+	switch config.GetTcpNoDelay() {
+	case SocketConfig_Enable:
+		tcpConn.SetNoDelay(true)
+	case SocketConfig_Disable:
+		tcpConn.SetNoDelay(false)
+	}
+}
+
+// sockoptListener applies the socket options to accepted connections.
+type sockoptListener struct {
+	net.Listener
+	config *SocketConfig
+}
+
+func (l *sockoptListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	applyConnOptions(conn, l.config)
+	return conn, nil
+}
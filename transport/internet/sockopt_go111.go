@@ -0,0 +1,30 @@
+// +build go1.11
+
+package internet
+
+import (
+	"context"
+
+	"v2ray.com/core/common/net"
+)
+
+// setDialerControl sets the socket options on sockets of the dialer before they are connected.
+func setDialerControl(dialer *net.Dialer, config *SocketConfig) {
+	dialer.Control = getControlFunc(config, applyOutboundSocketOptions)
+}
+
+// listenWithControl listens TCP on the given address, with the socket options set before the socket is bound.
+func listenWithControl(ctx context.Context, address string, config *SocketConfig, apply socketOptionsFunc) (net.Listener, error) {
+	lc := &net.ListenConfig{
+		Control: getControlFunc(config, apply),
+	}
+	return lc.Listen(ctx, "tcp", address)
+}
+
+// listenPacketWithControl listens UDP on the given address, with the socket options set before the socket is bound.
+func listenPacketWithControl(ctx context.Context, address string, config *SocketConfig, apply socketOptionsFunc) (net.PacketConn, error) {
+	lc := &net.ListenConfig{
+		Control: getControlFunc(config, apply),
+	}
+	return lc.ListenPacket(ctx, "udp", address)
+}
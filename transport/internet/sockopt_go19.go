@@ -0,0 +1,35 @@
+// +build !go1.11
+
+package internet
+
+import (
+	"context"
+	"sync"
+
+	"v2ray.com/core/common/net"
+)
+
+// Options on sockets before they are connected or bound need Go 1.11. With older versions, only the options that are
+// set on established connections take effect, which are TCP keepalive and no delay.
+
+var warnControlOnce sync.Once
+
+func warnControl() {
+	warnControlOnce.Do(func() {
+		newError("socket options other than TCP keepalive and no delay are ignored, as they need Go 1.11").AtWarning().WriteToLog()
+	})
+}
+
+func setDialerControl(dialer *net.Dialer, config *SocketConfig) {
+	warnControl()
+}
+
+func listenWithControl(ctx context.Context, address string, config *SocketConfig, apply socketOptionsFunc) (net.Listener, error) {
+	warnControl()
+	return net.Listen("tcp", address)
+}
+
+func listenPacketWithControl(ctx context.Context, address string, config *SocketConfig, apply socketOptionsFunc) (net.PacketConn, error) {
+	warnControl()
+	return net.ListenPacket("udp", address)
+}
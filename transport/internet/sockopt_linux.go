@@ -0,0 +1,70 @@
+// +build linux
+
+package internet
+
+import (
+	"strings"
+	"syscall"
+)
+
+const (
+	// TCP_FASTOPEN enables TCP Fast Open on listeners. Its value is the max number of pending requests.
+	tcpFastOpen = 23
+	// TCP_FASTOPEN_CONNECT enables TCP Fast Open on clients, since Linux 4.11.
+	tcpFastOpenConnect = 30
+
+	tcpFastOpenQueueLength = 256
+)
+
+func applySocketOptions(fd int, network string, config *SocketConfig) error {
+	if config.Mark != 0 {
+		if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_MARK, int(config.Mark)); err != nil {
+			return newError("failed to set SO_MARK").Base(err)
+		}
+	}
+	if len(config.BindToDevice) > 0 {
+		if err := syscall.SetsockoptString(fd, syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, config.BindToDevice); err != nil {
+			return newError("failed to bind to device ", config.BindToDevice).Base(err)
+		}
+	}
+	if config.Freebind {
+		// IP_FREEBIND applies to IPv6 sockets as well.
+		if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_FREEBIND, 1); err != nil {
+			return newError("failed to set IP_FREEBIND").Base(err)
+		}
+	}
+	if strings.HasPrefix(network, "tcp") && len(config.TcpCongestion) > 0 {
+		if err := syscall.SetsockoptString(fd, syscall.IPPROTO_TCP, syscall.TCP_CONGESTION, config.TcpCongestion); err != nil {
+			newError("failed to set TCP congestion control ", config.TcpCongestion).Base(err).AtWarning().WriteToLog()
+		}
+	}
+	return nil
+}
+
+func setTCPFastOpen(fd int, network string, option int, enabled int, state SocketConfig_State) {
+	if !strings.HasPrefix(network, "tcp") || state == SocketConfig_AsIs {
+		return
+	}
+	if state == SocketConfig_Disable {
+		enabled = 0
+	}
+	if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, option, enabled); err != nil {
+		newError("failed to set TCP Fast Open").Base(err).AtWarning().WriteToLog()
+	}
+}
+
+func applyOutboundSocketOptions(fd uintptr, network string, config *SocketConfig) error {
+	if err := applySocketOptions(int(fd), network, config); err != nil {
+		return err
+	}
+	setTCPFastOpen(int(fd), network, tcpFastOpenConnect, 1, config.Tfo)
+	return nil
+}
+
+func applyInboundSocketOptions(fd uintptr, network string, config *SocketConfig) error {
+	if err := applySocketOptions(int(fd), network, config); err != nil {
+		return err
+	}
+	setTCPFastOpen(int(fd), network, tcpFastOpen, tcpFastOpenQueueLength, config.Tfo)
+	return nil
+}
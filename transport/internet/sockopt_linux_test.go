@@ -0,0 +1,134 @@
+// +build linux,go1.11
+
+package internet_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"v2ray.com/core/common/net"
+	. "v2ray.com/core/transport/internet"
+	. "v2ray.com/ext/assert"
+)
+
+func getSockoptInt(conn syscall.Conn, level int, option int) (int, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var value int
+	var serr error
+	if err := rawConn.Control(func(fd uintptr) {
+		value, serr = syscall.GetsockoptInt(int(fd), level, option)
+	}); err != nil {
+		return 0, err
+	}
+	return value, serr
+}
+
+func TestSocketOptions(t *testing.T) {
+	assert := With(t)
+
+	ctx := ContextWithStreamSettings(context.Background(), &StreamConfig{
+		SocketSettings: &SocketConfig{
+			Tfo:        SocketConfig_Enable,
+			TcpNoDelay: SocketConfig_Disable,
+		},
+	})
+
+	listener, err := ListenSystem(ctx, net.LocalHostIP, 0)
+	assert(err, IsNil)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}()
+
+	conn, err := DefaultSystemDialer{}.Dial(ctx, nil, net.DestinationFromAddr(listener.Addr()))
+	assert(err, IsNil)
+	defer conn.Close()
+
+	noDelay, err := getSockoptInt(conn.(syscall.Conn), syscall.IPPROTO_TCP, syscall.TCP_NODELAY)
+	assert(err, IsNil)
+	assert(noDelay, Equals, 0)
+
+	// TCP_FASTOPEN_CONNECT
+	tfo, err := getSockoptInt(conn.(syscall.Conn), syscall.IPPROTO_TCP, 30)
+	if err != nil {
+		// TCP Fast Open on clients requires Linux 4.11.
+		t.Skip("unable to get TCP Fast Open: ", err)
+	}
+	assert(tfo, Equals, 1)
+}
+
+func TestKeepAliveOfAcceptedConnections(t *testing.T) {
+	assert := With(t)
+
+	ctx := ContextWithStreamSettings(context.Background(), &StreamConfig{
+		SocketSettings: &SocketConfig{
+			TcpKeepAliveInterval: 42,
+		},
+	})
+
+	listener, err := ListenSystem(ctx, net.LocalHostIP, 0)
+	assert(err, IsNil)
+	defer listener.Close()
+
+	conn, err := net.DialTCP("tcp", nil, listener.Addr().(*net.TCPAddr))
+	assert(err, IsNil)
+	defer conn.Close()
+
+	accepted, err := listener.Accept()
+	assert(err, IsNil)
+	defer accepted.Close()
+
+	keepAlive, err := getSockoptInt(accepted.(syscall.Conn), syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE)
+	assert(err, IsNil)
+	assert(keepAlive, Equals, 42)
+}
+
+func TestSocketMark(t *testing.T) {
+	assert := With(t)
+
+	ctx := ContextWithStreamSettings(context.Background(), &StreamConfig{
+		SocketSettings: &SocketConfig{
+			Mark: 255,
+		},
+	})
+
+	conn, err := ListenSystemPacket(ctx, nil)
+	if err != nil {
+		// Setting marks requires CAP_NET_ADMIN.
+		t.Skip("unable to set mark: ", err)
+	}
+	defer conn.Close()
+
+	mark, err := getSockoptInt(conn.(syscall.Conn), syscall.SOL_SOCKET, syscall.SO_MARK)
+	assert(err, IsNil)
+	assert(mark, Equals, 255)
+}
+
+func TestFreebind(t *testing.T) {
+	assert := With(t)
+
+	// An address of TEST-NET-1, which is not configured on any interface.
+	src := net.ParseAddress("192.0.2.1")
+
+	_, err := ListenSystemPacket(context.Background(), src)
+	assert(err, IsNotNil)
+
+	ctx := ContextWithStreamSettings(context.Background(), &StreamConfig{
+		SocketSettings: &SocketConfig{
+			Freebind: true,
+		},
+	})
+	conn, err := ListenSystemPacket(ctx, src)
+	assert(err, IsNil)
+	defer conn.Close()
+	assert(conn.LocalAddr().(*net.UDPAddr).IP.String(), Equals, "192.0.2.1")
+}
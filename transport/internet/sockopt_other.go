@@ -0,0 +1,13 @@
+// +build !linux
+
+package internet
+
+// Marks, devices, TCP Fast Open, congestion control and freebind are only supported on Linux, and ignored elsewhere.
+
+func applyOutboundSocketOptions(fd uintptr, network string, config *SocketConfig) error {
+	return nil
+}
+
+func applyInboundSocketOptions(fd uintptr, network string, config *SocketConfig) error {
+	return nil
+}
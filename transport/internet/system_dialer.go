@@ -38,7 +38,29 @@ func (DefaultSystemDialer) Dial(ctx context.Context, src net.Address, dest net.D
 		}
 		dialer.LocalAddr = addr
 	}
-	return dialer.DialContext(ctx, dest.Network.SystemString(), dest.NetAddr())
+	sockopt := StreamSettingsFromContext(ctx).GetSocketSettings()
+	if sockopt != nil {
+		setDialerControl(dialer, sockopt)
+		if keepAlive := sockopt.getKeepAlive(); keepAlive > 0 {
+			dialer.KeepAlive = keepAlive
+		}
+	}
+	var conn net.Conn
+	var err error
+	var ips []net.Address
+	if dest.Address.Family().IsDomain() {
+		ips = DialerIPsFromContext(ctx, dest.Address.Domain())
+	}
+	if len(ips) > 0 {
+		conn, err = dialHappyEyeballs(ctx, dialer, dest, ips)
+	} else {
+		conn, err = dialer.DialContext(ctx, dest.Network.SystemString(), dest.NetAddr())
+	}
+	if err != nil {
+		return nil, err
+	}
+	applyConnOptions(conn, sockopt)
+	return conn, nil
 }
 
 type SystemDialerAdapter interface {
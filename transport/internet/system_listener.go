@@ -0,0 +1,122 @@
+package internet
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"v2ray.com/core/common/net"
+)
+
+// IsUnixSocketAddress returns true if the address is the path of a Unix domain socket. Paths starting with "@" are
+// in the abstract namespace.
+func IsUnixSocketAddress(address net.Address) bool {
+	if address == nil || !address.Family().IsDomain() {
+		return false
+	}
+	domain := address.Domain()
+	return strings.HasPrefix(domain, "/") || strings.HasPrefix(domain, "@")
+}
+
+// ListenSystem listens TCP on the given address and port, or the Unix domain socket if the address is a path.
+func ListenSystem(ctx context.Context, address net.Address, port net.Port) (net.Listener, error) {
+	if IsUnixSocketAddress(address) {
+		return listenUnix(ctx, address.Domain())
+	}
+	addr := net.TCPDestination(address, port).NetAddr()
+	sockopt := StreamSettingsFromContext(ctx).GetSocketSettings()
+	if sockopt == nil {
+		return net.Listen("tcp", addr)
+	}
+
+	listener, err := listenWithControl(ctx, addr, sockopt, applyInboundSocketOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &sockoptListener{Listener: listener, config: sockopt}, nil
+}
+
+// ListenSystemUDP listens UDP on the given address and port, with the socket options in the stream settings of the
+// context.
+func ListenSystemUDP(ctx context.Context, address net.Address, port net.Port) (*net.UDPConn, error) {
+	addr := &net.UDPAddr{
+		IP:   address.IP(),
+		Port: int(port),
+	}
+	sockopt := StreamSettingsFromContext(ctx).GetSocketSettings()
+	if sockopt == nil {
+		return net.ListenUDP("udp", addr)
+	}
+
+	conn, err := listenPacketWithControl(ctx, addr.String(), sockopt, applyInboundSocketOptions)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.UDPConn), nil
+}
+
+// ListenSystemPacket opens an unconnected UDP socket on the given source address, which may send packets to any
+// destination. An empty or any source address means all local addresses.
+func ListenSystemPacket(ctx context.Context, src net.Address) (net.PacketConn, error) {
+	addr := &net.UDPAddr{}
+	if src != nil && src != net.AnyIP {
+		addr.IP = src.IP()
+	}
+	sockopt := StreamSettingsFromContext(ctx).GetSocketSettings()
+	if sockopt == nil {
+		return net.ListenUDP("udp", addr)
+	}
+
+	return listenPacketWithControl(ctx, addr.String(), sockopt, applyOutboundSocketOptions)
+}
+
+func listenUnix(ctx context.Context, path string) (net.Listener, error) {
+	abstract := strings.HasPrefix(path, "@")
+	if !abstract {
+		// Remove the socket file left by a previous instance.
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(path); err != nil {
+				return nil, newError("failed to remove existing socket ", path).Base(err)
+			}
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode := UnixSocketModeFromContext(ctx); mode != 0 && !abstract {
+		if err := os.Chmod(path, mode); err != nil {
+			listener.Close()
+			return nil, newError("failed to set permission of socket ", path).Base(err)
+		}
+	}
+
+	return &unixListener{Listener: listener}, nil
+}
+
+// unixListener is a Unix domain socket listener, whose connections report a loopback TCP address as their remote
+// address, as the peers are always on the local machine.
+type unixListener struct {
+	net.Listener
+}
+
+func (l *unixListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &unixConn{Conn: conn}, nil
+}
+
+type unixConn struct {
+	net.Conn
+}
+
+func (c *unixConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{
+		IP:   net.LocalHostIP.IP(),
+		Port: 0,
+	}
+}
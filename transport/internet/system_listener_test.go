@@ -0,0 +1,67 @@
+package internet_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"v2ray.com/core/common/net"
+	. "v2ray.com/core/transport/internet"
+	. "v2ray.com/ext/assert"
+)
+
+func TestIsUnixSocketAddress(t *testing.T) {
+	assert := With(t)
+
+	assert(IsUnixSocketAddress(net.DomainAddress("/run/v2ray.sock")), IsTrue)
+	assert(IsUnixSocketAddress(net.DomainAddress("@v2ray")), IsTrue)
+	assert(IsUnixSocketAddress(net.DomainAddress("v2ray.com")), IsFalse)
+	assert(IsUnixSocketAddress(net.LocalHostIP), IsFalse)
+	assert(IsUnixSocketAddress(nil), IsFalse)
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	assert := With(t)
+
+	dir, err := ioutil.TempDir("", "v2ray")
+	assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.sock")
+	ctx := ContextWithUnixSocketMode(context.Background(), 0600)
+	listener, err := ListenSystem(ctx, net.DomainAddress(path), 0)
+	assert(err, IsNil)
+
+	info, err := os.Stat(path)
+	assert(err, IsNil)
+	assert(info.Mode()&os.ModePerm, Equals, os.FileMode(0600))
+
+	go func() {
+		conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+		if err == nil {
+			conn.Write([]byte("test"))
+			conn.Close()
+		}
+	}()
+
+	conn, err := listener.Accept()
+	assert(err, IsNil)
+	assert(net.DestinationFromAddr(conn.RemoteAddr()), Equals, net.TCPDestination(net.LocalHostIP, 0))
+	b, err := ioutil.ReadAll(conn)
+	assert(err, IsNil)
+	assert(string(b), Equals, "test")
+	conn.Close()
+	listener.Close()
+
+	// Stale socket files are removed when listening again.
+	listener, err = net.Listen("unix", path)
+	assert(err, IsNil)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	listener, err = ListenSystem(context.Background(), net.DomainAddress(path), 0)
+	assert(err, IsNil)
+	listener.Close()
+}
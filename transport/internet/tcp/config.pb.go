@@ -18,6 +18,9 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Config struct {
 	HeaderSettings *v2ray_core_common_serial.TypedMessage `protobuf:"bytes,2,opt,name=header_settings,json=headerSettings" json:"header_settings,omitempty"`
+	// If true, connections must start with a PROXY protocol v1 or v2 header, whose source address is used as the
+	// address of the client.
+	AcceptProxyProtocol bool `protobuf:"varint,3,opt,name=accept_proxy_protocol,json=acceptProxyProtocol" json:"accept_proxy_protocol,omitempty"`
 }
 
 func (m *Config) Reset()                    { *m = Config{} }
@@ -32,6 +35,13 @@ func (m *Config) GetHeaderSettings() *v2ray_core_common_serial.TypedMessage {
 	return nil
 }
 
+func (m *Config) GetAcceptProxyProtocol() bool {
+	if m != nil {
+		return m.AcceptProxyProtocol
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*Config)(nil), "v2ray.core.transport.internet.tcp.Config")
 }
@@ -39,19 +49,21 @@ func init() {
 func init() { proto.RegisterFile("v2ray.com/core/transport/internet/tcp/config.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 223 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x8e, 0xc1, 0x4a, 0xc4, 0x30,
-	0x10, 0x86, 0x69, 0x95, 0x45, 0x2a, 0xa8, 0xec, 0x49, 0x3c, 0xb9, 0x82, 0xe2, 0x69, 0x22, 0xf1,
-	0x0d, 0xdc, 0x93, 0x82, 0x28, 0xb5, 0x78, 0xf0, 0x52, 0xe2, 0xec, 0x58, 0x03, 0x26, 0x13, 0x26,
-	0x83, 0xd0, 0x57, 0xf2, 0x29, 0x65, 0x37, 0x76, 0x11, 0x2f, 0xde, 0xbf, 0xef, 0xfb, 0xff, 0xc6,
-	0x7e, 0x5a, 0x71, 0x23, 0x20, 0x07, 0x83, 0x2c, 0x64, 0x54, 0x5c, 0xcc, 0x89, 0x45, 0x8d, 0x8f,
-	0x4a, 0x12, 0x49, 0x8d, 0x62, 0x32, 0xc8, 0xf1, 0xcd, 0x0f, 0x90, 0x84, 0x95, 0xe7, 0x8b, 0xc9,
-	0x11, 0x82, 0x2d, 0x0f, 0x13, 0x0f, 0x8a, 0xe9, 0xe4, 0xea, 0x4f, 0x16, 0x39, 0x04, 0x8e, 0x26,
-	0x93, 0x78, 0xf7, 0x61, 0x74, 0x4c, 0xb4, 0xea, 0x03, 0xe5, 0xec, 0x06, 0x2a, 0xd1, 0xb3, 0xbe,
-	0x99, 0x2d, 0x37, 0x23, 0xf3, 0x87, 0xe6, 0xf0, 0x9d, 0xdc, 0x8a, 0xa4, 0xcf, 0xa4, 0xea, 0xe3,
-	0x90, 0x8f, 0xeb, 0xd3, 0xea, 0x72, 0xdf, 0x5e, 0xc0, 0xaf, 0xe1, 0x52, 0x84, 0x52, 0x84, 0x6e,
-	0x5d, 0xbc, 0x2f, 0xc1, 0xf6, 0xa0, 0xe8, 0x4f, 0x3f, 0xf6, 0xdd, 0xee, 0x5e, 0x75, 0x54, 0xdf,
-	0xb4, 0xcd, 0x39, 0x72, 0x80, 0x7f, 0xbf, 0x3f, 0x56, 0x2f, 0x3b, 0x8a, 0xe9, 0xab, 0x5e, 0x3c,
-	0xdb, 0xd6, 0x8d, 0xb0, 0x5c, 0xa3, 0xdd, 0x16, 0xbd, 0x9d, 0xd0, 0x0e, 0xd3, 0xeb, 0x6c, 0xf3,
-	0xfd, 0xfa, 0x3b, 0x00, 0x00, 0xff, 0xff, 0x15, 0xf9, 0x1f, 0xa0, 0x46, 0x01, 0x00, 0x00,
+	// 254 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x8f, 0xc1, 0x4a, 0xc3, 0x40,
+	0x10, 0x86, 0x49, 0x2a, 0xa5, 0x44, 0x50, 0x89, 0x08, 0xc5, 0x53, 0x2b, 0x28, 0x3d, 0xed, 0x4a,
+	0x7c, 0x03, 0x7b, 0x52, 0x10, 0x43, 0x0c, 0x1e, 0xbc, 0x84, 0x75, 0x3a, 0xc6, 0x40, 0xb3, 0xb3,
+	0xcc, 0x0e, 0x62, 0x9e, 0xc3, 0xb7, 0xf0, 0x29, 0x25, 0xd9, 0xa6, 0x88, 0x17, 0xcf, 0xf3, 0xfd,
+	0xdf, 0xc7, 0x24, 0xd9, 0x47, 0xc6, 0xa6, 0x53, 0x40, 0xad, 0x06, 0x62, 0xd4, 0xc2, 0xc6, 0x7a,
+	0x47, 0x2c, 0xba, 0xb1, 0x82, 0x6c, 0x51, 0xb4, 0x80, 0xd3, 0x40, 0xf6, 0xad, 0xa9, 0x95, 0x63,
+	0x12, 0x4a, 0x97, 0xe3, 0x86, 0x51, 0xed, 0x79, 0x35, 0xf2, 0x4a, 0xc0, 0x9d, 0x5f, 0xff, 0xd1,
+	0x02, 0xb5, 0x2d, 0x59, 0xed, 0x91, 0x1b, 0xb3, 0xd5, 0xd2, 0x39, 0xdc, 0x54, 0x2d, 0x7a, 0x6f,
+	0x6a, 0x0c, 0xd2, 0x8b, 0xaf, 0x28, 0x99, 0xae, 0x87, 0x4a, 0xfa, 0x98, 0x1c, 0xbf, 0xa3, 0xd9,
+	0x20, 0x57, 0x1e, 0x45, 0x1a, 0x5b, 0xfb, 0x79, 0xbc, 0x88, 0x56, 0x87, 0xd9, 0x95, 0xfa, 0x55,
+	0x0e, 0x4a, 0x15, 0x94, 0xaa, 0xec, 0x95, 0x0f, 0xc1, 0x58, 0x1c, 0x85, 0xf9, 0xd3, 0x6e, 0x9d,
+	0x66, 0xc9, 0x99, 0x01, 0x40, 0x27, 0x95, 0x63, 0xfa, 0xec, 0xaa, 0xa1, 0x08, 0xb4, 0x9d, 0x4f,
+	0x16, 0xd1, 0x6a, 0x56, 0x9c, 0x86, 0x63, 0xde, 0xdf, 0xf2, 0xdd, 0xe9, 0xfe, 0x60, 0x16, 0x9d,
+	0xc4, 0xb7, 0x45, 0x72, 0x09, 0xd4, 0xaa, 0x7f, 0x1f, 0xce, 0xa3, 0x97, 0x89, 0x80, 0xfb, 0x8e,
+	0x97, 0xcf, 0x59, 0x61, 0x3a, 0xb5, 0xee, 0xd1, 0x72, 0x8f, 0xde, 0x8d, 0x68, 0x09, 0xee, 0x75,
+	0x3a, 0xe4, 0x6f, 0x7e, 0x06, 0x00, 0x52, 0x83, 0x53, 0xc1, 0x7b, 0x01, 0x00, 0x00,
 }
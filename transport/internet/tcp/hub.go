@@ -6,13 +6,14 @@ import (
 
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/common/protocol/proxyproto"
 	"v2ray.com/core/common/retry"
 	"v2ray.com/core/transport/internet"
 	"v2ray.com/core/transport/internet/tls"
 )
 
 type TCPListener struct {
-	listener   *net.TCPListener
+	listener   net.Listener
 	tlsConfig  *gotls.Config
 	authConfig internet.ConnectionAuthenticator
 	config     *Config
@@ -20,16 +21,16 @@ type TCPListener struct {
 }
 
 func ListenTCP(ctx context.Context, address net.Address, port net.Port, addConn internet.AddConnection) (internet.Listener, error) {
-	listener, err := net.ListenTCP("tcp", &net.TCPAddr{
-		IP:   address.IP(),
-		Port: int(port),
-	})
+	listener, err := internet.ListenSystem(ctx, address, port)
 	if err != nil {
 		return nil, err
 	}
-	newError("listening TCP on ", address, ":", port).WriteToLog()
+	newError("listening TCP on ", listener.Addr()).WriteToLog()
 	networkSettings := internet.TransportSettingsFromContext(ctx)
 	tcpSettings := networkSettings.(*Config)
+	if tcpSettings.AcceptProxyProtocol {
+		listener = &proxyproto.Listener{Listener: listener}
+	}
 
 	l := &TCPListener{
 		listener: listener,
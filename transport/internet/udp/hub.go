@@ -6,6 +6,7 @@ import (
 	"v2ray.com/core/common/buf"
 	"v2ray.com/core/common/dice"
 	"v2ray.com/core/common/net"
+	"v2ray.com/core/transport/internet"
 )
 
 // Payload represents a single UDP payload.
@@ -79,14 +80,12 @@ type Hub struct {
 	option ListenOption
 }
 
-func ListenUDP(address net.Address, port net.Port, option ListenOption) (*Hub, error) {
+// ListenUDP listens UDP on the given address and port, with the socket options in the stream settings of the context.
+func ListenUDP(ctx context.Context, address net.Address, port net.Port, option ListenOption) (*Hub, error) {
 	if option.Concurrency < 1 {
 		option.Concurrency = 1
 	}
-	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{
-		IP:   address.IP(),
-		Port: int(port),
-	})
+	udpConn, err := internet.ListenSystemUDP(ctx, address, port)
 	if err != nil {
 		return nil, err
 	}
@@ -105,14 +104,14 @@ func ListenUDP(address net.Address, port net.Port, option ListenOption) (*Hub, e
 			return nil, newError("failed to control socket").Base(err)
 		}
 	}
-	ctx, cancel := context.WithCancel(context.Background())
+	hubCtx, cancel := context.WithCancel(context.Background())
 	hub := &Hub{
 		conn:   udpConn,
 		queue:  NewPayloadQueue(option),
 		option: option,
 		cancel: cancel,
 	}
-	go hub.start(ctx)
+	go hub.start(hubCtx)
 	return hub, nil
 }
 
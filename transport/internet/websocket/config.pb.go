@@ -43,6 +43,9 @@ type Config struct {
 	// URL path to the WebSocket service. Empty value means root(/).
 	Path   string    `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
 	Header []*Header `protobuf:"bytes,3,rep,name=header" json:"header,omitempty"`
+	// If true, connections must start with a PROXY protocol v1 or v2 header, whose source address is used as the
+	// address of the client.
+	AcceptProxyProtocol bool `protobuf:"varint,4,opt,name=accept_proxy_protocol,json=acceptProxyProtocol" json:"accept_proxy_protocol,omitempty"`
 }
 
 func (m *Config) Reset()                    { *m = Config{} }
@@ -64,6 +67,13 @@ func (m *Config) GetHeader() []*Header {
 	return nil
 }
 
+func (m *Config) GetAcceptProxyProtocol() bool {
+	if m != nil {
+		return m.AcceptProxyProtocol
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*Header)(nil), "v2ray.core.transport.internet.websocket.Header")
 	proto.RegisterType((*Config)(nil), "v2ray.core.transport.internet.websocket.Config")
@@ -74,20 +84,22 @@ func init() {
 }
 
 var fileDescriptor0 = []byte{
-	// 229 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xb2, 0x28, 0x33, 0x2a, 0x4a,
-	0xac, 0xd4, 0x4b, 0xce, 0xcf, 0xd5, 0x4f, 0xce, 0x2f, 0x4a, 0xd5, 0x2f, 0x29, 0x4a, 0xcc, 0x2b,
-	0x2e, 0xc8, 0x2f, 0x2a, 0xd1, 0xcf, 0xcc, 0x2b, 0x49, 0x2d, 0xca, 0x4b, 0x2d, 0xd1, 0x2f, 0x4f,
-	0x4d, 0x2a, 0xce, 0x4f, 0xce, 0x4e, 0x2d, 0xd1, 0x4f, 0xce, 0xcf, 0x4b, 0xcb, 0x4c, 0xd7, 0x2b,
-	0x28, 0xca, 0x2f, 0xc9, 0x17, 0x52, 0x87, 0xe9, 0x2c, 0x4a, 0xd5, 0x83, 0xeb, 0xd2, 0x83, 0xe9,
-	0xd2, 0x83, 0xeb, 0x52, 0x32, 0xe0, 0x62, 0xf3, 0x48, 0x4d, 0x4c, 0x49, 0x2d, 0x12, 0x12, 0xe0,
-	0x62, 0xce, 0x4e, 0xad, 0x94, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0x02, 0x31, 0x85, 0x44, 0xb8,
-	0x58, 0xcb, 0x12, 0x73, 0x4a, 0x53, 0x25, 0x98, 0xc0, 0x62, 0x10, 0x8e, 0x52, 0x36, 0x17, 0x9b,
-	0x33, 0xd8, 0x2a, 0x21, 0x21, 0x2e, 0x96, 0x82, 0xc4, 0x92, 0x0c, 0xa8, 0x34, 0x98, 0x2d, 0xe4,
-	0xce, 0xc5, 0x96, 0x01, 0x36, 0x4f, 0x82, 0x59, 0x81, 0x59, 0x83, 0xdb, 0x48, 0x5f, 0x8f, 0x48,
-	0x97, 0xe8, 0x41, 0x9c, 0x11, 0x04, 0xd5, 0xee, 0xc5, 0xc2, 0xc1, 0x28, 0xc0, 0xe4, 0x94, 0xc2,
-	0xa5, 0x9d, 0x9c, 0x9f, 0x4b, 0xac, 0x19, 0x01, 0x8c, 0x51, 0x9c, 0x70, 0xce, 0x2a, 0x26, 0xf5,
-	0x30, 0xa3, 0xa0, 0xc4, 0x4a, 0x3d, 0x67, 0x90, 0xb6, 0x10, 0xb8, 0x36, 0x4f, 0x98, 0xb6, 0x70,
-	0x98, 0xca, 0x24, 0x36, 0x70, 0xa0, 0x19, 0x03, 0x02, 0x00, 0x00, 0xff, 0xff, 0xf5, 0x7e, 0x60,
-	0xf9, 0x70, 0x01, 0x00, 0x00,
+	// 258 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x8f, 0x3d, 0x4f, 0xc3, 0x30,
+	0x10, 0x86, 0xe5, 0x24, 0x44, 0xad, 0x59, 0x2a, 0x03, 0x52, 0xc6, 0xa8, 0x4b, 0x23, 0x21, 0xd9,
+	0x28, 0x2c, 0xcc, 0x74, 0xe0, 0x63, 0x8a, 0x22, 0x04, 0x12, 0x4b, 0xe5, 0xba, 0x07, 0xad, 0xda,
+	0xe6, 0xac, 0xab, 0x29, 0xe4, 0xd7, 0xb0, 0xf3, 0x2b, 0x51, 0x9c, 0x8f, 0xb9, 0xdb, 0x9d, 0x1f,
+	0x3f, 0x7e, 0x5f, 0xf3, 0xbb, 0x63, 0x4e, 0xba, 0x96, 0x06, 0xf7, 0xca, 0x20, 0x81, 0x72, 0xa4,
+	0xab, 0x83, 0x45, 0x72, 0x6a, 0x53, 0x39, 0xa0, 0x0a, 0x9c, 0xfa, 0x86, 0xe5, 0x01, 0xcd, 0x16,
+	0x9c, 0x32, 0x58, 0x7d, 0x6c, 0x3e, 0xa5, 0x25, 0x74, 0x28, 0x66, 0xbd, 0x49, 0x20, 0x07, 0x4b,
+	0xf6, 0x96, 0x1c, 0xac, 0xe9, 0x0d, 0x8f, 0x1f, 0x41, 0xaf, 0x80, 0xc4, 0x84, 0x87, 0x5b, 0xa8,
+	0x13, 0x96, 0xb2, 0x6c, 0x5c, 0x36, 0xa3, 0xb8, 0xe4, 0x67, 0x47, 0xbd, 0xfb, 0x82, 0x24, 0xf0,
+	0x67, 0xed, 0x32, 0xfd, 0x65, 0x3c, 0x9e, 0xfb, 0x2c, 0x21, 0x78, 0x64, 0xb5, 0x5b, 0x77, 0xdc,
+	0xcf, 0xe2, 0x81, 0xc7, 0x6b, 0xff, 0x60, 0x12, 0xa6, 0x61, 0x76, 0x9e, 0x2b, 0x79, 0x62, 0x15,
+	0xd9, 0xf6, 0x28, 0x3b, 0x5d, 0xe4, 0xfc, 0x4a, 0x1b, 0x03, 0xd6, 0x2d, 0x2c, 0xe1, 0x4f, 0xbd,
+	0xf0, 0x1f, 0x33, 0xb8, 0x4b, 0xa2, 0x94, 0x65, 0xa3, 0xf2, 0xa2, 0x85, 0x45, 0xc3, 0x8a, 0x0e,
+	0x3d, 0x47, 0x23, 0x36, 0x09, 0xee, 0x57, 0xfc, 0xda, 0xe0, 0xfe, 0xd4, 0xdc, 0x82, 0xbd, 0x8f,
+	0x87, 0xe5, 0x2f, 0x98, 0xbd, 0xe6, 0xa5, 0xae, 0xe5, 0xbc, 0xd1, 0x5e, 0x06, 0xed, 0xa9, 0xd7,
+	0xde, 0xfa, 0x9b, 0xcb, 0xd8, 0x17, 0xba, 0xfd, 0x1f, 0x00, 0x5e, 0x44, 0xde, 0x23, 0xa5, 0x01,
+	0x00, 0x00,
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/tls"
 	"net/http"
-	"strconv"
 	"sync"
 	"time"
 
@@ -12,6 +11,7 @@ import (
 	"v2ray.com/core/common"
 	"v2ray.com/core/common/net"
 	http_proto "v2ray.com/core/common/protocol/http"
+	"v2ray.com/core/common/protocol/proxyproto"
 	"v2ray.com/core/transport/internet"
 	v2tls "v2ray.com/core/transport/internet/tls"
 )
@@ -69,26 +69,21 @@ func ListenWS(ctx context.Context, address net.Address, port net.Port, addConn i
 		l.tlsConfig = config.GetTLSConfig()
 	}
 
-	err := l.listenws(address, port)
+	err := l.listenws(ctx, address, port)
 
 	return l, err
 }
 
-func (ln *Listener) listenws(address net.Address, port net.Port) error {
-	netAddr := address.String() + ":" + strconv.Itoa(int(port.Value()))
-	var listener net.Listener
-	if ln.tlsConfig == nil {
-		l, err := net.Listen("tcp", netAddr)
-		if err != nil {
-			return newError("failed to listen TCP ", netAddr).Base(err)
-		}
-		listener = l
-	} else {
-		l, err := tls.Listen("tcp", netAddr, ln.tlsConfig)
-		if err != nil {
-			return newError("failed to listen TLS ", netAddr).Base(err)
-		}
-		listener = l
+func (ln *Listener) listenws(ctx context.Context, address net.Address, port net.Port) error {
+	listener, err := internet.ListenSystem(ctx, address, port)
+	if err != nil {
+		return newError("failed to listen on ", address, ":", port).Base(err)
+	}
+	if ln.config.AcceptProxyProtocol {
+		listener = &proxyproto.Listener{Listener: listener}
+	}
+	if ln.tlsConfig != nil {
+		listener = tls.NewListener(listener, ln.tlsConfig)
 	}
 	ln.listener = listener
 
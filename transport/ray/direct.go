@@ -60,6 +60,7 @@ type Stream struct {
 	ctx         context.Context
 	readSignal  *signal.Notifier
 	writeSignal *signal.Notifier
+	dataSignal  *signal.Notifier
 	close       bool
 	err         bool
 }
@@ -70,6 +71,7 @@ func NewStream(ctx context.Context) *Stream {
 		ctx:         ctx,
 		readSignal:  signal.NewNotifier(),
 		writeSignal: signal.NewNotifier(),
+		dataSignal:  signal.NewNotifier(),
 		size:        0,
 	}
 }
@@ -106,6 +108,17 @@ func (s *Stream) Peek(b *buf.Buffer) {
 	}))
 }
 
+// DataSignal returns a channel that is signaled when more data is written into the Stream, or the Stream is closed.
+// Unlike reading, it doesn't consume any data, so it can be used together with Peek.
+func (s *Stream) DataSignal() <-chan bool {
+	return s.dataSignal.Wait()
+}
+
+// ReadSignal returns a channel that is signaled when data is read from the Stream, or the Stream is closed.
+func (s *Stream) ReadSignal() <-chan bool {
+	return s.readSignal.Wait()
+}
+
 // ReadMultiBuffer reads data from the Stream.
 func (s *Stream) ReadMultiBuffer() (buf.MultiBuffer, error) {
 	for {
@@ -169,7 +182,10 @@ func (s *Stream) waitForStreamSize() error {
 		case <-s.ctx.Done():
 			return io.ErrClosedPipe
 		case <-s.readSignal.Wait():
-			if s.err || s.close {
+			s.access.RLock()
+			closed := s.err || s.close
+			s.access.RUnlock()
+			if closed {
 				return io.ErrClosedPipe
 			}
 		}
@@ -204,6 +220,7 @@ func (s *Stream) WriteMultiBuffer(data buf.MultiBuffer) error {
 	s.data.AppendMulti(data)
 	s.size += uint64(data.Len())
 	s.writeSignal.Signal()
+	s.dataSignal.Signal()
 
 	return nil
 }
@@ -214,6 +231,7 @@ func (s *Stream) Close() {
 	s.close = true
 	s.readSignal.Signal()
 	s.writeSignal.Signal()
+	s.dataSignal.Signal()
 	s.access.Unlock()
 }
 
@@ -228,5 +246,6 @@ func (s *Stream) CloseError() {
 	}
 	s.readSignal.Signal()
 	s.writeSignal.Signal()
+	s.dataSignal.Signal()
 	s.access.Unlock()
 }
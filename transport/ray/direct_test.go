@@ -47,3 +47,36 @@ func TestStreamClose(t *testing.T) {
 	_, err = stream.ReadMultiBuffer()
 	assert(err, Equals, io.EOF)
 }
+
+func TestStreamDataSignal(t *testing.T) {
+	assert := With(t)
+
+	stream := NewStream(context.Background())
+	b1 := buf.New()
+	b1.AppendBytes('a')
+	assert(stream.WriteMultiBuffer(buf.NewMultiBufferValue(b1)), IsNil)
+
+	select {
+	case <-stream.DataSignal():
+	default:
+		t.Error("no signal after write")
+	}
+
+	payload := buf.New()
+	defer payload.Release()
+	stream.Peek(payload)
+	assert(payload.String(), Equals, "a")
+
+	select {
+	case <-stream.DataSignal():
+		t.Error("unexpected signal")
+	default:
+	}
+
+	stream.Close()
+	select {
+	case <-stream.DataSignal():
+	default:
+		t.Error("no signal after close")
+	}
+}
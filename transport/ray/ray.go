@@ -43,9 +43,26 @@ type InputStream interface {
 	buf.TimeoutReader
 	RayStream
 	Peek(*buf.Buffer)
+	// DataSignal returns a channel that is signaled when more data is available for Peek.
+	DataSignal() <-chan bool
 }
 
 type OutputStream interface {
 	buf.Writer
 	RayStream
 }
+
+// ErrorCloser is implemented by streams that keep the error that ends them.
+type ErrorCloser interface {
+	// CloseWithError closes the stream with error as CloseError() does, and keeps the given error.
+	CloseWithError(err error)
+}
+
+// CloseWithError closes the stream with error. The given error is kept if the stream is an ErrorCloser.
+func CloseWithError(s RayStream, err error) {
+	if c, ok := s.(ErrorCloser); ok {
+		c.CloseWithError(err)
+		return
+	}
+	s.CloseError()
+}